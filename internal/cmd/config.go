@@ -7,9 +7,12 @@ import (
 	"strings"
 
 	k "github.com/dhth/kplay/internal/kafka"
+	s "github.com/dhth/kplay/internal/serde"
 	t "github.com/dhth/kplay/internal/types"
 	"github.com/dhth/kplay/internal/utils"
 	yaml "github.com/goccy/go-yaml"
+	"github.com/linkedin/goavro/v2"
+	"google.golang.org/protobuf/encoding/protowire"
 	"google.golang.org/protobuf/reflect/protoreflect"
 )
 
@@ -23,6 +26,19 @@ var (
 	errCouldntReadDescriptorSetFile       = errors.New("couldn't read descriptor set file")
 	ErrIssueWithProtobufFileDescriptorSet = errors.New("there's an issue with the file descriptor set")
 	errDescriptorNameIsInvalid            = errors.New("descriptor name is invalid")
+	errKeyProtoConfigMissing              = errors.New("key protobuf config missing")
+	errKeyAvroConfigMissing               = errors.New("key avro config missing")
+	errCouldntReadAvroSchemaFile          = errors.New("couldn't read avro schema file")
+	errAvroSchemaIsInvalid                = errors.New("avro schema is invalid")
+	errHeaderKeyEmpty                     = errors.New("header key cannot be empty")
+	errDuplicateHeaderRule                = errors.New("duplicate header rule")
+	errHeaderProtoConfigMissing           = errors.New("header protobuf config missing")
+	errValidationNotSupported             = errors.New("validation is not supported for this encoding format")
+	errJSONSchemaFileMissing              = errors.New("JSON schema file missing")
+	errInvalidValidationOption            = errors.New("validation option is not applicable to this encoding format")
+	errRequiredFieldOptionInvalid         = errors.New("required field option is invalid")
+	errRedactionNotSupported              = errors.New("redaction is not supported for this encoding format")
+	errRedactionWithUnknownFields         = errors.New("redaction can't be used along with showUnknownFields, since unknown fields can't be redacted")
 )
 
 type kplayConfig struct {
@@ -30,17 +46,56 @@ type kplayConfig struct {
 }
 
 type profile struct {
-	Name           string
-	Authentication string
-	EncodingFormat string       `yaml:"encodingFormat"`
-	ProtoConfig    *protoConfig `yaml:"protoConfig"`
-	Brokers        []string
-	Topic          string
+	Name             string
+	Authentication   string
+	EncodingFormat   string       `yaml:"encodingFormat"`
+	ProtoConfig      *protoConfig `yaml:"protoConfig"`
+	ValueCompression string       `yaml:"valueCompression"`
+	KeyEncoding      string       `yaml:"keyEncoding"`
+	KeyProtoConfig   *protoConfig `yaml:"keyProtoConfig"`
+	KeyAvroConfig    *avroConfig  `yaml:"keyAvroConfig"`
+	Headers          []headerRule
+	Validation       *validationConfig
+	Redaction        []redactionRule
+	RedactionHashKey string `yaml:"redactionHashKey"`
+	Brokers          []string
+	Topic            string
 }
 
 type protoConfig struct {
-	DescriptorSetFile string `yaml:"descriptorSetFile"`
-	DescriptorName    string `yaml:"descriptorName"`
+	DescriptorSetFile string            `yaml:"descriptorSetFile"`
+	DescriptorName    string            `yaml:"descriptorName"`
+	JSONOptions       *protoJSONOptions `yaml:"jsonOptions"`
+	RawDecodeFormat   string            `yaml:"rawDecodeFormat"`
+}
+
+type protoJSONOptions struct {
+	EmitUnpopulated   bool `yaml:"emitUnpopulated"`
+	UseProtoNames     bool `yaml:"useProtoNames"`
+	UseEnumNumbers    bool `yaml:"useEnumNumbers"`
+	ShowUnknownFields bool `yaml:"showUnknownFields"`
+}
+
+type avroConfig struct {
+	SchemaFile string `yaml:"schemaFile"`
+}
+
+type validationConfig struct {
+	JSONSchemaFile      string `yaml:"jsonSchemaFile"`
+	RejectUnknownFields bool   `yaml:"rejectUnknownFields"`
+	CheckRequiredFields bool   `yaml:"checkRequiredFields"`
+	RequiredFieldOption string `yaml:"requiredFieldOption"`
+}
+
+type redactionRule struct {
+	Path   string
+	Action string
+}
+
+type headerRule struct {
+	Key         string
+	Encoding    string
+	ProtoConfig *protoConfig `yaml:"protoConfig"`
 }
 
 func ParseProfileConfig(bytes []byte, profileName string, homeDir string) (t.Config, error) {
@@ -104,6 +159,16 @@ func parseConfig(kConfig kplayConfig, profileName string, homeDir string) (t.Con
 			return config, err
 		}
 
+		valueCompression, err := t.ValidateValueCompressionValue(pr.ValueCompression)
+		if err != nil {
+			return config, err
+		}
+
+		keyEncodingFmt, err := t.ValidateKeyEncodingFmtValue(pr.KeyEncoding)
+		if err != nil {
+			return config, err
+		}
+
 		if len(pr.Brokers) == 0 {
 			return config, errBrokersEmpty
 		}
@@ -112,59 +177,316 @@ func parseConfig(kConfig kplayConfig, profileName string, homeDir string) (t.Con
 			return config, errTopicEmpty
 		}
 
+		config = t.Config{
+			Name:           profileName,
+			Authentication: auth,
+			Encoding:       encodingFmt,
+			Compression:    valueCompression,
+			KeyEncoding:    keyEncodingFmt,
+			Brokers:        pr.Brokers,
+			Topic:          pr.Topic,
+		}
+
 		if encodingFmt == t.Protobuf {
 			if pr.ProtoConfig == nil {
 				return config, errProtoConfigMissing
 			}
 
-			if strings.TrimSpace(pr.ProtoConfig.DescriptorSetFile) == "" {
-				return config, fmt.Errorf("protobuf descriptor set file is empty/missing")
+			protoCfg, err := parseProtoConfig(*pr.ProtoConfig, homeDir)
+			if err != nil {
+				return config, err
 			}
 
-			pr.ProtoConfig.DescriptorSetFile = utils.ExpandTilde(os.ExpandEnv(pr.ProtoConfig.DescriptorSetFile), homeDir)
+			config.Proto = &protoCfg
+		}
 
-			if strings.TrimSpace(pr.ProtoConfig.DescriptorName) == "" {
-				return config, fmt.Errorf("protobuf descriptor name is empty/missing")
+		switch keyEncodingFmt {
+		case t.KeyProtobuf:
+			if pr.KeyProtoConfig == nil {
+				return config, errKeyProtoConfigMissing
 			}
 
-			descriptorBytes, err := os.ReadFile(pr.ProtoConfig.DescriptorSetFile)
+			keyProtoCfg, err := parseProtoConfig(*pr.KeyProtoConfig, homeDir)
 			if err != nil {
-				return config, fmt.Errorf("%w: %s", errCouldntReadDescriptorSetFile, err.Error())
+				return config, fmt.Errorf("key: %w", err)
 			}
 
-			descriptorName := protoreflect.FullName(pr.ProtoConfig.DescriptorName)
-			if !descriptorName.IsValid() {
-				return config, errDescriptorNameIsInvalid
+			config.KeyProto = &keyProtoCfg
+		case t.KeyAvro:
+			if pr.KeyAvroConfig == nil {
+				return config, errKeyAvroConfigMissing
 			}
 
-			msgDescriptor, err := k.GetDescriptorFromDescriptorSet(descriptorBytes, descriptorName)
+			keyAvroCfg, err := parseAvroConfig(*pr.KeyAvroConfig, homeDir)
 			if err != nil {
-				return config, fmt.Errorf("%w: %s", ErrIssueWithProtobufFileDescriptorSet, err.Error())
+				return config, fmt.Errorf("key: %w", err)
 			}
 
-			protoCfg := t.ProtoConfig{
-				DescriptorSetFile: pr.ProtoConfig.DescriptorSetFile,
-				DescriptorName:    pr.ProtoConfig.DescriptorName,
-				MsgDescriptor:     msgDescriptor,
+			config.KeyAvro = &keyAvroCfg
+		}
+
+		if len(pr.Headers) > 0 {
+			headerRules, err := parseHeaderRules(pr.Headers, homeDir)
+			if err != nil {
+				return config, err
 			}
-			return t.Config{
-				Name:           profileName,
-				Authentication: auth,
-				Encoding:       encodingFmt,
-				Brokers:        pr.Brokers,
-				Topic:          pr.Topic,
-				Proto:          &protoCfg,
-			}, nil
+
+			config.HeaderRules = headerRules
 		}
 
-		return t.Config{
-			Name:           profileName,
-			Authentication: auth,
-			Encoding:       encodingFmt,
-			Brokers:        pr.Brokers,
-			Topic:          pr.Topic,
-		}, nil
+		if pr.Validation != nil {
+			validationCfg, err := parseValidationConfig(*pr.Validation, config.Encoding, config.Proto, homeDir)
+			if err != nil {
+				return config, fmt.Errorf("validation: %w", err)
+			}
+
+			config.Validation = &validationCfg
+		}
+
+		if len(pr.Redaction) > 0 {
+			hashKey := os.ExpandEnv(pr.RedactionHashKey)
+			redactionRules, err := parseRedactionRules(pr.Redaction, config.Encoding, config.Proto, []byte(hashKey))
+			if err != nil {
+				return config, fmt.Errorf("redaction: %w", err)
+			}
+
+			config.Redaction = redactionRules
+		}
+
+		return config, nil
 	}
 
 	return config, fmt.Errorf("%w; available profiles: %v", errProfileNotFound, availableProfiles)
 }
+
+func parseProtoConfig(pc protoConfig, homeDir string) (t.ProtoConfig, error) {
+	var protoCfg t.ProtoConfig
+
+	if strings.TrimSpace(pc.DescriptorSetFile) == "" {
+		return protoCfg, fmt.Errorf("protobuf descriptor set file is empty/missing")
+	}
+
+	descriptorSetFile := utils.ExpandTilde(os.ExpandEnv(pc.DescriptorSetFile), homeDir)
+
+	if strings.TrimSpace(pc.DescriptorName) == "" {
+		return protoCfg, fmt.Errorf("protobuf descriptor name is empty/missing")
+	}
+
+	descriptorBytes, err := os.ReadFile(descriptorSetFile)
+	if err != nil {
+		return protoCfg, fmt.Errorf("%w: %s", errCouldntReadDescriptorSetFile, err.Error())
+	}
+
+	descriptorName := protoreflect.FullName(pc.DescriptorName)
+	if !descriptorName.IsValid() {
+		return protoCfg, errDescriptorNameIsInvalid
+	}
+
+	msgDescriptor, resolver, err := k.GetDescriptorFromDescriptorSet(descriptorBytes, descriptorName)
+	if err != nil {
+		return protoCfg, fmt.Errorf("%w: %s", ErrIssueWithProtobufFileDescriptorSet, err.Error())
+	}
+
+	rawDecodeFormat, err := t.ValidateRawDecodeFmtValue(pc.RawDecodeFormat)
+	if err != nil {
+		return protoCfg, err
+	}
+
+	var jsonOptions s.ProtoJSONOptions
+	if pc.JSONOptions != nil {
+		jsonOptions = s.ProtoJSONOptions{
+			EmitUnpopulated:   pc.JSONOptions.EmitUnpopulated,
+			UseProtoNames:     pc.JSONOptions.UseProtoNames,
+			UseEnumNumbers:    pc.JSONOptions.UseEnumNumbers,
+			ShowUnknownFields: pc.JSONOptions.ShowUnknownFields,
+		}
+	}
+
+	return t.ProtoConfig{
+		DescriptorSetFile: descriptorSetFile,
+		DescriptorName:    pc.DescriptorName,
+		MsgDescriptor:     msgDescriptor,
+		Resolver:          resolver,
+		JSONOptions:       jsonOptions,
+		RawDecodeFormat:   rawDecodeFormat,
+	}, nil
+}
+
+func parseHeaderRules(rules []headerRule, homeDir string) (map[string]t.HeaderRule, error) {
+	headerRules := make(map[string]t.HeaderRule)
+
+	for _, hr := range rules {
+		if strings.TrimSpace(hr.Key) == "" {
+			return nil, errHeaderKeyEmpty
+		}
+
+		if _, ok := headerRules[hr.Key]; ok {
+			return nil, fmt.Errorf("%w: %q", errDuplicateHeaderRule, hr.Key)
+		}
+
+		encodingFmt, err := t.ValidateHeaderEncodingFmtValue(hr.Encoding)
+		if err != nil {
+			return nil, fmt.Errorf("header %q: %w", hr.Key, err)
+		}
+
+		rule := t.HeaderRule{
+			Key:      hr.Key,
+			Encoding: encodingFmt,
+		}
+
+		if encodingFmt == t.HeaderProtobuf {
+			if hr.ProtoConfig == nil {
+				return nil, fmt.Errorf("%w for header %q", errHeaderProtoConfigMissing, hr.Key)
+			}
+
+			protoCfg, err := parseProtoConfig(*hr.ProtoConfig, homeDir)
+			if err != nil {
+				return nil, fmt.Errorf("header %q: %w", hr.Key, err)
+			}
+
+			rule.Proto = &protoCfg
+		}
+
+		headerRules[hr.Key] = rule
+	}
+
+	return headerRules, nil
+}
+
+func parseAvroConfig(ac avroConfig, homeDir string) (t.AvroConfig, error) {
+	var avroCfg t.AvroConfig
+
+	if strings.TrimSpace(ac.SchemaFile) == "" {
+		return avroCfg, fmt.Errorf("avro schema file is empty/missing")
+	}
+
+	schemaFile := utils.ExpandTilde(os.ExpandEnv(ac.SchemaFile), homeDir)
+
+	schemaBytes, err := os.ReadFile(schemaFile)
+	if err != nil {
+		return avroCfg, fmt.Errorf("%w: %s", errCouldntReadAvroSchemaFile, err.Error())
+	}
+
+	codec, err := goavro.NewCodec(string(schemaBytes))
+	if err != nil {
+		return avroCfg, fmt.Errorf("%w: %s", errAvroSchemaIsInvalid, err.Error())
+	}
+
+	return t.AvroConfig{
+		SchemaFile: schemaFile,
+		Codec:      codec,
+	}, nil
+}
+
+func parseValidationConfig(vc validationConfig, encodingFmt t.EncodingFormat, protoCfg *t.ProtoConfig, homeDir string) (t.ValidationConfig, error) {
+	var validationCfg t.ValidationConfig
+
+	switch encodingFmt {
+	case t.JSON:
+		if vc.RejectUnknownFields || vc.CheckRequiredFields || vc.RequiredFieldOption != "" {
+			return validationCfg, fmt.Errorf("%w: only jsonSchemaFile can be used for JSON profiles", errInvalidValidationOption)
+		}
+
+		if strings.TrimSpace(vc.JSONSchemaFile) == "" {
+			return validationCfg, errJSONSchemaFileMissing
+		}
+
+		schemaFile := utils.ExpandTilde(os.ExpandEnv(vc.JSONSchemaFile), homeDir)
+		schema, err := s.CompileJSONSchema(schemaFile)
+		if err != nil {
+			return validationCfg, err
+		}
+
+		validationCfg.JSONSchemaFile = schemaFile
+		validationCfg.JSONSchema = schema
+	case t.Protobuf:
+		if vc.JSONSchemaFile != "" {
+			return validationCfg, fmt.Errorf("%w: jsonSchemaFile can only be used for JSON profiles", errInvalidValidationOption)
+		}
+
+		validationCfg.Proto = s.ProtoValidationOptions{
+			RejectUnknownFields: vc.RejectUnknownFields,
+			CheckRequiredFields: vc.CheckRequiredFields,
+		}
+
+		if vc.RequiredFieldOption != "" {
+			number, err := getRequiredFieldOptionNumber(vc.RequiredFieldOption, protoCfg)
+			if err != nil {
+				return validationCfg, err
+			}
+			validationCfg.RequiredFieldOption = vc.RequiredFieldOption
+			validationCfg.Proto.RequiredFieldOption = number
+		}
+
+		// missing required fields are reported as violations rather than
+		// causing decoding to fail
+		if vc.CheckRequiredFields && protoCfg != nil {
+			protoCfg.JSONOptions.AllowPartial = true
+		}
+	default:
+		return validationCfg, errValidationNotSupported
+	}
+
+	return validationCfg, nil
+}
+
+func parseRedactionRules(rules []redactionRule, encodingFmt t.EncodingFormat, protoCfg *t.ProtoConfig, hashKey []byte) ([]s.RedactionRule, error) {
+	var msgDescriptor protoreflect.MessageDescriptor
+	switch encodingFmt {
+	case t.JSON:
+	case t.Protobuf:
+		if protoCfg != nil {
+			// unknown fields are shown as their raw wire data, which redaction
+			// rules can't reach
+			if protoCfg.JSONOptions.ShowUnknownFields {
+				return nil, errRedactionWithUnknownFields
+			}
+			msgDescriptor = protoCfg.MsgDescriptor
+		}
+	default:
+		return nil, errRedactionNotSupported
+	}
+
+	redactionRules := make([]s.RedactionRule, len(rules))
+	for i, rule := range rules {
+		action, err := t.ValidateRedactionActionValue(rule.Action)
+		if err != nil {
+			return nil, err
+		}
+
+		segments, err := s.ParseRedactionPath(rule.Path, msgDescriptor)
+		if err != nil {
+			return nil, err
+		}
+
+		redactionRules[i] = s.RedactionRule{
+			Path:     strings.TrimSpace(rule.Path),
+			Segments: segments,
+			Action:   action,
+		}
+		if action == s.RedactionHash && len(hashKey) > 0 {
+			redactionRules[i].HashKey = hashKey
+		}
+	}
+
+	return redactionRules, nil
+}
+
+func getRequiredFieldOptionNumber(optionName string, protoCfg *t.ProtoConfig) (protowire.Number, error) {
+	name := protoreflect.FullName(optionName)
+	if !name.IsValid() || protoCfg == nil || protoCfg.Resolver == nil {
+		return 0, fmt.Errorf("%w: %q", errRequiredFieldOptionInvalid, optionName)
+	}
+
+	extType, err := protoCfg.Resolver.FindExtensionByName(name)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s", errRequiredFieldOptionInvalid, err.Error())
+	}
+
+	extDescriptor := extType.TypeDescriptor()
+	if extDescriptor.ContainingMessage().FullName() != "google.protobuf.FieldOptions" || extDescriptor.Kind() != protoreflect.BoolKind {
+		return 0, fmt.Errorf("%w: %q needs to be a bool field option", errRequiredFieldOptionInvalid, optionName)
+	}
+
+	return extDescriptor.Number(), nil
+}
@@ -0,0 +1,183 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	a "github.com/dhth/kplay/internal/awsweb"
+	"github.com/dhth/kplay/internal/diff"
+	k "github.com/dhth/kplay/internal/kafka"
+	t "github.com/dhth/kplay/internal/types"
+	"github.com/dhth/kplay/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	errInvalidEndTimestampProvided = errors.New(`invalid value provided for "to timestamp"`)
+	errEndOffsetNegative           = errors.New(`"to offset" must be greater than or equal to 0`)
+)
+
+func newDiffCmd(configPath *string, homeDir string, debug *bool, defaultOutputDir string) *cobra.Command {
+	var fromOffset string
+	var fromTimestamp string
+	var toOffset int64
+	var toTimestamp string
+	var sequenceField string
+	var numMessages uint
+	var batchSize uint
+	var outputDir string
+
+	cmd := &cobra.Command{
+		Use:   "diff <PROFILE_A> <PROFILE_B>",
+		Short: "Compare the messages in two kafka topics",
+		Long: `This command is useful when you want to verify that the contents of two topics
+match (eg. when migrating or mirroring a topic across clusters). It consumes
+both topics over the same range, matches messages by their key (along with
+either the value of a sequence field, or their position among messages with
+the same key), and reports messages that are missing from the second topic,
+extra messages in it, and messages whose decoded values differ (along with a
+structured diff of the values).
+`,
+		Example:      `kplay diff billing-old billing-new --from-timestamp 2025-04-06T00:00:00Z --to-timestamp 2025-04-07T00:00:00Z`,
+		Args:         cobra.ExactArgs(2),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if batchSize == 0 {
+				return fmt.Errorf("batch size must be greater than 0")
+			}
+
+			if numMessages == 0 {
+				return fmt.Errorf("count must be greater than 0")
+			}
+
+			configPathFromEnvVar := os.Getenv(envVarConfigPath)
+			if configPathFromEnvVar != "" && !cmd.Flags().Changed("config-path") {
+				*configPath = configPathFromEnvVar
+			}
+
+			configPathFull := utils.ExpandTilde(*configPath, homeDir)
+			configBytes, err := os.ReadFile(configPathFull)
+			if err != nil {
+				return fmt.Errorf("%w: %w", ErrCouldntReadConfigFile, err)
+			}
+
+			configs, err := ParseProfileConfigs(configBytes, args, homeDir)
+			if errors.Is(err, errProfileNotFound) {
+				return err
+			} else if err != nil {
+				return fmt.Errorf("%w: %w", ErrConfigInvalid, err)
+			}
+
+			var consumeBehaviours t.ConsumeBehaviours
+			if cmd.Flags().Changed("from-timestamp") {
+				startTimestamp, err := time.Parse(time.RFC3339, fromTimestamp)
+				if err != nil {
+					return fmt.Errorf("%w: %q; expected RFC3339 format (e.g., 2006-01-02T15:04:05Z07:00)",
+						errInvalidTimestampProvided, fromTimestamp)
+				}
+				consumeBehaviours.StartTimeStamp = &startTimestamp
+			} else if cmd.Flags().Changed("from-offset") {
+				startOffset, partitionOffsets, err := parseFromOffset(fromOffset)
+				if err != nil {
+					return fmt.Errorf("%w: %s", errInvalidOffsetProvided, err.Error())
+				}
+				consumeBehaviours.StartOffset = startOffset
+				consumeBehaviours.PartitionOffsets = partitionOffsets
+			}
+
+			behaviours := diff.Behaviours{
+				NumMessages: numMessages,
+				BatchSize:   batchSize,
+			}
+
+			if cmd.Flags().Changed("to-offset") {
+				if toOffset < 0 {
+					return errEndOffsetNegative
+				}
+				behaviours.EndOffset = &toOffset
+			}
+
+			if cmd.Flags().Changed("to-timestamp") {
+				endTimestamp, err := time.Parse(time.RFC3339, toTimestamp)
+				if err != nil {
+					return fmt.Errorf("%w: %q; expected RFC3339 format (e.g., 2006-01-02T15:04:05Z07:00)",
+						errInvalidEndTimestampProvided, toTimestamp)
+				}
+				behaviours.EndTimestamp = &endTimestamp
+			}
+
+			behaviours.SequencePath, err = parseFieldPath(sequenceField)
+			if err != nil {
+				return err
+			}
+
+			if *debug {
+				fmt.Printf(`%s
+
+%s
+  output directory        %s
+
+%s
+
+%s
+`,
+					configs[0].Display(),
+					configs[1].Display(),
+					outputDir,
+					behaviours.Display(),
+					consumeBehaviours.Display(),
+				)
+
+				return nil
+			}
+
+			var awsConfig *aws.Config
+			if configs[0].Authentication == t.AWSMSKIAM || configs[1].Authentication == t.AWSMSKIAM {
+				awsCfg, err := a.GetAWSConfig(cmd.Context())
+				if err != nil {
+					return err
+				}
+
+				awsConfig = &awsCfg
+			}
+
+			sides := make([]diff.Side, len(configs))
+			for i, config := range configs {
+				client, err := k.GetKafkaClientUpToEndOffsets(
+					config.Authentication,
+					config.Brokers,
+					config.Topic,
+					consumeBehaviours,
+					awsConfig,
+				)
+				if err != nil {
+					return err
+				}
+
+				defer client.Close()
+
+				sides[i] = diff.Side{Client: client, Config: config}
+			}
+
+			differ := diff.New(sides[0], sides[1], behaviours, outputDir)
+
+			return differ.Execute()
+		},
+	}
+
+	cmd.Flags().StringVarP(&fromOffset, "from-offset", "o", "", "compare messages from this offset; provide a single offset for all partitions (eg. 1000) or specify offsets per partition (e.g., '0:1000,2:1500')")
+	cmd.Flags().StringVarP(&fromTimestamp, "from-timestamp", "t", "", "compare messages from this timestamp (in RFC3339 format, e.g., 2006-01-02T15:04:05Z07:00)")
+	cmd.Flags().Int64Var(&toOffset, "to-offset", 0, "compare messages up to this offset (inclusive) in each partition")
+	cmd.Flags().StringVar(&toTimestamp, "to-timestamp", "", "compare messages up to this timestamp (in RFC3339 format, e.g., 2006-01-02T15:04:05Z07:00)")
+	cmd.Flags().StringVar(&sequenceField, "sequence-field", "", "path of a field in decoded values that's used, along with keys, to match messages (eg. 'event.sequence'); messages are matched by their position among messages with the same key by default")
+	cmd.Flags().UintVarP(&numMessages, "num-records", "n", diff.DiffNumRecordsDefault, "maximum number of messages to consume from each topic")
+	cmd.Flags().UintVarP(&batchSize, "batch-size", "b", 100, "number of messages to fetch per batch (must be greater than 0)")
+	cmd.Flags().StringVarP(&outputDir, "output-dir", "O", defaultOutputDir, "directory to save the diff report in")
+
+	cmd.MarkFlagsMutuallyExclusive("from-offset", "from-timestamp")
+
+	return cmd
+}
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dhth/kplay/internal/scan"
+)
+
+var (
+	errInvalidFieldPath           = errors.New("field path is invalid")
+	errDuplicateWindowInvalid     = errors.New("duplicate window must be greater than 0")
+	errDetectDuplicatesNotEnabled = errors.New("--duplicate-id-field, --event-time-field, and --duplicate-window require --detect-duplicates")
+)
+
+// parseFieldPath splits a dot separated path to a field in decoded values
+// (optionally prefixed with "$.") into its segments.
+func parseFieldPath(path string) ([]string, error) {
+	trimmed := strings.TrimPrefix(strings.TrimSpace(path), "$.")
+	if trimmed == "" {
+		return nil, nil
+	}
+
+	segments := strings.Split(trimmed, ".")
+	for _, segment := range segments {
+		if segment == "" {
+			return nil, fmt.Errorf("%w: %q has an empty segment", errInvalidFieldPath, path)
+		}
+	}
+
+	return segments, nil
+}
+
+func parseDuplicateDetection(idField, eventTimeField string, window time.Duration) (*scan.DuplicateDetection, error) {
+	if window <= 0 {
+		return nil, errDuplicateWindowInvalid
+	}
+
+	idPath, err := parseFieldPath(idField)
+	if err != nil {
+		return nil, err
+	}
+
+	eventTimePath, err := parseFieldPath(eventTimeField)
+	if err != nil {
+		return nil, err
+	}
+
+	return &scan.DuplicateDetection{
+		IDPath:        idPath,
+		EventTimePath: eventTimePath,
+		Window:        window,
+	}, nil
+}
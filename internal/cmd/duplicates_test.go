@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDuplicateDetection(t *testing.T) {
+	tests := []struct {
+		name           string
+		idField        string
+		eventTimeField string
+		window         time.Duration
+		expected       string
+		expectedError  error
+	}{
+		// SUCCESSES
+		{
+			name:     "key and value hash",
+			window:   time.Hour,
+			expected: "by key and value hash, within 1h0m0s; event time from record timestamps",
+		},
+		{
+			name:           "id and event time fields",
+			idField:        "$.event.id",
+			eventTimeField: " occurredAt ",
+			window:         10 * time.Minute,
+			expected:       `by field "event.id", within 10m0s; event time from field "occurredAt"`,
+		},
+		// FAILURES
+		{
+			name:          "window of 0",
+			window:        0,
+			expectedError: errDuplicateWindowInvalid,
+		},
+		{
+			name:          "id field with an empty segment",
+			idField:       "event..id",
+			window:        time.Hour,
+			expectedError: errInvalidFieldPath,
+		},
+		{
+			name:           "event time field with a trailing dot",
+			eventTimeField: "occurredAt.",
+			window:         time.Hour,
+			expectedError:  errInvalidFieldPath,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			detection, err := parseDuplicateDetection(tt.idField, tt.eventTimeField, tt.window)
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, detection.String())
+		})
+	}
+}
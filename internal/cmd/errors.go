@@ -4,6 +4,8 @@ import (
 	_ "embed"
 	"errors"
 	"fmt"
+
+	"github.com/dhth/kplay/internal/filter"
 )
 
 //go:embed assets/sample-config.yml
@@ -19,6 +21,11 @@ var (
 	errInvalidTimestampProvided = errors.New(`invalid value provided for "from timestamp"`)
 	errInvalidOffsetProvided    = errors.New(`invalid value provided for "from offset"`)
 	errInvalidRegexProvided     = errors.New("invalid regex provided")
+	errCheckpointTopicMismatch  = errors.New("checkpoint doesn't belong to the profile's topic")
+	errCheckpointFormatMismatch = errors.New("output format doesn't match the checkpoint's")
+	errSampleCantBeResumed      = errors.New("scans sampling a reservoir or evenly spaced offsets can't be resumed")
+	errSpacedSampleWithStart    = errors.New("evenly spaced offsets are sampled from the start of each partition, and can't be combined with a start offset or timestamp")
+	errSpacedSampleWithMerge    = errors.New("evenly spaced offsets are sampled partition by partition, and can't be merged by timestamp")
 )
 
 func GetErrorFollowUp(err error) (string, bool) {
@@ -37,6 +44,30 @@ kplay's config looks like this:
 `, sampleConfig), true
 	}
 
+	if errors.Is(err, filter.ErrInvalidFilter) {
+		return `
+Hint: --filter accepts a CEL expression (https://cel.dev) that evaluates to a bool, and
+has access to the following variables:
+- key: the decoded key
+- value: the decoded value (JSON values are available as maps, lists, etc.)
+- headers: a map of header keys to their decoded values
+- partition, offset: ints
+- timestamp: the message's timestamp
+
+eg. --filter='value.status == "FAILED" && headers["source"] == "billing"'
+`, true
+	}
+
+	if errors.Is(err, errInvalidSampleFormat) || errors.Is(err, errInvalidSampleMode) {
+		return `
+Hint: --sample can be either of the following:
+- every:<N>, which samples 1 in every N messages (eg. --sample=every:100)
+- probability:<P>, which samples each message with a probability P (eg. --sample=probability:0.01)
+- reservoir:<N>, which samples N messages uniformly across the scan (eg. --sample=reservoir:500)
+- spaced:<N>, which samples N evenly spaced offsets per partition (eg. --sample=spaced:50)
+`, true
+	}
+
 	if errors.Is(err, errInvalidOffsetProvided) {
 		return `
 Hint: --from-offset can be either of the following:
@@ -0,0 +1,19 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/dhth/kplay/internal/filter"
+)
+
+const filterFlagUsage = `CEL expression to filter messages by; has access to key, value, headers, partition, offset, and timestamp (eg. 'value.status == "FAILED" && headers["source"] == "billing"')`
+
+// parseFilter compiles a filter expression; it returns a nil filter for an
+// empty expression.
+func parseFilter(expression string) (*filter.Filter, error) {
+	if strings.TrimSpace(expression) == "" {
+		return nil, nil
+	}
+
+	return filter.New(expression)
+}
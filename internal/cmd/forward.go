@@ -31,6 +31,7 @@ const (
 	envVarRunServer              = "KPLAY_FORWARD_RUN_SERVER"
 	envVarHost                   = "KPLAY_FORWARD_SERVER_HOST"
 	envVarPort                   = "KPLAY_FORWARD_SERVER_PORT"
+	envVarSaveRaw                = "KPLAY_FORWARD_SAVE_RAW"
 
 	// longest env var
 	// KPLAY_FORWARD_POLL_FETCH_TIMEOUT_MILLIS -> 39
@@ -75,6 +76,8 @@ const (
 	reportBatchSizeMin     = 1000
 	reportBatchSizeMax     = 20000
 
+	saveRawDefault = false
+
 	runServerDefault = false
 	hostDefault      = "127.0.0.1"
 
@@ -90,6 +93,8 @@ var (
 )
 
 func newForwardCmd(configPath *string, homeDir string, debug *bool, version string) *cobra.Command {
+	var filterExpr string
+
 	cmd := &cobra.Command{
 		Use:   "forward <PROFILE>,<PROFILE>,... <DESTINATION>",
 		Short: "Consume messages in a kafka topic and forward them to a remote destination",
@@ -113,6 +118,10 @@ as such, it accepts configuration via the following environment variables.
 - %s whether to run an http server alongside the forwarder (default: %v)
 - %s host to run the server on (default: %s)
 - %s port to run the server on (default: %d)
+- %s whether to also upload the raw bytes of messages (default: %v)
+
+Only messages that match the expression provided via --filter are forwarded, if
+one is provided.
 
 If needed, this command can also start an HTTP server which can be used for
 health checks (at /health).
@@ -130,6 +139,7 @@ health checks (at /health).
 			utils.RightPadTrim(envVarRunServer, envVarHelpPadding), runServerDefault,
 			utils.RightPadTrim(envVarHost, envVarHelpPadding), hostDefault,
 			utils.RightPadTrim(envVarPort, envVarHelpPadding), portDefault,
+			utils.RightPadTrim(envVarSaveRaw, envVarHelpPadding), saveRawDefault,
 		),
 		Example:      `kplay forward profile-1,profile-2 arn:aws:s3:::bucket-to-forward-messages-to/prefix`,
 		Args:         cobra.ExactArgs(2),
@@ -173,6 +183,11 @@ health checks (at /health).
 				return err
 			}
 
+			forwardBehaviours.Filter, err = parseFilter(filterExpr)
+			if err != nil {
+				return err
+			}
+
 			destinationStr := strings.TrimSpace(args[1])
 			if len(destinationStr) == 0 {
 				return errDestinationEmpty
@@ -262,6 +277,8 @@ Destination               %s
 		},
 	}
 
+	cmd.Flags().StringVarP(&filterExpr, "filter", "f", "", filterFlagUsage)
+
 	return cmd
 }
 
@@ -370,6 +387,11 @@ func getBehaviorsFromEnv() (f.Behaviours, error) {
 		errs = append(errs, err)
 	}
 
+	saveRaw, err := getBoolEnvVar(envVarSaveRaw, saveRawDefault)
+	if err != nil {
+		errs = append(errs, err)
+	}
+
 	if len(errs) > 0 {
 		if len(errs) == 1 {
 			return f.Behaviours{}, errs[0]
@@ -391,6 +413,7 @@ func getBehaviorsFromEnv() (f.Behaviours, error) {
 		RunServer:                      runServer,
 		ServerHost:                     host,
 		ServerPort:                     port,
+		SaveRaw:                        saveRaw,
 	}, nil
 }
 
@@ -409,6 +432,11 @@ func logStartupInfo(profileConfigNames []string, destination string, behaviours
 		"upload_reports", behaviours.UploadReports,
 		"log_json", behaviours.LogJSON,
 		"run_server", behaviours.RunServer,
+		"save_raw", behaviours.SaveRaw,
+	}
+
+	if behaviours.Filter != nil {
+		args = append(args, "filter", behaviours.Filter.String())
 	}
 
 	if behaviours.UploadReports {
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/dhth/kplay/internal/scan"
+)
+
+var errInvalidHeaderFilterFormat = errors.New("header filter is not in the format <KEY>=<REGEX>")
+
+// parseHeaderFilters parses header filters in the format <KEY>=<REGEX>, and
+// header keys that need to be present, into filters matched by a scan.
+func parseHeaderFilters(headerFilters []string, requiredHeaders []string) ([]scan.HeaderFilter, error) {
+	filters := make([]scan.HeaderFilter, 0, len(headerFilters)+len(requiredHeaders))
+
+	for _, value := range headerFilters {
+		key, regexStr, ok := strings.Cut(value, "=")
+		if !ok {
+			return nil, fmt.Errorf("%w: %q", errInvalidHeaderFilterFormat, value)
+		}
+
+		key = strings.TrimSpace(key)
+		if key == "" {
+			return nil, fmt.Errorf("%w: %q", errHeaderKeyEmpty, value)
+		}
+
+		valueRegex, err := regexp.Compile(regexStr)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q", errInvalidRegexProvided, regexStr)
+		}
+
+		filters = append(filters, scan.HeaderFilter{Key: key, ValueRegex: valueRegex})
+	}
+
+	for _, key := range requiredHeaders {
+		key = strings.TrimSpace(key)
+		if key == "" {
+			return nil, errHeaderKeyEmpty
+		}
+
+		filters = append(filters, scan.HeaderFilter{Key: key})
+	}
+
+	return filters, nil
+}
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseHeaderFilters(t *testing.T) {
+	tests := []struct {
+		name            string
+		headerFilters   []string
+		requiredHeaders []string
+		expected        []string
+		expectedError   error
+	}{
+		// SUCCESSES
+		{
+			name:     "no filters",
+			expected: []string{},
+		},
+		{
+			name:          "header filter",
+			headerFilters: []string{"tenant=^acme$"},
+			expected:      []string{"tenant=^acme$"},
+		},
+		{
+			name:          "header filter with = in the regex",
+			headerFilters: []string{"query=a=b"},
+			expected:      []string{"query=a=b"},
+		},
+		{
+			name:          "header filter with an empty regex",
+			headerFilters: []string{"tenant="},
+			expected:      []string{"tenant="},
+		},
+		{
+			name:            "header filters and required headers",
+			headerFilters:   []string{"tenant=^acme$", " source = billing"},
+			requiredHeaders: []string{"trace-id"},
+			expected:        []string{"tenant=^acme$", "source= billing", "trace-id (present)"},
+		},
+		// FAILURES
+		{
+			name:          "missing separator",
+			headerFilters: []string{"tenant"},
+			expectedError: errInvalidHeaderFilterFormat,
+		},
+		{
+			name:          "empty key",
+			headerFilters: []string{"=acme"},
+			expectedError: errHeaderKeyEmpty,
+		},
+		{
+			name:          "invalid regex",
+			headerFilters: []string{"tenant=(acme"},
+			expectedError: errInvalidRegexProvided,
+		},
+		{
+			name:            "empty required header",
+			requiredHeaders: []string{" "},
+			expectedError:   errHeaderKeyEmpty,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filters, err := parseHeaderFilters(tt.headerFilters, tt.requiredHeaders)
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+
+			got := make([]string, len(filters))
+			for i, f := range filters {
+				got[i] = f.String()
+			}
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
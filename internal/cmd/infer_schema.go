@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	a "github.com/dhth/kplay/internal/awsweb"
+	k "github.com/dhth/kplay/internal/kafka"
+	"github.com/dhth/kplay/internal/scan"
+	t "github.com/dhth/kplay/internal/types"
+	"github.com/spf13/cobra"
+)
+
+var errSchemaInferenceNotSupported = errors.New("schema inference is only supported for profiles with JSON or protobuf encoding")
+
+func newInferSchemaCmd(
+	preRunE func(cmd *cobra.Command, args []string) error,
+	config *t.Config,
+	consumeBehaviours *t.ConsumeBehaviours,
+	fromOffset *string,
+	fromTimestamp *string,
+	outputDir *string,
+	debug *bool,
+	defaultOutputDir string,
+) *cobra.Command {
+	var keyFilterRegexStr string
+	var filterExpr string
+	var numMessages uint
+	var batchSize uint
+
+	cmd := &cobra.Command{
+		Use:   "infer-schema <PROFILE>",
+		Short: "Infer a JSON schema from messages in a kafka topic",
+		Long: `This command is useful when you want to find out the shape of messages in a
+Kafka topic that has no documented contract. It scans messages (the same way
+"kplay scan" does), and merges their decoded values into a JSON schema, which
+describes the types of fields, how often each field is present, enums for
+strings with few distinct values, and example values.
+`,
+		Args:              cobra.ExactArgs(1),
+		SilenceUsage:      true,
+		PersistentPreRunE: preRunE,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if config.Encoding == t.Raw {
+				return errSchemaInferenceNotSupported
+			}
+
+			if batchSize == 0 {
+				return fmt.Errorf("batch size must be greater than 0")
+			}
+
+			if numMessages == 0 {
+				return fmt.Errorf("count must be greater than 0")
+			}
+
+			var keyFilterRegex *regexp.Regexp
+			if strings.TrimSpace(keyFilterRegexStr) != "" {
+				var regexErr error
+				keyFilterRegex, regexErr = regexp.Compile(keyFilterRegexStr)
+				if regexErr != nil {
+					return fmt.Errorf("%w: %q", errInvalidRegexProvided, keyFilterRegexStr)
+				}
+			}
+
+			msgFilter, err := parseFilter(filterExpr)
+			if err != nil {
+				return err
+			}
+
+			behaviours := scan.Behaviours{
+				NumMessages:    numMessages,
+				KeyFilterRegex: keyFilterRegex,
+				Filter:         msgFilter,
+				Decode:         true,
+				BatchSize:      batchSize,
+				Workers:        1,
+				InferSchema:    true,
+			}
+
+			if *debug {
+				fmt.Printf(`%s
+  output directory        %s
+
+%s
+
+%s
+`,
+					config.Display(),
+					*outputDir,
+					behaviours.InferSchemaDisplay(),
+					consumeBehaviours.Display(),
+				)
+
+				return nil
+			}
+
+			var awsConfig *aws.Config
+			if config.Authentication == t.AWSMSKIAM {
+				awsCfg, err := a.GetAWSConfig(cmd.Context())
+				if err != nil {
+					return err
+				}
+
+				awsConfig = &awsCfg
+			}
+
+			client, err := k.GetKafkaClient(
+				config.Authentication,
+				config.Brokers,
+				config.Topic,
+				*consumeBehaviours,
+				awsConfig,
+			)
+			if err != nil {
+				return err
+			}
+
+			defer client.Close()
+
+			scanner := scan.New(client, *config, behaviours, *consumeBehaviours, *outputDir)
+
+			return scanner.Execute()
+		},
+	}
+
+	cmd.Flags().StringVarP(fromOffset, "from-offset", "o", "", "scan messages from this offset; provide a single offset for all partitions (eg. 1000) or specify offsets per partition (e.g., '0:1000,2:1500')")
+	cmd.Flags().StringVarP(fromTimestamp, "from-timestamp", "t", "", "scan messages from this timestamp (in RFC3339 format, e.g., 2006-01-02T15:04:05Z07:00)")
+	cmd.Flags().StringVarP(&keyFilterRegexStr, "key-regex", "k", "", "regex to filter message keys by (matched against keys decoded as per the profile's key encoding)")
+	cmd.Flags().StringVarP(&filterExpr, "filter", "f", "", filterFlagUsage)
+	cmd.Flags().UintVarP(&numMessages, "num-records", "n", scan.ScanNumRecordsDefault, "maximum number of messages to infer the schema from")
+	cmd.Flags().UintVarP(&batchSize, "batch-size", "b", 100, "number of messages to fetch per batch (must be greater than 0)")
+	cmd.Flags().StringVarP(outputDir, "output-dir", "O", defaultOutputDir, "directory to save the inferred schema in")
+
+	return cmd
+}
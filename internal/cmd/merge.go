@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"errors"
+	"time"
+
+	k "github.com/dhth/kplay/internal/kafka"
+)
+
+const (
+	mergeFlagUsage         = "whether to merge messages from all partitions into a single stream ordered by timestamp"
+	mergeLatenessFlagUsage = "how long (in terms of message timestamps) to hold messages back for, so that older messages from other partitions can be slotted in before them"
+	mergeLatenessDefault   = 5 * time.Second
+)
+
+var (
+	errMergeLatenessNegative = errors.New("merge lateness must be greater than or equal to 0")
+	errMergeNotEnabled       = errors.New("--merge-lateness requires --merge")
+)
+
+// parseMergeMode returns the merge mode to use; it returns nil when messages
+// aren't to be merged.
+func parseMergeMode(merge bool, lateness time.Duration, latenessProvided bool) (*k.MergeMode, error) {
+	if !merge {
+		if latenessProvided {
+			return nil, errMergeNotEnabled
+		}
+
+		return nil, nil
+	}
+
+	if lateness < 0 {
+		return nil, errMergeLatenessNegative
+	}
+
+	return &k.MergeMode{Lateness: lateness}, nil
+}
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMergeMode(t *testing.T) {
+	tests := []struct {
+		name             string
+		merge            bool
+		lateness         time.Duration
+		latenessProvided bool
+		expected         string
+		expectedError    error
+	}{
+		// SUCCESSES
+		{
+			name:     "merge with the default lateness",
+			merge:    true,
+			lateness: mergeLatenessDefault,
+			expected: "by timestamp, with a lateness window of 5s",
+		},
+		{
+			name:             "merge without a lateness window",
+			merge:            true,
+			lateness:         0,
+			latenessProvided: true,
+			expected:         "by timestamp, with a lateness window of 0s",
+		},
+		// FAILURES
+		{
+			name:             "negative lateness",
+			merge:            true,
+			lateness:         -time.Second,
+			latenessProvided: true,
+			expectedError:    errMergeLatenessNegative,
+		},
+		{
+			name:             "lateness without merge",
+			lateness:         time.Minute,
+			latenessProvided: true,
+			expectedError:    errMergeNotEnabled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mode, err := parseMergeMode(tt.merge, tt.lateness, tt.latenessProvided)
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+			require.NotNil(t, mode)
+			assert.Equal(t, tt.expected, mode.String())
+		})
+	}
+}
+
+func TestParseMergeModeReturnsNilWhenNotMerging(t *testing.T) {
+	mode, err := parseMergeMode(false, mergeLatenessDefault, false)
+
+	require.NoError(t, err)
+	assert.Nil(t, mode)
+}
@@ -140,8 +140,29 @@ to brokers, message encoding, authentication, etc.
 		defaultOutputDir,
 	)
 
+	inferSchemaCmd := newInferSchemaCmd(
+		preRunE,
+		&config,
+		&consumeBehaviours,
+		&fromOffset,
+		&fromTimestamp,
+		&outputDir,
+		&debug,
+		defaultOutputDir,
+	)
+
+	snapshotCmd := newSnapshotCmd(
+		preRunE,
+		&config,
+		&outputDir,
+		&debug,
+		defaultOutputDir,
+	)
+
 	forwardCmd := newForwardCmd(&configPath, homeDir, &debug, version)
 
+	diffCmd := newDiffCmd(&configPath, homeDir, &debug, defaultOutputDir)
+
 	configDir, err := os.UserConfigDir()
 	if err != nil {
 		return nil, fmt.Errorf("%w: %s", errCouldntGetUserConfigDir, err.Error())
@@ -155,7 +176,10 @@ to brokers, message encoding, authentication, etc.
 	rootCmd.AddCommand(tuiCmd)
 	rootCmd.AddCommand(serveCmd)
 	rootCmd.AddCommand(scanCmd)
+	rootCmd.AddCommand(inferSchemaCmd)
+	rootCmd.AddCommand(snapshotCmd)
 	rootCmd.AddCommand(forwardCmd)
+	rootCmd.AddCommand(diffCmd)
 
 	rootCmd.CompletionOptions.DisableDefaultCmd = true
 
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/dhth/kplay/internal/scan"
+)
+
+var (
+	errInvalidSampleFormat      = errors.New("sample is not in the format <MODE>:<VALUE>")
+	errInvalidSampleMode        = errors.New("invalid sample mode provided")
+	errInvalidSampleSize        = errors.New("sample size must be an integer greater than 0")
+	errInvalidSampleProbability = errors.New("sample probability must be greater than 0 and at most 1")
+)
+
+// parseSample parses a sample in the format <MODE>:<VALUE>, where the value is
+// a probability for the "probability" mode, and a size for every other mode.
+func parseSample(value string) (*scan.Sample, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+
+	modeStr, valueStr, ok := strings.Cut(value, ":")
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", errInvalidSampleFormat, value)
+	}
+
+	mode, err := scan.ValidateSampleModeValue(strings.TrimSpace(modeStr))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errInvalidSampleMode, err.Error())
+	}
+
+	valueStr = strings.TrimSpace(valueStr)
+
+	if mode == scan.SampleProbability {
+		probability, err := strconv.ParseFloat(valueStr, 64)
+		if err != nil || probability <= 0 || probability > 1 {
+			return nil, fmt.Errorf("%w: %q", errInvalidSampleProbability, valueStr)
+		}
+
+		return &scan.Sample{Mode: mode, Probability: probability}, nil
+	}
+
+	size, err := strconv.ParseUint(valueStr, 10, 64)
+	if err != nil || size == 0 {
+		return nil, fmt.Errorf("%w: %q", errInvalidSampleSize, valueStr)
+	}
+
+	return &scan.Sample{Mode: mode, Size: uint(size)}, nil
+}
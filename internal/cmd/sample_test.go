@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/dhth/kplay/internal/scan"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSample(t *testing.T) {
+	tests := []struct {
+		name          string
+		value         string
+		expected      *scan.Sample
+		expectedError error
+	}{
+		// SUCCESSES
+		{
+			name:  "no sample",
+			value: "",
+		},
+		{
+			name:     "every nth message",
+			value:    "every:10",
+			expected: &scan.Sample{Mode: scan.SampleEveryNth, Size: 10},
+		},
+		{
+			name:     "probability",
+			value:    "probability:0.05",
+			expected: &scan.Sample{Mode: scan.SampleProbability, Probability: 0.05},
+		},
+		{
+			name:     "probability of 1",
+			value:    "probability:1",
+			expected: &scan.Sample{Mode: scan.SampleProbability, Probability: 1},
+		},
+		{
+			name:     "reservoir",
+			value:    "reservoir:500",
+			expected: &scan.Sample{Mode: scan.SampleReservoir, Size: 500},
+		},
+		{
+			name:     "evenly spaced offsets with spaces",
+			value:    " spaced : 20 ",
+			expected: &scan.Sample{Mode: scan.SampleSpaced, Size: 20},
+		},
+		// FAILURES
+		{
+			name:          "missing separator",
+			value:         "every",
+			expectedError: errInvalidSampleFormat,
+		},
+		{
+			name:          "unknown mode",
+			value:         "first:10",
+			expectedError: errInvalidSampleMode,
+		},
+		{
+			name:          "size of 0",
+			value:         "reservoir:0",
+			expectedError: errInvalidSampleSize,
+		},
+		{
+			name:          "non-integer size",
+			value:         "every:2.5",
+			expectedError: errInvalidSampleSize,
+		},
+		{
+			name:          "probability of 0",
+			value:         "probability:0",
+			expectedError: errInvalidSampleProbability,
+		},
+		{
+			name:          "probability greater than 1",
+			value:         "probability:1.5",
+			expectedError: errInvalidSampleProbability,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sample, err := parseSample(tt.value)
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, sample)
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"errors"
+
+	"github.com/dhth/kplay/internal/fs"
+)
+
+const (
+	saveRawFlagUsage          = "whether to also save the raw bytes of the key, value, and headers of saved messages (in a JSON envelope next to the decoded output)"
+	savePathTemplateFlagUsage = "template for the paths of saved messages, relative to the messages directory; has access to .Topic, .Partition, .Offset, .Key, .Timestamp, .Date, and .Ext, and needs to refer to .Offset (eg. '{{.Topic}}/{{.Date}}/{{.Key}}-{{.Offset}}.{{.Ext}}')"
+	saveFormatFlagUsage       = "format of saved messages; possible values: [txt, json, value] (value saves the decoded value only)"
+)
+
+var (
+	errSaveRawWithoutSaveMessages    = errors.New("--save-raw requires --save-messages")
+	errSaveLayoutWithoutSaveMessages = errors.New("--save-path-template and --save-format require --save-messages")
+)
+
+// parseSaveLayout returns the layout to save messages in.
+func parseSaveLayout(pathTemplate, formatStr string, raw bool) (fs.SaveLayout, error) {
+	format, err := fs.ValidateSaveFormatValue(formatStr)
+	if err != nil {
+		return fs.SaveLayout{}, err
+	}
+
+	return fs.NewSaveLayout(pathTemplate, format, raw)
+}
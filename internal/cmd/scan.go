@@ -4,9 +4,11 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	a "github.com/dhth/kplay/internal/awsweb"
+	"github.com/dhth/kplay/internal/fs"
 	k "github.com/dhth/kplay/internal/kafka"
 	"github.com/dhth/kplay/internal/scan"
 	t "github.com/dhth/kplay/internal/types"
@@ -24,10 +26,30 @@ func newScanCmd(
 	defaultOutputDir string,
 ) *cobra.Command {
 	var scanKeyFilterRegexStr string
+	var scanHeaderFilters []string
+	var scanRequiredHeaders []string
+	var scanHeaderColumns []string
+	var scanFilterExpr string
+	var scanSampleStr string
 	var scanNumMessages uint
+	var scanFormatStr string
+	var scanSingleFile bool
+	var scanSQLitePath string
+	var scanStats bool
+	var scanDetectDuplicates bool
+	var scanDuplicateIDField string
+	var scanEventTimeField string
+	var scanDuplicateWindow time.Duration
+	var scanMerge bool
+	var scanMergeLateness time.Duration
+	var scanResumePath string
 	var scanSaveMessages bool
+	var scanSaveRaw bool
+	var scanSavePathTemplate string
+	var scanSaveFormat string
 	var scanDecode bool
 	var scanBatchSize uint
+	var scanWorkers uint
 
 	cmd := &cobra.Command{
 		Use:   "scan <PROFILE>",
@@ -44,6 +66,10 @@ optionally save the message values to your local filesystem.
 				return fmt.Errorf("batch size must be greater than 0")
 			}
 
+			if scanWorkers == 0 {
+				return fmt.Errorf("workers must be greater than 0")
+			}
+
 			if scanNumMessages == 0 {
 				return fmt.Errorf("count must be greater than 0")
 			}
@@ -57,12 +83,109 @@ optionally save the message values to your local filesystem.
 				}
 			}
 
+			outputFormat, err := scan.ValidateOutputFormatValue(scanFormatStr)
+			if err != nil {
+				return err
+			}
+
+			var resumeCheckpoint *scan.Checkpoint
+			if strings.TrimSpace(scanResumePath) != "" {
+				checkpoint, err := scan.ReadCheckpoint(scanResumePath)
+				if err != nil {
+					return err
+				}
+
+				if checkpoint.Topic != config.Topic {
+					return fmt.Errorf("%w: checkpoint is for topic %q, profile's topic is %q", errCheckpointTopicMismatch, checkpoint.Topic, config.Topic)
+				}
+
+				if cmd.Flags().Changed("format") && checkpoint.Format != outputFormat.String() {
+					return fmt.Errorf("%w: checkpoint's results are in %q format", errCheckpointFormatMismatch, checkpoint.Format)
+				}
+
+				outputFormat, _ = scan.ValidateOutputFormatValue(checkpoint.Format)
+				if strings.TrimSpace(scanSQLitePath) == "" {
+					scanSQLitePath = checkpoint.SQLitePath
+				}
+
+				*consumeBehaviours = checkpoint.ConsumeBehaviours()
+				resumeCheckpoint = &checkpoint
+			}
+
+			headerFilters, err := parseHeaderFilters(scanHeaderFilters, scanRequiredHeaders)
+			if err != nil {
+				return err
+			}
+
+			msgFilter, err := parseFilter(scanFilterExpr)
+			if err != nil {
+				return err
+			}
+
+			sample, err := parseSample(scanSampleStr)
+			if err != nil {
+				return err
+			}
+
+			if sample != nil && !sample.IsResumable() && resumeCheckpoint != nil {
+				return errSampleCantBeResumed
+			}
+
+			if sample != nil && sample.Mode == scan.SampleSpaced && (cmd.Flags().Changed("from-offset") || cmd.Flags().Changed("from-timestamp")) {
+				return errSpacedSampleWithStart
+			}
+
+			var duplicateDetection *scan.DuplicateDetection
+			if scanDetectDuplicates {
+				duplicateDetection, err = parseDuplicateDetection(scanDuplicateIDField, scanEventTimeField, scanDuplicateWindow)
+				if err != nil {
+					return err
+				}
+			} else if cmd.Flags().Changed("duplicate-id-field") || cmd.Flags().Changed("event-time-field") || cmd.Flags().Changed("duplicate-window") {
+				return errDetectDuplicatesNotEnabled
+			}
+
+			if scanSaveRaw && !scanSaveMessages {
+				return errSaveRawWithoutSaveMessages
+			}
+
+			if !scanSaveMessages && (cmd.Flags().Changed("save-path-template") || cmd.Flags().Changed("save-format")) {
+				return errSaveLayoutWithoutSaveMessages
+			}
+
+			saveLayout, err := parseSaveLayout(scanSavePathTemplate, scanSaveFormat, scanSaveRaw)
+			if err != nil {
+				return err
+			}
+
+			mergeMode, err := parseMergeMode(scanMerge, scanMergeLateness, cmd.Flags().Changed("merge-lateness"))
+			if err != nil {
+				return err
+			}
+
+			if mergeMode != nil && sample != nil && sample.Mode == scan.SampleSpaced {
+				return errSpacedSampleWithMerge
+			}
+
 			scanBehaviours := scan.Behaviours{
 				NumMessages:    scanNumMessages,
 				KeyFilterRegex: keyFilterRegex,
+				HeaderFilters:  headerFilters,
+				Filter:         msgFilter,
+				Sample:         sample,
+				HeaderColumns:  scanHeaderColumns,
+				Format:         outputFormat,
+				SingleFile:     scanSingleFile,
+				SQLitePath:     strings.TrimSpace(scanSQLitePath),
+				Stats:          scanStats,
+				Duplicates:     duplicateDetection,
+				Merge:          mergeMode,
+				Resume:         resumeCheckpoint,
 				SaveMessages:   scanSaveMessages,
+				Save:           saveLayout,
 				Decode:         scanDecode,
 				BatchSize:      scanBatchSize,
+				Workers:        scanWorkers,
 			}
 
 			if *debug {
@@ -105,7 +228,7 @@ optionally save the message values to your local filesystem.
 
 			defer client.Close()
 
-			scanner := scan.New(client, *config, scanBehaviours, *outputDir)
+			scanner := scan.New(client, *config, scanBehaviours, *consumeBehaviours, *outputDir)
 
 			return scanner.Execute()
 		},
@@ -113,12 +236,40 @@ optionally save the message values to your local filesystem.
 
 	cmd.Flags().StringVarP(fromOffset, "from-offset", "o", "", "scan messages from this offset; provide a single offset for all partitions (eg. 1000) or specify offsets per partition (e.g., '0:1000,2:1500')")
 	cmd.Flags().StringVarP(fromTimestamp, "from-timestamp", "t", "", "scan messages from this timestamp (in RFC3339 format, e.g., 2006-01-02T15:04:05Z07:00)")
-	cmd.Flags().StringVarP(&scanKeyFilterRegexStr, "key-regex", "k", "", "regex to filter message keys by")
+	cmd.Flags().StringVarP(&scanKeyFilterRegexStr, "key-regex", "k", "", "regex to filter message keys by (matched against keys decoded as per the profile's key encoding)")
+	cmd.Flags().StringArrayVar(&scanHeaderFilters, "header", nil, "filter messages by a header's value, in the format <KEY>=<REGEX> (can be repeated)")
+	cmd.Flags().StringArrayVar(&scanRequiredHeaders, "has-header", nil, "filter messages by the presence of a header (can be repeated)")
+	cmd.Flags().StringVarP(&scanFilterExpr, "filter", "f", "", filterFlagUsage)
+	cmd.Flags().StringVar(&scanSampleStr, "sample", "", "sample the scanned messages (that match the filters); possible values: [every:<N>, probability:<P>, reservoir:<N>, spaced:<N>]")
+	cmd.Flags().StringSliceVar(&scanHeaderColumns, "header-columns", nil, "header keys to add as columns to the scan results (eg. 'tenant,trace-id')")
 	cmd.Flags().UintVarP(&scanNumMessages, "num-records", "n", scan.ScanNumRecordsDefault, "maximum number of messages to scan")
+	cmd.Flags().StringVar(&scanFormatStr, "format", "csv", "format of the scan results file; possible values: [csv, tsv, jsonl] (jsonl results include decoded values and headers)")
+	cmd.Flags().BoolVar(&scanSingleFile, "single-file", false, "whether to write message values to the scan results file (as a value column for csv/tsv) instead of one file per message")
+	cmd.Flags().StringVar(&scanSQLitePath, "sqlite", "", "path of a SQLite database to write messages to (created if needed; messages already present in it are skipped)")
+	cmd.Flags().BoolVar(&scanStats, "stats", false, "whether to compute statistics for the scanned messages (key cardinality, top keys, partition skew, value sizes, tombstones, decode errors, and message rate)")
+	cmd.Flags().BoolVar(&scanDetectDuplicates, "detect-duplicates", false, "whether to report duplicate messages, and messages whose event time goes backwards for their key (in a report next to the scan results)")
+	cmd.Flags().StringVar(&scanDuplicateIDField, "duplicate-id-field", "", "path of a field in decoded values that identifies messages when detecting duplicates (eg. 'event.id'); messages are identified by a hash of their key and value by default")
+	cmd.Flags().StringVar(&scanEventTimeField, "event-time-field", "", "path of a field in decoded values that holds the event time (RFC3339, or seconds/milliseconds since the epoch) when detecting out-of-order messages; record timestamps are used by default")
+	cmd.Flags().DurationVar(&scanDuplicateWindow, "duplicate-window", scan.DuplicateWindowDefault, "window of record timestamps within which messages with the same ID are reported as duplicates")
+	cmd.Flags().BoolVar(&scanMerge, "merge", false, mergeFlagUsage)
+	cmd.Flags().DurationVar(&scanMergeLateness, "merge-lateness", mergeLatenessDefault, mergeLatenessFlagUsage)
+	cmd.Flags().StringVar(&scanResumePath, "resume", "", "path of a checkpoint file to resume a scan from (checkpoints are saved next to scan results)")
 	cmd.Flags().BoolVarP(&scanSaveMessages, "save-messages", "s", false, "whether to save kafka messages to the local filesystem")
+	cmd.Flags().StringVar(&scanSavePathTemplate, "save-path-template", fs.DefaultSavePathTemplate, savePathTemplateFlagUsage)
+	cmd.Flags().StringVar(&scanSaveFormat, "save-format", "txt", saveFormatFlagUsage)
+	cmd.Flags().BoolVar(&scanSaveRaw, "save-raw", false, saveRawFlagUsage)
 	cmd.Flags().BoolVarP(&scanDecode, "decode", "d", true, "whether to decode message values (false is equivalent to 'encodingFormat: raw' in kplay's config)")
 	cmd.Flags().UintVarP(&scanBatchSize, "batch-size", "b", 100, "number of messages to fetch per batch (must be greater than 0)")
+	cmd.Flags().UintVar(&scanWorkers, "workers", 1, "number of workers to decode and filter messages with (must be greater than 0); results are written in the order messages are consumed in")
 	cmd.Flags().StringVarP(outputDir, "output-dir", "O", defaultOutputDir, "directory to save scan results in")
 
+	cmd.MarkFlagsMutuallyExclusive("single-file", "save-messages")
+	cmd.MarkFlagsMutuallyExclusive("resume", "from-offset")
+	cmd.MarkFlagsMutuallyExclusive("resume", "from-timestamp")
+	// the state these are computed from is held in memory, and isn't part of
+	// checkpoints
+	cmd.MarkFlagsMutuallyExclusive("resume", "stats")
+	cmd.MarkFlagsMutuallyExclusive("resume", "detect-duplicates")
+
 	return cmd
 }
@@ -22,7 +22,11 @@ func newServeCmd(
 	debug *bool,
 ) *cobra.Command {
 	var selectOnHover bool
+	var hexView bool
 	var webOpen bool
+	var filterExpr string
+	var merge bool
+	var mergeLateness time.Duration
 
 	cmd := &cobra.Command{
 		Use:   "serve <PROFILE>",
@@ -36,8 +40,21 @@ timestamp to start consuming messages from.
 		SilenceUsage:      true,
 		PersistentPreRunE: preRunE,
 		RunE: func(cmd *cobra.Command, _ []string) error {
+			msgFilter, err := parseFilter(filterExpr)
+			if err != nil {
+				return err
+			}
+
+			mergeMode, err := parseMergeMode(merge, mergeLateness, cmd.Flags().Changed("merge-lateness"))
+			if err != nil {
+				return err
+			}
+
 			behaviours := server.Behaviours{
 				SelectOnHover: selectOnHover,
+				HexView:       hexView,
+				Filter:        msgFilter,
+				Merge:         mergeMode,
 			}
 			if *debug {
 				fmt.Printf(`%s
@@ -84,9 +101,13 @@ timestamp to start consuming messages from.
 		},
 	}
 
+	cmd.Flags().StringVarP(&filterExpr, "filter", "f", "", filterFlagUsage)
+	cmd.Flags().BoolVar(&merge, "merge", false, mergeFlagUsage)
+	cmd.Flags().DurationVar(&mergeLateness, "merge-lateness", mergeLatenessDefault, mergeLatenessFlagUsage)
 	cmd.Flags().StringVarP(fromOffset, "from-offset", "o", "", "start consuming messages from this offset; provide a single offset for all partitions (eg. 1000) or specify offsets per partition (e.g., '0:1000,2:1500')")
 	cmd.Flags().StringVarP(fromTimestamp, "from-timestamp", "t", "", "start consuming messages from this timestamp (in RFC3339 format, e.g., 2006-01-02T15:04:05Z07:00)")
 	cmd.Flags().BoolVarP(&selectOnHover, "select-on-hover", "S", false, "whether to start the web interface with the setting \"select on hover\" ON")
+	cmd.Flags().BoolVarP(&hexView, "hex-view", "x", false, "whether to start the web interface with the setting \"hex view\" ON")
 	cmd.Flags().BoolVarP(&webOpen, "open", "O", false, "whether to open web interface in browser automatically")
 
 	return cmd
@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	a "github.com/dhth/kplay/internal/awsweb"
+	k "github.com/dhth/kplay/internal/kafka"
+	"github.com/dhth/kplay/internal/snapshot"
+	t "github.com/dhth/kplay/internal/types"
+	"github.com/spf13/cobra"
+)
+
+func newSnapshotCmd(
+	preRunE func(cmd *cobra.Command, args []string) error,
+	config *t.Config,
+	outputDir *string,
+	debug *bool,
+	defaultOutputDir string,
+) *cobra.Command {
+	var formatStr string
+	var maxKeysInMemory uint
+	var batchSize uint
+
+	cmd := &cobra.Command{
+		Use:   "snapshot <PROFILE>",
+		Short: "Materialize the latest value for each key in a kafka topic",
+		Long: `This command is useful when you want the "table" view of a compacted topic, ie,
+the latest value for each key, minus the keys whose latest record is a
+tombstone. It consumes the topic from the start up to its end (as of when the
+snapshot starts), and writes the snapshot to a JSONL file or a SQLite database,
+along with counts of live and deleted keys.
+`,
+		Args:              cobra.ExactArgs(1),
+		SilenceUsage:      true,
+		PersistentPreRunE: preRunE,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if batchSize == 0 {
+				return fmt.Errorf("batch size must be greater than 0")
+			}
+
+			if maxKeysInMemory == 0 {
+				return fmt.Errorf("max keys in memory must be greater than 0")
+			}
+
+			format, err := snapshot.ValidateOutputFormatValue(formatStr)
+			if err != nil {
+				return err
+			}
+
+			behaviours := snapshot.Behaviours{
+				Format:          format,
+				MaxKeysInMemory: maxKeysInMemory,
+				BatchSize:       batchSize,
+			}
+
+			if *debug {
+				fmt.Printf(`%s
+  output directory        %s
+
+%s
+`,
+					config.Display(),
+					*outputDir,
+					behaviours.Display(),
+				)
+
+				return nil
+			}
+
+			var awsConfig *aws.Config
+			if config.Authentication == t.AWSMSKIAM {
+				awsCfg, err := a.GetAWSConfig(cmd.Context())
+				if err != nil {
+					return err
+				}
+
+				awsConfig = &awsCfg
+			}
+
+			// snapshots always start from the earliest offset of each partition
+			client, err := k.GetKafkaClientUpToEndOffsets(
+				config.Authentication,
+				config.Brokers,
+				config.Topic,
+				t.ConsumeBehaviours{},
+				awsConfig,
+			)
+			if err != nil {
+				return err
+			}
+
+			defer client.Close()
+
+			snapshotter := snapshot.New(client, *config, behaviours, *outputDir)
+
+			return snapshotter.Execute()
+		},
+	}
+
+	cmd.Flags().StringVar(&formatStr, "format", "jsonl", "format of the snapshot; possible values: [jsonl, sqlite]")
+	cmd.Flags().UintVar(&maxKeysInMemory, "max-keys-in-memory", snapshot.SnapshotMaxKeysInMemoryDefault, "number of keys to hold in memory before spilling them to disk (must be greater than 0)")
+	cmd.Flags().UintVarP(&batchSize, "batch-size", "b", 100, "number of messages to fetch per batch (must be greater than 0)")
+	cmd.Flags().StringVarP(outputDir, "output-dir", "O", defaultOutputDir, "directory to save the snapshot in")
+
+	return cmd
+}
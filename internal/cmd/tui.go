@@ -7,6 +7,7 @@ import (
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	a "github.com/dhth/kplay/internal/awsweb"
+	"github.com/dhth/kplay/internal/fs"
 	k "github.com/dhth/kplay/internal/kafka"
 	"github.com/dhth/kplay/internal/tui"
 	t "github.com/dhth/kplay/internal/types"
@@ -25,6 +26,13 @@ func newTuiCmd(
 ) *cobra.Command {
 	var persistMessages bool
 	var skipMessages bool
+	var hexView bool
+	var saveRaw bool
+	var savePathTemplate string
+	var saveFormat string
+	var filterExpr string
+	var merge bool
+	var mergeLateness time.Duration
 
 	cmd := &cobra.Command{
 		Use:   "tui <PROFILE>",
@@ -38,9 +46,28 @@ either providing an offset or a timestamp to start consuming messages from.
 		SilenceUsage:      true,
 		PersistentPreRunE: preRunE,
 		RunE: func(cmd *cobra.Command, _ []string) error {
+			msgFilter, err := parseFilter(filterExpr)
+			if err != nil {
+				return err
+			}
+
+			saveLayout, err := parseSaveLayout(savePathTemplate, saveFormat, saveRaw)
+			if err != nil {
+				return err
+			}
+
+			mergeMode, err := parseMergeMode(merge, mergeLateness, cmd.Flags().Changed("merge-lateness"))
+			if err != nil {
+				return err
+			}
+
 			behaviours := tui.Behaviours{
 				PersistMessages: persistMessages,
 				SkipMessages:    skipMessages,
+				HexView:         hexView,
+				Save:            saveLayout,
+				Filter:          msgFilter,
+				Merge:           mergeMode,
 			}
 
 			if *debug {
@@ -93,6 +120,13 @@ either providing an offset or a timestamp to start consuming messages from.
 
 	cmd.Flags().BoolVarP(&persistMessages, "persist-messages", "p", false, "whether to start the TUI with the setting \"persist messages\" ON")
 	cmd.Flags().BoolVarP(&skipMessages, "skip-messages", "s", false, "whether to start the TUI with the setting \"skip messages\" ON")
+	cmd.Flags().BoolVarP(&hexView, "hex-view", "x", false, "whether to start the TUI with the setting \"hex view\" ON")
+	cmd.Flags().StringVar(&savePathTemplate, "save-path-template", fs.DefaultSavePathTemplate, savePathTemplateFlagUsage)
+	cmd.Flags().StringVar(&saveFormat, "save-format", "txt", saveFormatFlagUsage)
+	cmd.Flags().BoolVar(&saveRaw, "save-raw", false, saveRawFlagUsage)
+	cmd.Flags().StringVarP(&filterExpr, "filter", "f", "", filterFlagUsage)
+	cmd.Flags().BoolVar(&merge, "merge", false, mergeFlagUsage)
+	cmd.Flags().DurationVar(&mergeLateness, "merge-lateness", mergeLatenessDefault, mergeLatenessFlagUsage)
 	cmd.Flags().StringVarP(fromOffset, "from-offset", "o", "", "start consuming messages from this offset; provide a single offset for all partitions (eg. 1000) or specify offsets per partition (e.g., '0:1000,2:1500')")
 	cmd.Flags().StringVarP(fromTimestamp, "from-timestamp", "t", "", "start consuming messages from this timestamp (in RFC3339 format, e.g., 2006-01-02T15:04:05Z07:00)")
 	cmd.Flags().StringVarP(outputDir, "output-dir", "O", defaultOutputDir, "directory to persist messages in")
@@ -0,0 +1,55 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	t "github.com/dhth/kplay/internal/types"
+)
+
+const (
+	DiffNumRecordsDefault = 10000
+)
+
+// Behaviours determines the range of messages consumed from each topic, and how
+// they're matched. Both topics are consumed from the same start position (as
+// per the consume behaviours), up to their end at the time of the diff, or up
+// to EndOffset/EndTimestamp, whichever comes first.
+type Behaviours struct {
+	NumMessages  uint
+	SequencePath []string
+	EndOffset    *int64
+	EndTimestamp *time.Time
+	BatchSize    uint
+}
+
+func (b Behaviours) Display() string {
+	sequenceField := t.NotProvided
+	if len(b.SequencePath) > 0 {
+		sequenceField = strings.Join(b.SequencePath, ".")
+	}
+
+	endOffset := t.NotProvided
+	if b.EndOffset != nil {
+		endOffset = fmt.Sprintf("%d", *b.EndOffset)
+	}
+
+	endTimestamp := t.NotProvided
+	if b.EndTimestamp != nil {
+		endTimestamp = b.EndTimestamp.Format(time.RFC3339)
+	}
+
+	return fmt.Sprintf(`Diff Behaviours:
+  number of messages      %d
+  sequence field          %s
+  end offset              %s
+  end timestamp           %s
+  batch size              %d`,
+		b.NumMessages,
+		sequenceField,
+		endOffset,
+		endTimestamp,
+		b.BatchSize,
+	)
+}
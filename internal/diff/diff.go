@@ -0,0 +1,256 @@
+package diff
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	k "github.com/dhth/kplay/internal/kafka"
+	t "github.com/dhth/kplay/internal/types"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+const (
+	fetchTimeout = 5 * time.Second
+	// consuming stops if this many fetches in a row return no records; the diff
+	// fails if some partitions haven't reached their end offset by then, since
+	// messages in them would otherwise be reported as missing
+	maxIdleFetches = 2
+)
+
+var (
+	errCouldntCreateDiffReport = errors.New("couldn't create diff report")
+	errCouldntWriteDiffReport  = errors.New("couldn't write to diff report")
+)
+
+// Side is one of the topics being compared.
+type Side struct {
+	Client *kgo.Client
+	Config t.Config
+}
+
+type Differ struct {
+	a           Side
+	b           Side
+	behaviours  Behaviours
+	outputDir   string
+	matcher     *matcher
+	numConsumed [2]uint
+	completed   bool
+}
+
+func New(a, b Side, behaviours Behaviours, outputDir string) Differ {
+	return Differ{
+		a:          a,
+		b:          b,
+		behaviours: behaviours,
+		outputDir:  outputDir,
+		matcher:    newMatcher(behaviours.SequencePath),
+	}
+}
+
+func (d *Differ) Execute() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sigChan := make(chan os.Signal, 2)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigChan)
+
+	diffErrChan := make(chan error)
+
+	go func(errChan chan<- error) {
+		err := d.diff(ctx)
+		errChan <- err
+	}(diffErrChan)
+
+	select {
+	case <-sigChan:
+		cancel()
+		select {
+		case err := <-diffErrChan:
+			return err
+			// on a second signal
+		case <-sigChan:
+			return nil
+			// timeout after first signal
+		case <-time.After(5 * time.Second):
+			return t.ErrCouldntShutDownGracefully
+		}
+	case err := <-diffErrChan:
+		return err
+	}
+}
+
+func (d *Differ) diff(ctx context.Context) error {
+	reportDir := filepath.Join(d.outputDir, "diffs")
+	err := os.MkdirAll(reportDir, 0o755)
+	if err != nil {
+		return fmt.Errorf("%w: %s", t.ErrCouldntCreateDir, err.Error())
+	}
+
+	reportFilePath := filepath.Join(reportDir, fmt.Sprintf("diff-%d.jsonl", time.Now().Unix()))
+	reportFile, err := os.Create(reportFilePath)
+	if err != nil {
+		return fmt.Errorf("%w: %s", errCouldntCreateDiffReport, err.Error())
+	}
+	defer reportFile.Close()
+
+	reportWriter := bufio.NewWriter(reportFile)
+	encoder := json.NewEncoder(reportWriter)
+	encoder.SetEscapeHTML(false)
+
+	writeFinding := func(finding Finding) error {
+		if err := encoder.Encode(finding); err != nil {
+			return fmt.Errorf("%w: %s", errCouldntWriteDiffReport, err.Error())
+		}
+
+		return nil
+	}
+
+	defer func() {
+		_ = reportWriter.Flush()
+		d.reportResults(reportFilePath)
+	}()
+
+	err = d.consume(ctx, 0, d.a, func(msg t.Message) error {
+		d.matcher.addA(msg)
+		return nil
+	})
+	if err != nil || ctx.Err() != nil {
+		return err
+	}
+
+	err = d.consume(ctx, 1, d.b, func(msg t.Message) error {
+		if finding, ok := d.matcher.matchB(msg); ok {
+			return writeFinding(finding)
+		}
+		return nil
+	})
+	if err != nil || ctx.Err() != nil {
+		return err
+	}
+
+	// messages from the first topic can only be considered missing once the
+	// second one has been consumed fully
+	for _, finding := range d.matcher.missing() {
+		if err := writeFinding(finding); err != nil {
+			return err
+		}
+	}
+	d.completed = true
+
+	return nil
+}
+
+// consume consumes a topic from the start position of its client, until each
+// partition reaches its end offset (or the end timestamp), or the maximum
+// number of messages have been consumed.
+func (d *Differ) consume(ctx context.Context, index int, side Side, handle func(msg t.Message) error) error {
+	offsetRanges, err := k.GetPartitionOffsetRanges(ctx, side.Client, side.Config.Topic)
+	if err != nil {
+		return err
+	}
+
+	progress := k.NewPartitionProgress(offsetRanges, d.behaviours.EndOffset)
+
+	var numIdleFetches int
+	for !progress.Done() && d.numConsumed[index] < d.behaviours.NumMessages {
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		toFetch := min(d.behaviours.NumMessages-d.numConsumed[index], d.behaviours.BatchSize)
+
+		fetchCtx, cancel := context.WithTimeout(ctx, fetchTimeout)
+		records, err := k.FetchRecords(fetchCtx, side.Client, toFetch)
+		cancel()
+
+		if err != nil {
+			return err
+		}
+
+		if len(records) == 0 {
+			numIdleFetches++
+			if numIdleFetches >= maxIdleFetches {
+				if err := progress.Err(); err != nil {
+					return fmt.Errorf("%q: %w", side.Config.Name, err)
+				}
+				break
+			}
+			continue
+		}
+		numIdleFetches = 0
+
+		for _, record := range records {
+			// transaction markers aren't messages, but they take up offsets
+			if record.Attrs.IsControl() {
+				progress.Advance(record.Partition, record.Offset+1)
+				continue
+			}
+
+			if d.numConsumed[index] >= d.behaviours.NumMessages || !progress.Accepts(record) {
+				continue
+			}
+
+			if d.behaviours.EndTimestamp != nil && record.Timestamp.After(*d.behaviours.EndTimestamp) {
+				progress.Finish(record.Partition)
+				continue
+			}
+
+			msg := t.GetMessageFromRecord(*record, side.Config, true)
+			if err := handle(msg); err != nil {
+				return err
+			}
+			d.numConsumed[index]++
+
+			progress.Consumed(record)
+		}
+
+		if finished := progress.TakeFinished(); len(finished) > 0 {
+			side.Client.PauseFetchPartitions(map[string][]int32{side.Config.Topic: finished})
+		}
+
+		fmt.Fprintf(os.Stderr, "\r\033[Kconsuming %q: %d messages", side.Config.Name, d.numConsumed[index])
+	}
+
+	return nil
+}
+
+func (d *Differ) reportResults(reportFilePath string) {
+	fmt.Fprint(os.Stderr, "\r\033[K")
+
+	fmt.Printf(`Summary:
+
+Diff Report File:              %s
+A:                             %s (topic: %s)
+B:                             %s (topic: %s)
+Messages consumed from A:      %d
+Messages consumed from B:      %d
+Identical messages:            %d
+Differing messages:            %d
+Extra in B:                    %d
+`,
+		reportFilePath,
+		d.a.Config.Name, d.a.Config.Topic,
+		d.b.Config.Name, d.b.Config.Topic,
+		d.numConsumed[0],
+		d.numConsumed[1],
+		d.matcher.numIdentical,
+		d.matcher.numDifferent,
+		d.matcher.numExtra,
+	)
+
+	if d.completed {
+		fmt.Printf("Missing in B:                  %d\n", d.matcher.numPending)
+	} else {
+		fmt.Print("\nThe diff wasn't completed; messages missing in B weren't determined\n")
+	}
+}
@@ -0,0 +1,157 @@
+package diff
+
+import (
+	"cmp"
+	"slices"
+	"strconv"
+	"time"
+
+	s "github.com/dhth/kplay/internal/serde"
+	t "github.com/dhth/kplay/internal/types"
+)
+
+const (
+	findingMissing   = "missing"
+	findingExtra     = "extra"
+	findingDifferent = "different"
+)
+
+type messageRef struct {
+	Partition int32     `json:"partition"`
+	Offset    int64     `json:"offset"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+func getMessageRef(msg t.Message) *messageRef {
+	return &messageRef{Partition: msg.Partition, Offset: msg.Offset, Timestamp: msg.Metadata.Timestamp}
+}
+
+// Finding is a line in the diff report. Messages are "missing" if they're only
+// present in the first topic, "extra" if they're only present in the second
+// one, and "different" if their values differ.
+type Finding struct {
+	Kind        string       `json:"kind"`
+	Key         string       `json:"key"`
+	Sequence    string       `json:"sequence"`
+	A           *messageRef  `json:"a,omitempty"`
+	B           *messageRef  `json:"b,omitempty"`
+	Differences []Difference `json:"differences,omitempty"`
+}
+
+// matcher matches messages from the second topic against the ones consumed
+// from the first topic. Messages are matched by their key, along with either
+// the value of a sequence field, or the number of messages seen with the same
+// key before them.
+type matcher struct {
+	sequencePath []string
+	pending      map[string][]pendingMessage
+	occurrencesA map[string]uint
+	occurrencesB map[string]uint
+	numPending   uint
+	numIdentical uint
+	numDifferent uint
+	numExtra     uint
+}
+
+type pendingMessage struct {
+	msg      t.Message
+	sequence string
+}
+
+func newMatcher(sequencePath []string) *matcher {
+	return &matcher{
+		sequencePath: sequencePath,
+		pending:      make(map[string][]pendingMessage),
+		occurrencesA: make(map[string]uint),
+		occurrencesB: make(map[string]uint),
+	}
+}
+
+func (m *matcher) identify(msg t.Message, occurrences map[string]uint) (string, string) {
+	var sequence string
+	if len(m.sequencePath) > 0 {
+		if value, ok := parseValue(msg); ok {
+			if field, ok := s.LookupJSONField(value, m.sequencePath); ok {
+				sequence = s.JSONValueString(field)
+			}
+		}
+	} else {
+		occurrences[msg.Key]++
+		sequence = strconv.FormatUint(uint64(occurrences[msg.Key]), 10)
+	}
+
+	return msg.Key + "\x00" + sequence, sequence
+}
+
+func (m *matcher) addA(msg t.Message) {
+	identity, sequence := m.identify(msg, m.occurrencesA)
+	m.pending[identity] = append(m.pending[identity], pendingMessage{msg: msg, sequence: sequence})
+	m.numPending++
+}
+
+// matchB matches a message from the second topic, and returns a finding if
+// it's either extra, or different from its counterpart.
+func (m *matcher) matchB(msg t.Message) (Finding, bool) {
+	identity, sequence := m.identify(msg, m.occurrencesB)
+
+	candidates := m.pending[identity]
+	if len(candidates) == 0 {
+		m.numExtra++
+		return Finding{
+			Kind:     findingExtra,
+			Key:      msg.Key,
+			Sequence: sequence,
+			B:        getMessageRef(msg),
+		}, true
+	}
+
+	counterpart := candidates[0].msg
+	if len(candidates) == 1 {
+		delete(m.pending, identity)
+	} else {
+		m.pending[identity] = candidates[1:]
+	}
+	m.numPending--
+
+	same, differences := compareValues(counterpart, msg)
+	if same {
+		m.numIdentical++
+		return Finding{}, false
+	}
+
+	m.numDifferent++
+
+	return Finding{
+		Kind:        findingDifferent,
+		Key:         msg.Key,
+		Sequence:    sequence,
+		A:           getMessageRef(counterpart),
+		B:           getMessageRef(msg),
+		Differences: differences,
+	}, true
+}
+
+// missing returns findings for the messages from the first topic that weren't
+// matched, in partition and offset order.
+func (m *matcher) missing() []Finding {
+	var unmatched []pendingMessage
+	for _, candidates := range m.pending {
+		unmatched = append(unmatched, candidates...)
+	}
+
+	slices.SortFunc(unmatched, func(a, b pendingMessage) int {
+		return cmp.Or(cmp.Compare(a.msg.Partition, b.msg.Partition), cmp.Compare(a.msg.Offset, b.msg.Offset))
+	})
+
+	findings := make([]Finding, len(unmatched))
+	for i, p := range unmatched {
+		findings[i] = Finding{
+			Kind:     findingMissing,
+			Key:      p.msg.Key,
+			Sequence: p.sequence,
+			A:        getMessageRef(p.msg),
+		}
+	}
+
+	return findings
+}
@@ -0,0 +1,79 @@
+package diff
+
+import (
+	"testing"
+
+	t "github.com/dhth/kplay/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func getTestKeyedMessage(key, value string, partition int32, offset int64) t.Message {
+	return t.Message{Key: key, Value: []byte(value), Partition: partition, Offset: offset}
+}
+
+func TestMatcherMatchesMessagesByKeyAndPosition(t *testing.T) {
+	// GIVEN
+	m := newMatcher(nil)
+	m.addA(getTestKeyedMessage("order-1", `{"status": "PAID"}`, 0, 1))
+	m.addA(getTestKeyedMessage("order-1", `{"status": "SHIPPED"}`, 0, 2))
+	m.addA(getTestKeyedMessage("order-2", `{"status": "PAID"}`, 1, 1))
+	m.addA(getTestKeyedMessage("order-3", `{"status": "PAID"}`, 1, 2))
+
+	// WHEN
+	var findings []Finding
+	for _, msg := range []struct {
+		key   string
+		value string
+	}{
+		{"order-1", `{"status": "PAID"}`},
+		{"order-2", `{"status":"PAID"}`},
+		{"order-1", `{"status": "DELIVERED"}`},
+		{"order-4", `{"status": "PAID"}`},
+	} {
+		if finding, ok := m.matchB(getTestKeyedMessage(msg.key, msg.value, 0, 100)); ok {
+			findings = append(findings, finding)
+		}
+	}
+	findings = append(findings, m.missing()...)
+
+	// THEN
+	require.Len(t, findings, 3)
+
+	assert.Equal(t, findingDifferent, findings[0].Kind)
+	assert.Equal(t, "order-1", findings[0].Key)
+	assert.Equal(t, "2", findings[0].Sequence)
+	assert.Equal(t, int64(2), findings[0].A.Offset)
+	assert.Equal(t, []Difference{{Path: "$.status", Kind: differenceChanged, A: "SHIPPED", B: "DELIVERED"}}, findings[0].Differences)
+
+	assert.Equal(t, findingExtra, findings[1].Kind)
+	assert.Equal(t, "order-4", findings[1].Key)
+	assert.Nil(t, findings[1].A)
+
+	assert.Equal(t, findingMissing, findings[2].Kind)
+	assert.Equal(t, "order-3", findings[2].Key)
+	assert.Nil(t, findings[2].B)
+
+	assert.Equal(t, uint(2), m.numIdentical)
+	assert.Equal(t, uint(1), m.numDifferent)
+	assert.Equal(t, uint(1), m.numExtra)
+	assert.Equal(t, uint(1), m.numPending)
+}
+
+func TestMatcherMatchesMessagesBySequenceField(t *testing.T) {
+	// GIVEN
+	m := newMatcher([]string{"seq"})
+	m.addA(getTestKeyedMessage("order-1", `{"seq": 1, "status": "PAID"}`, 0, 1))
+	m.addA(getTestKeyedMessage("order-1", `{"seq": 2, "status": "SHIPPED"}`, 0, 2))
+
+	// WHEN
+	// messages arrive in a different order in the second topic
+	_, secondDiffers := m.matchB(getTestKeyedMessage("order-1", `{"seq": 2, "status": "SHIPPED"}`, 3, 7))
+	_, firstDiffers := m.matchB(getTestKeyedMessage("order-1", `{"seq": 1, "status": "PAID"}`, 3, 8))
+
+	// THEN
+	assert.False(t, secondDiffers)
+	assert.False(t, firstDiffers)
+	assert.Empty(t, m.missing())
+	assert.Equal(t, uint(2), m.numIdentical)
+}
@@ -0,0 +1,138 @@
+package diff
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+
+	s "github.com/dhth/kplay/internal/serde"
+	t "github.com/dhth/kplay/internal/types"
+)
+
+const (
+	differenceChanged = "changed"
+	// the value is only present in the first topic
+	differenceRemoved = "removed"
+	// the value is only present in the second topic
+	differenceAdded = "added"
+)
+
+// Difference is a difference between the decoded values of two messages. A and
+// B are nil for values missing from the respective side.
+type Difference struct {
+	Path string `json:"path"`
+	Kind string `json:"kind"`
+	A    any    `json:"a"`
+	B    any    `json:"b"`
+}
+
+// compareValues reports whether the values of two messages are the same, and
+// the differences between them. Values that are valid JSON are compared
+// structurally, so that formatting and the order of object keys don't matter;
+// other values are compared byte for byte, and differences aren't reported for
+// them.
+func compareValues(a, b t.Message) (bool, []Difference) {
+	aValue, aIsJSON := parseValue(a)
+	bValue, bIsJSON := parseValue(b)
+
+	if !aIsJSON || !bIsJSON {
+		return bytes.Equal(a.Value, b.Value), nil
+	}
+
+	differences := compareJSON("$", aValue, bValue, nil)
+
+	return len(differences) == 0, differences
+}
+
+func parseValue(msg t.Message) (any, bool) {
+	if msg.DecodeErr != nil || len(msg.Value) == 0 {
+		return nil, false
+	}
+
+	value, err := s.DecodeJSON(msg.Value)
+	if err != nil {
+		return nil, false
+	}
+
+	return value, true
+}
+
+func compareJSON(path string, a, b any, differences []Difference) []Difference {
+	switch aValue := a.(type) {
+	case map[string]any:
+		bValue, ok := b.(map[string]any)
+		if !ok {
+			break
+		}
+
+		keys := make([]string, 0, len(aValue)+len(bValue))
+		for key := range aValue {
+			keys = append(keys, key)
+		}
+		for key := range bValue {
+			if _, ok := aValue[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		slices.Sort(keys)
+
+		for _, key := range keys {
+			keyPath := fmt.Sprintf("%s.%s", path, key)
+			aField, aOK := aValue[key]
+			bField, bOK := bValue[key]
+			switch {
+			case !bOK:
+				differences = append(differences, Difference{Path: keyPath, Kind: differenceRemoved, A: aField})
+			case !aOK:
+				differences = append(differences, Difference{Path: keyPath, Kind: differenceAdded, B: bField})
+			default:
+				differences = compareJSON(keyPath, aField, bField, differences)
+			}
+		}
+
+		return differences
+	case []any:
+		bValue, ok := b.([]any)
+		if !ok {
+			break
+		}
+
+		for i := range max(len(aValue), len(bValue)) {
+			indexPath := path + "[" + strconv.Itoa(i) + "]"
+			switch {
+			case i >= len(bValue):
+				differences = append(differences, Difference{Path: indexPath, Kind: differenceRemoved, A: aValue[i]})
+			case i >= len(aValue):
+				differences = append(differences, Difference{Path: indexPath, Kind: differenceAdded, B: bValue[i]})
+			default:
+				differences = compareJSON(indexPath, aValue[i], bValue[i], differences)
+			}
+		}
+
+		return differences
+	case json.Number:
+		// numbers are compared by value, so that 1.0 and 1 are the same
+		if bValue, ok := b.(json.Number); ok && numbersEqual(aValue, bValue) {
+			return differences
+		}
+	default:
+		if a == b {
+			return differences
+		}
+	}
+
+	return append(differences, Difference{Path: path, Kind: differenceChanged, A: a, B: b})
+}
+
+func numbersEqual(a, b json.Number) bool {
+	if a == b {
+		return true
+	}
+
+	aFloat, aErr := a.Float64()
+	bFloat, bErr := b.Float64()
+
+	return aErr == nil && bErr == nil && aFloat == bFloat
+}
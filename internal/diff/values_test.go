@@ -0,0 +1,96 @@
+package diff
+
+import (
+	"encoding/json"
+	"testing"
+
+	t "github.com/dhth/kplay/internal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func getTestMessage(value string) t.Message {
+	var valueBytes []byte
+	if value != "" {
+		valueBytes = []byte(value)
+	}
+
+	return t.Message{Value: valueBytes}
+}
+
+func TestCompareValues(t *testing.T) {
+	testCases := []struct {
+		name                string
+		a                   string
+		b                   string
+		expectedSame        bool
+		expectedDifferences []Difference
+	}{
+		{
+			name:         "identical values with different formatting and key order",
+			a:            `{"id": 1, "status": "PAID"}`,
+			b:            `{"status":"PAID","id":1}`,
+			expectedSame: true,
+		},
+		{
+			name:         "equal numbers with different representations",
+			a:            `{"amount": 10}`,
+			b:            `{"amount": 10.0}`,
+			expectedSame: true,
+		},
+		{
+			name:         "tombstones",
+			expectedSame: true,
+		},
+		{
+			name: "changed, removed, and added fields",
+			a:    `{"id": 1, "status": "PAID", "customer": {"name": "a", "tier": "gold"}}`,
+			b:    `{"id": 1, "status": "REFUNDED", "customer": {"name": "a", "region": "eu"}}`,
+			expectedDifferences: []Difference{
+				{Path: "$.customer.region", Kind: differenceAdded, B: "eu"},
+				{Path: "$.customer.tier", Kind: differenceRemoved, A: "gold"},
+				{Path: "$.status", Kind: differenceChanged, A: "PAID", B: "REFUNDED"},
+			},
+		},
+		{
+			name: "arrays of different lengths",
+			a:    `{"items": [1, 2, 3]}`,
+			b:    `{"items": [1, 5]}`,
+			expectedDifferences: []Difference{
+				{Path: "$.items[1]", Kind: differenceChanged, A: json.Number("2"), B: json.Number("5")},
+				{Path: "$.items[2]", Kind: differenceRemoved, A: json.Number("3")},
+			},
+		},
+		{
+			name: "values of different types",
+			a:    `{"id": "1"}`,
+			b:    `{"id": 1}`,
+			expectedDifferences: []Difference{
+				{Path: "$.id", Kind: differenceChanged, A: "1", B: json.Number("1")},
+			},
+		},
+		{
+			name:         "identical non-JSON values",
+			a:            "plain text",
+			b:            "plain text",
+			expectedSame: true,
+		},
+		{
+			name: "different non-JSON values",
+			a:    "plain text",
+			b:    "other text",
+		},
+		{
+			name: "tombstone and a value",
+			a:    `{"id": 1}`,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			same, differences := compareValues(getTestMessage(tt.a), getTestMessage(tt.b))
+
+			assert.Equal(t, tt.expectedSame, same)
+			assert.Equal(t, tt.expectedDifferences, differences)
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package filter
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"unicode/utf8"
+
+	t "github.com/dhth/kplay/internal/types"
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/ext"
+)
+
+var (
+	ErrInvalidFilter       = errors.New("invalid filter expression")
+	errFilterOutputNotBool = errors.New("expression doesn't evaluate to a bool")
+)
+
+// Filter is a compiled CEL expression that messages are matched against. The
+// expression has access to the following variables:
+//
+//   - key: the decoded key
+//   - value: the decoded value; JSON values are available as maps, lists,
+//     etc., other values as strings, and values that couldn't be decoded (as
+//     well as tombstones) as null
+//   - headers: a map of header keys to their decoded values
+//   - partition, offset: ints
+//   - timestamp: the message's timestamp
+type Filter struct {
+	expression string
+	program    cel.Program
+}
+
+func New(expression string) (*Filter, error) {
+	env, err := cel.NewEnv(
+		cel.Variable("key", cel.StringType),
+		cel.Variable("value", cel.DynType),
+		cel.Variable("headers", cel.MapType(cel.StringType, cel.StringType)),
+		cel.Variable("partition", cel.IntType),
+		cel.Variable("offset", cel.IntType),
+		cel.Variable("timestamp", cel.TimestampType),
+		ext.Strings(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidFilter, err.Error())
+	}
+
+	ast, issues := env.Compile(expression)
+	if issues.Err() != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidFilter, issues.Err().Error())
+	}
+
+	outputType := ast.OutputType()
+	if !outputType.IsExactType(cel.BoolType) && !outputType.IsExactType(cel.DynType) {
+		return nil, fmt.Errorf("%w: %s (got %s)", ErrInvalidFilter, errFilterOutputNotBool.Error(), outputType)
+	}
+
+	program, err := env.Program(ast)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidFilter, err.Error())
+	}
+
+	return &Filter{
+		expression: expression,
+		program:    program,
+	}, nil
+}
+
+// Matches reports whether a message satisfies the filter. Expressions that
+// fail to evaluate for a message (eg. because a field it refers to is absent)
+// don't match it.
+func (f *Filter) Matches(msg t.Message) bool {
+	headers := make(map[string]string, len(msg.Headers))
+	for _, h := range msg.Headers {
+		headers[h.Key] = h.Value
+	}
+
+	out, _, err := f.program.Eval(map[string]any{
+		"key":       msg.Key,
+		"value":     getFilterValue(msg),
+		"headers":   headers,
+		"partition": int64(msg.Partition),
+		"offset":    msg.Offset,
+		"timestamp": msg.Metadata.Timestamp,
+	})
+	if err != nil {
+		return false
+	}
+
+	matches, ok := out.Value().(bool)
+
+	return ok && matches
+}
+
+func (f *Filter) String() string {
+	return f.expression
+}
+
+func getFilterValue(msg t.Message) any {
+	if len(msg.Value) == 0 || msg.DecodeErr != nil {
+		return nil
+	}
+
+	var value any
+	if err := json.Unmarshal(msg.Value, &value); err == nil {
+		return value
+	}
+
+	if utf8.Valid(msg.Value) {
+		return string(msg.Value)
+	}
+
+	return msg.Value
+}
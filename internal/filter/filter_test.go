@@ -0,0 +1,147 @@
+package filter
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	t "github.com/dhth/kplay/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func getTestMessage() t.Message {
+	return t.Message{
+		Metadata: t.Metadata{
+			Timestamp: time.Date(2025, 4, 6, 11, 18, 3, 0, time.UTC),
+		},
+		Offset:    42,
+		Partition: 1,
+		Key:       "order-1",
+		Value:     []byte(`{"status": "FAILED", "amount": 10, "items": [{"sku": "s-1"}]}`),
+		Headers: []t.Header{
+			{Key: "source", Value: "billing"},
+		},
+	}
+}
+
+func TestFilterMatches(t *testing.T) {
+	testCases := []struct {
+		name       string
+		expression string
+		expected   bool
+	}{
+		{
+			name:       "value and headers",
+			expression: `value.status == "FAILED" && headers["source"] == "billing"`,
+			expected:   true,
+		},
+		{
+			name:       "numeric value",
+			expression: `value.amount > 5`,
+			expected:   true,
+		},
+		{
+			name:       "nested lists",
+			expression: `value.items.exists(i, i.sku == "s-1")`,
+			expected:   true,
+		},
+		{
+			name:       "key, partition, and offset",
+			expression: `key.startsWith("order-") && partition == 1 && offset >= 40`,
+			expected:   true,
+		},
+		{
+			name:       "timestamp",
+			expression: `timestamp > timestamp("2025-01-01T00:00:00Z")`,
+			expected:   true,
+		},
+		{
+			name:       "string extensions",
+			expression: `value.status.lowerAscii() == "failed"`,
+			expected:   true,
+		},
+		{
+			name:       "expression that doesn't match",
+			expression: `value.status == "SUCCEEDED"`,
+			expected:   false,
+		},
+		{
+			name:       "absent field doesn't match",
+			expression: `value.reason == "timeout"`,
+			expected:   false,
+		},
+		{
+			name:       "absent header doesn't match",
+			expression: `headers["trace-id"] == "abc"`,
+			expected:   false,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := New(tt.expression)
+			require.NoError(t, err)
+
+			got := filter.Matches(getTestMessage())
+
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestFilterTreatsUndecodedValuesAsNull(t *testing.T) {
+	// GIVEN
+	filter, err := New(`value == null`)
+	require.NoError(t, err)
+	msg := getTestMessage()
+	msg.DecodeErr = errors.New("couldn't decode")
+
+	// WHEN
+	got := filter.Matches(msg)
+
+	// THEN
+	assert.True(t, got)
+}
+
+func TestFilterExposesNonJSONValuesAsStrings(t *testing.T) {
+	// GIVEN
+	filter, err := New(`value.contains("needle")`)
+	require.NoError(t, err)
+	msg := getTestMessage()
+	msg.Value = []byte("a haystack with a needle")
+
+	// WHEN
+	got := filter.Matches(msg)
+
+	// THEN
+	assert.True(t, got)
+}
+
+func TestNewFailsForInvalidExpressions(t *testing.T) {
+	testCases := []struct {
+		name       string
+		expression string
+	}{
+		{
+			name:       "syntax error",
+			expression: `value.status ==`,
+		},
+		{
+			name:       "unknown variable",
+			expression: `body.status == "FAILED"`,
+		},
+		{
+			name:       "non-bool output",
+			expression: `offset + 1`,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.expression)
+
+			assert.ErrorIs(t, err, ErrInvalidFilter)
+		})
+	}
+}
@@ -2,6 +2,9 @@ package forwarder
 
 import (
 	"fmt"
+
+	"github.com/dhth/kplay/internal/filter"
+	t "github.com/dhth/kplay/internal/types"
 )
 
 type Behaviours struct {
@@ -18,9 +21,16 @@ type Behaviours struct {
 	RunServer                      bool
 	ServerHost                     string
 	ServerPort                     uint16
+	Filter                         *filter.Filter
+	SaveRaw                        bool
 }
 
 func (b Behaviours) Display() string {
+	filterExpr := t.NotProvided
+	if b.Filter != nil {
+		filterExpr = b.Filter.String()
+	}
+
 	value := fmt.Sprintf(`Forward Behaviours:
   consumer group          %s
   fetch batch size        %d
@@ -31,7 +41,9 @@ func (b Behaviours) Display() string {
   poll sleep (ms)         %d
   log JSON                %v
   run server              %v
-  upload reports          %v`,
+  upload reports          %v
+  filter                  %s
+  save raw bytes          %v`,
 		b.ConsumerGroup,
 		b.FetchBatchSize,
 		b.NumUploadWorkers,
@@ -42,6 +54,8 @@ func (b Behaviours) Display() string {
 		b.LogJSON,
 		b.RunServer,
 		b.UploadReports,
+		filterExpr,
+		b.SaveRaw,
 	)
 
 	if b.UploadReports {
@@ -9,11 +9,12 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
-	"strings"
+	"path/filepath"
 	"sync"
 	"syscall"
 	"time"
 
+	"github.com/dhth/kplay/internal/fs"
 	k "github.com/dhth/kplay/internal/kafka"
 	t "github.com/dhth/kplay/internal/types"
 
@@ -39,11 +40,14 @@ type Forwarder struct {
 type uploadWork struct {
 	msg      t.Message
 	fileName string
+	// only set if raw bytes are to be uploaded as well
+	rawFileName string
 }
 
 type uploadResult struct {
-	work        uploadWork
-	err         error
+	work uploadWork
+	err  error
+	// attempts made across the message's uploads (including the raw one)
 	numAttempts int
 }
 
@@ -204,6 +208,7 @@ func (f *Forwarder) startForwarder(ctx context.Context) {
 
 	pendingWork := make([]uploadWork, 0)
 	var numRecordsProcessed uint64
+	var numRecordsFilteredOut uint64
 
 	var uploadWg sync.WaitGroup
 	slog.Info("starting upload workers", "num", f.behaviours.NumUploadWorkers)
@@ -239,7 +244,7 @@ func (f *Forwarder) startForwarder(ctx context.Context) {
 				slog.Info("reporter worker shut down", "num_report_rows_written", numReportRowsWritten)
 			}
 
-			slog.Info("forwarder shut down", "num_records_processed", numRecordsProcessed)
+			slog.Info("forwarder shut down", "num_records_processed", numRecordsProcessed, "num_records_filtered_out", numRecordsFilteredOut)
 			return
 		default:
 			if len(pendingWork) > 0 {
@@ -266,26 +271,71 @@ func (f *Forwarder) startForwarder(ctx context.Context) {
 					}
 				} else if len(records) > 0 {
 					for _, record := range records {
+						msg := t.GetMessageFromRecord(*record, f.configs[clientIndex], true)
+						if f.behaviours.Filter != nil && !f.behaviours.Filter.Matches(msg) {
+							slog.Debug("skipping record that doesn't match the filter",
+								"key", msg.Key,
+								"topic", record.Topic,
+								"offset", record.Offset,
+								"partition", record.Partition,
+							)
+							numRecordsFilteredOut++
+							continue
+						}
 						slog.Info("processing record",
-							"key", string(record.Key),
+							"key", msg.Key,
 							"topic", record.Topic,
 							"offset", record.Offset,
 							"partition", record.Partition,
 							"value_bytes", len(record.Value),
 						)
-						msg := t.GetMessageFromRecord(*record, f.configs[clientIndex], true)
+						if msg.KeyDecodeErr != nil {
+							slog.Warn("couldn't decode record key",
+								"key", msg.Key,
+								"topic", record.Topic,
+								"offset", record.Offset,
+								"partition", record.Partition,
+								"key_decode_error", msg.KeyDecodeErr,
+							)
+						}
 						if msg.DecodeErr != nil {
 							slog.Warn("couldn't decode record",
-								"key", string(record.Key),
+								"key", msg.Key,
 								"topic", record.Topic,
 								"offset", record.Offset,
 								"partition", record.Partition,
 								"decode_error", msg.DecodeErr,
 							)
 						}
+						// messages are uploaded in the default save layout
+						filePath, err := fs.SaveLayout{}.Path(msg)
+						if err != nil {
+							slog.Error("couldn't determine where to upload record",
+								"key", msg.Key,
+								"topic", record.Topic,
+								"offset", record.Offset,
+								"partition", record.Partition,
+								"error", err,
+							)
+							continue
+						}
 						work := uploadWork{
 							msg:      msg,
-							fileName: fmt.Sprintf("%s/partition-%d/offset-%d.txt", record.Topic, record.Partition, record.Offset),
+							fileName: filepath.ToSlash(filePath),
+						}
+						if f.behaviours.SaveRaw {
+							rawFilePath, err := fs.SaveLayout{}.RawPath(msg)
+							if err != nil {
+								slog.Error("couldn't determine where to upload record's raw bytes",
+									"key", msg.Key,
+									"topic", record.Topic,
+									"offset", record.Offset,
+									"partition", record.Partition,
+									"error", err,
+								)
+								continue
+							}
+							work.rawFileName = filepath.ToSlash(rawFilePath)
 						}
 						pendingWork = append(pendingWork, work)
 						numRecordsProcessed++
@@ -333,30 +383,53 @@ func (f *Forwarder) processUpload(
 	work uploadWork,
 	resultChan chan<- uploadResult,
 ) {
-	objectKey := f.destination.getDestinationFilePath(work.fileName)
 	result := uploadResult{
 		work: work,
 	}
 
+	result.numAttempts, result.err = f.uploadWithRetries(ctx, []byte(work.msg.GetDetails()), work.fileName, "text/plain")
+
+	if result.err == nil && f.behaviours.SaveRaw {
+		rawDetails, err := work.msg.GetRawDetails()
+		if err != nil {
+			result.err = fmt.Errorf("raw upload: %w", err)
+		} else {
+			numAttempts, err := f.uploadWithRetries(ctx, rawDetails, work.rawFileName, "application/json")
+			result.numAttempts += numAttempts
+			if err != nil {
+				result.err = fmt.Errorf("raw upload: %w", err)
+			}
+		}
+	}
+
+	if resultChan != nil {
+		resultChan <- result
+	}
+}
+
+// uploadWithRetries uploads a file, retrying on failures, and returns the number
+// of attempts made.
+func (f *Forwarder) uploadWithRetries(ctx context.Context, body []byte, fileName, contentType string) (int, error) {
+	objectKey := f.destination.getDestinationFilePath(fileName)
+
+	var numAttempts int
+	var err error
 	for i := range numUploadRetryAttempts {
-		result.numAttempts = i
-		bodyReader := strings.NewReader(work.msg.GetDetails())
+		numAttempts = i + 1
 		uploadCtx, uploadCancel := context.WithTimeout(context.WithoutCancel(ctx), time.Duration(f.behaviours.UploadTimeoutMillis)*time.Millisecond)
-		result.err = f.destination.upload(uploadCtx, bodyReader, work.fileName, "text/plain")
+		err = f.destination.upload(uploadCtx, bytes.NewReader(body), fileName, contentType)
 		uploadCancel()
 
-		if result.err == nil {
-			if result.numAttempts > 0 {
-				slog.Info("uploading to s3 succeeded after failures", "object_key", objectKey, "attempt_num", i+1)
+		if err == nil {
+			if numAttempts > 1 {
+				slog.Info("uploading to s3 succeeded after failures", "object_key", objectKey, "attempt_num", numAttempts)
 			}
 			break
 		}
-		slog.Error("uploading to s3 failed", "object_key", objectKey, "attempt_num", i+1, "error", result.err)
+		slog.Error("uploading to s3 failed", "object_key", objectKey, "attempt_num", i+1, "error", err)
 	}
 
-	if resultChan != nil {
-		resultChan <- result
-	}
+	return numAttempts, err
 }
 
 func (f *Forwarder) startReporterWorker(
@@ -51,7 +51,7 @@ func (w *reportWriter) writeRow(result uploadResult) error {
 	row := []string{
 		fmt.Sprintf("%d", msg.Partition),
 		fmt.Sprintf("%d", msg.Offset),
-		msg.Timestamp.Format(time.RFC3339),
+		msg.Metadata.Timestamp.Format(time.RFC3339),
 		msg.Key,
 		tombstone,
 		decodeErr,
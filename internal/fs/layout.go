@@ -0,0 +1,278 @@
+package fs
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	t "github.com/dhth/kplay/internal/types"
+)
+
+// DefaultSavePathTemplate is where messages are saved, relative to the
+// messages directory, unless a template is provided.
+const DefaultSavePathTemplate = "{{.Topic}}/partition-{{.Partition}}/offset-{{.Offset}}.{{.Ext}}"
+
+// values longer than this are truncated when used as a path segment
+const maxPathSegmentLength = 128
+
+var (
+	errSavePathTemplateInvalid = errors.New("save path template is invalid")
+	errSavePathInvalid         = errors.New("save path is invalid")
+	errSavePathOverwrites      = errors.New("it needs to refer to .Offset, so that messages don't overwrite each other")
+)
+
+var defaultSavePathTemplate = template.Must(template.New("save-path").Parse(DefaultSavePathTemplate))
+
+type SaveFormat uint
+
+const (
+	TextSaveFormat SaveFormat = iota
+	JSONSaveFormat
+	ValueSaveFormat
+)
+
+func ValidateSaveFormatValue(value string) (SaveFormat, error) {
+	switch value {
+	case "txt":
+		return TextSaveFormat, nil
+	case "json":
+		return JSONSaveFormat, nil
+	case "value":
+		return ValueSaveFormat, nil
+	default:
+		return TextSaveFormat, fmt.Errorf("save format is incorrect; possible values: [txt, json, value]")
+	}
+}
+
+func (f SaveFormat) String() string {
+	switch f {
+	case TextSaveFormat:
+		return "txt"
+	case JSONSaveFormat:
+		return "json"
+	case ValueSaveFormat:
+		return "value"
+	default:
+		return "unknown"
+	}
+}
+
+// SaveLayout determines where (relative to the messages directory) and how
+// messages are saved. The zero value saves messages in the default layout, as
+// text.
+type SaveLayout struct {
+	pathTemplate    *template.Template
+	pathTemplateStr string
+	Format          SaveFormat
+	// whether to save the raw bytes of messages as well (see RawPath)
+	Raw bool
+}
+
+// savePathData is what save path templates are executed against.
+type savePathData struct {
+	Topic     string
+	Partition int32
+	Offset    int64
+	Key       string
+	Timestamp time.Time
+	// the record's timestamp in UTC, as 2006-01-02
+	Date string
+	// the file extension for the save format
+	Ext string
+}
+
+func NewSaveLayout(pathTemplate string, format SaveFormat, raw bool) (SaveLayout, error) {
+	layout := SaveLayout{
+		Format: format,
+		Raw:    raw,
+	}
+
+	tmpl, err := template.New("save-path").Parse(pathTemplate)
+	if err != nil {
+		return layout, fmt.Errorf("%w: %s", errSavePathTemplateInvalid, err.Error())
+	}
+	layout.pathTemplate = tmpl
+	layout.pathTemplateStr = pathTemplate
+
+	// referring to fields that don't exist, or to a location outside the
+	// messages directory, is only detected when the template is executed
+	sample := t.Message{Topic: "topic", Key: "key"}
+	samplePath, err := layout.Path(sample)
+	if err != nil {
+		return layout, fmt.Errorf("%w: %s", errSavePathTemplateInvalid, err.Error())
+	}
+
+	sample.Offset++
+	nextPath, err := layout.Path(sample)
+	if err != nil {
+		return layout, fmt.Errorf("%w: %s", errSavePathTemplateInvalid, err.Error())
+	}
+
+	if nextPath == samplePath {
+		return layout, fmt.Errorf("%w: %w", errSavePathTemplateInvalid, errSavePathOverwrites)
+	}
+
+	return layout, nil
+}
+
+func (l SaveLayout) PathTemplate() string {
+	if l.pathTemplate == nil {
+		return DefaultSavePathTemplate
+	}
+
+	return l.pathTemplateStr
+}
+
+func (l SaveLayout) template() *template.Template {
+	if l.pathTemplate == nil {
+		return defaultSavePathTemplate
+	}
+
+	return l.pathTemplate
+}
+
+// Path returns the path a message is saved at, relative to the messages
+// directory. The message's key and topic are sanitized before being used in
+// the path.
+func (l SaveLayout) Path(msg t.Message) (string, error) {
+	return l.render(msg, l.extension(msg))
+}
+
+// RawPath returns the path of the file holding the raw bytes of a message,
+// relative to the messages directory, eg, "partition-0/offset-12.raw.json" for
+// "partition-0/offset-12.txt". For templates that don't refer to .Ext, the raw
+// file suffix is appended to the message's path instead.
+func (l SaveLayout) RawPath(msg t.Message) (string, error) {
+	path, err := l.Path(msg)
+	if err != nil {
+		return "", err
+	}
+
+	rawPath, err := l.render(msg, rawFileExt)
+	if err != nil {
+		return "", err
+	}
+
+	if rawPath == path {
+		return path + "." + rawFileExt, nil
+	}
+
+	return rawPath, nil
+}
+
+func (l SaveLayout) render(msg t.Message, ext string) (string, error) {
+	data := savePathData{
+		Topic:     sanitizePathSegment(msg.Topic),
+		Partition: msg.Partition,
+		Offset:    msg.Offset,
+		Key:       sanitizePathSegment(msg.Key),
+		Timestamp: msg.Metadata.Timestamp,
+		Date:      msg.Metadata.Timestamp.UTC().Format(time.DateOnly),
+		Ext:       ext,
+	}
+
+	var buf bytes.Buffer
+	if err := l.template().Execute(&buf, data); err != nil {
+		return "", err
+	}
+
+	path := filepath.Clean(filepath.FromSlash(buf.String()))
+	if path == "." || filepath.IsAbs(path) || path == ".." || strings.HasPrefix(path, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%w: %q is not inside the messages directory", errSavePathInvalid, buf.String())
+	}
+
+	return path, nil
+}
+
+// Save writes a message to its path under dir, and returns the path it was
+// written to.
+func (l SaveLayout) Save(msg t.Message, dir string) (string, error) {
+	relativePath, err := l.Path(msg)
+	if err != nil {
+		return "", err
+	}
+
+	contents, err := l.contents(msg)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", t.ErrCouldntWriteToFile, err)
+	}
+
+	path := filepath.Join(dir, relativePath)
+
+	var rawPath string
+	if l.Raw {
+		relativeRawPath, err := l.RawPath(msg)
+		if err != nil {
+			return "", err
+		}
+		rawPath = filepath.Join(dir, relativeRawPath)
+	}
+
+	return path, saveToFileSystem(msg, contents, path, rawPath)
+}
+
+func (l SaveLayout) contents(msg t.Message) ([]byte, error) {
+	switch l.Format {
+	case JSONSaveFormat:
+		return json.MarshalIndent(msg.ToSerializable(), "", "  ")
+	case ValueSaveFormat:
+		return []byte(msg.ValueDisplay()), nil
+	default:
+		return []byte(msg.GetDetails()), nil
+	}
+}
+
+// extension returns the file extension for a message; decoded values are
+// saved with a "json" extension if they're valid JSON.
+func (l SaveLayout) extension(msg t.Message) string {
+	switch l.Format {
+	case JSONSaveFormat:
+		return "json"
+	case ValueSaveFormat:
+		if msg.DecodeErr == nil && len(msg.Value) > 0 && json.Valid(msg.Value) {
+			return "json"
+		}
+		return "txt"
+	default:
+		return "txt"
+	}
+}
+
+// sanitizePathSegment makes a value safe to use as a single path segment, by
+// replacing path separators, characters that aren't allowed in file names on
+// common file systems, and control characters with "_", and truncating long
+// values.
+func sanitizePathSegment(value string) string {
+	sanitized := strings.Map(func(r rune) rune {
+		switch {
+		case r == utf8.RuneError, !unicode.IsPrint(r):
+			return '_'
+		case strings.ContainsRune(`/\:*?"<>|`, r):
+			return '_'
+		default:
+			return r
+		}
+	}, value)
+
+	if len(sanitized) > maxPathSegmentLength {
+		cut := maxPathSegmentLength
+		for cut > 0 && !utf8.RuneStart(sanitized[cut]) {
+			cut--
+		}
+		sanitized = sanitized[:cut]
+	}
+
+	// empty values, and values like "." and ".." would refer to directories
+	if strings.Trim(sanitized, ".") == "" {
+		return strings.Repeat("_", max(len(sanitized), 1))
+	}
+
+	return sanitized
+}
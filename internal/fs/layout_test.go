@@ -0,0 +1,271 @@
+package fs
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	t "github.com/dhth/kplay/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func getTestMessage(key, value string) t.Message {
+	return t.Message{
+		Topic:     "orders",
+		Partition: 2,
+		Offset:    12,
+		Key:       key,
+		RawKey:    []byte(key),
+		Value:     []byte(value),
+		RawValue:  []byte(value),
+		Metadata: t.Metadata{
+			Timestamp: time.Date(2025, 4, 6, 23, 18, 3, 0, time.FixedZone("", -2*60*60)),
+		},
+	}
+}
+
+func TestSaveLayoutPath(t *testing.T) {
+	testCases := []struct {
+		name     string
+		template string
+		format   SaveFormat
+		key      string
+		value    string
+		expected string
+	}{
+		{
+			name:     "default template",
+			template: DefaultSavePathTemplate,
+			key:      "order-1",
+			expected: "orders/partition-2/offset-12.txt",
+		},
+		{
+			name:     "default template with json format",
+			template: DefaultSavePathTemplate,
+			format:   JSONSaveFormat,
+			key:      "order-1",
+			expected: "orders/partition-2/offset-12.json",
+		},
+		{
+			name:     "date and key",
+			template: "{{.Topic}}/{{.Date}}/{{.Key}}-{{.Offset}}.{{.Ext}}",
+			key:      "order-1",
+			expected: "orders/2025-04-07/order-1-12.txt",
+		},
+		{
+			name:     "formatted timestamp",
+			template: `{{.Timestamp.UTC.Format "2006/01/02/15"}}/{{.Offset}}.txt`,
+			key:      "order-1",
+			expected: "2025/04/07/01/12.txt",
+		},
+		{
+			name:     "value format for a JSON value",
+			template: DefaultSavePathTemplate,
+			format:   ValueSaveFormat,
+			key:      "order-1",
+			value:    `{"id": 1}`,
+			expected: "orders/partition-2/offset-12.json",
+		},
+		{
+			name:     "value format for a text value",
+			template: DefaultSavePathTemplate,
+			format:   ValueSaveFormat,
+			key:      "order-1",
+			value:    "not json",
+			expected: "orders/partition-2/offset-12.txt",
+		},
+		{
+			name:     "key with path separators",
+			template: "{{.Key}}-{{.Offset}}.{{.Ext}}",
+			key:      "../../etc/passwd",
+			expected: ".._.._etc_passwd-12.txt",
+		},
+		{
+			name:     "key with reserved and control characters",
+			template: "{{.Key}}-{{.Offset}}.{{.Ext}}",
+			key:      "a:b*c?\"d<e>f|g\\h\ni\x00j",
+			expected: "a_b_c__d_e_f_g_h_i_j-12.txt",
+		},
+		{
+			name:     "key referring to the parent directory",
+			template: "{{.Key}}/{{.Offset}}.{{.Ext}}",
+			key:      "..",
+			expected: "__/12.txt",
+		},
+		{
+			name:     "empty key",
+			template: "{{.Key}}/{{.Offset}}.{{.Ext}}",
+			key:      "",
+			expected: "_/12.txt",
+		},
+		{
+			name:     "long key",
+			template: "{{.Key}}/{{.Offset}}",
+			key:      strings.Repeat("é", 100),
+			expected: strings.Repeat("é", 64) + "/12",
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			layout, err := NewSaveLayout(tt.template, tt.format, false)
+			require.NoError(t, err)
+
+			// WHEN
+			got, err := layout.Path(getTestMessage(tt.key, tt.value))
+
+			// THEN
+			require.NoError(t, err)
+			assert.Equal(t, filepath.FromSlash(tt.expected), got)
+		})
+	}
+}
+
+func TestZeroSaveLayoutUsesDefaultTemplate(t *testing.T) {
+	// GIVEN
+	var layout SaveLayout
+
+	// WHEN
+	got, err := layout.Path(getTestMessage("order-1", ""))
+
+	// THEN
+	require.NoError(t, err)
+	assert.Equal(t, filepath.FromSlash("orders/partition-2/offset-12.txt"), got)
+	assert.Equal(t, DefaultSavePathTemplate, layout.PathTemplate())
+}
+
+func TestNewSaveLayoutFails(t *testing.T) {
+	testCases := []struct {
+		name     string
+		template string
+	}{
+		{
+			name:     "incorrect syntax",
+			template: "{{.Topic}/{{.Offset}}",
+		},
+		{
+			name:     "unknown field",
+			template: "{{.Topic}}/{{.Unknown}}",
+		},
+		{
+			name:     "empty template",
+			template: "",
+		},
+		{
+			name:     "parent directory",
+			template: "../{{.Offset}}.txt",
+		},
+		{
+			name:     "absolute path",
+			template: "/tmp/{{.Offset}}.txt",
+		},
+		{
+			name:     "not referring to the offset",
+			template: "{{.Topic}}/{{.Key}}.{{.Ext}}",
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			// WHEN
+			_, err := NewSaveLayout(tt.template, TextSaveFormat, false)
+
+			// THEN
+			assert.ErrorIs(t, err, errSavePathTemplateInvalid)
+		})
+	}
+}
+
+func TestSaveLayoutSave(t *testing.T) {
+	testCases := []struct {
+		name     string
+		format   SaveFormat
+		expected string
+	}{
+		{
+			name:     "txt",
+			format:   TextSaveFormat,
+			expected: "Metadata",
+		},
+		{
+			name:     "json",
+			format:   JSONSaveFormat,
+			expected: `"value": "{\"id\": 1}"`,
+		},
+		{
+			name:     "value",
+			format:   ValueSaveFormat,
+			expected: `{"id": 1}`,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			layout, err := NewSaveLayout(DefaultSavePathTemplate, tt.format, true)
+			require.NoError(t, err)
+			dir := t.TempDir()
+
+			// WHEN
+			path, err := layout.Save(getTestMessage("order-1", `{"id": 1}`), dir)
+
+			// THEN
+			require.NoError(t, err)
+			contents, err := os.ReadFile(path)
+			require.NoError(t, err)
+			assert.Contains(t, string(contents), tt.expected)
+
+			rawContents, err := os.ReadFile(filepath.Join(filepath.Dir(path), "offset-12.raw.json"))
+			require.NoError(t, err)
+			assert.Contains(t, string(rawContents), `"value": "eyJpZCI6IDF9"`)
+		})
+	}
+}
+
+func TestSaveLayoutRawPath(t *testing.T) {
+	testCases := []struct {
+		name     string
+		template string
+		key      string
+		expected string
+	}{
+		{
+			name:     "default template",
+			template: DefaultSavePathTemplate,
+			key:      "order-1",
+			expected: "orders/partition-2/offset-12.raw.json",
+		},
+		{
+			name:     "template without an extension",
+			template: "{{.Topic}}/{{.Key}}-{{.Offset}}",
+			key:      "order.v2",
+			expected: "orders/order.v2-12.raw.json",
+		},
+		{
+			name:     "template with a fixed extension",
+			template: "{{.Topic}}/{{.Offset}}.txt",
+			key:      "order-1",
+			expected: "orders/12.txt.raw.json",
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			layout, err := NewSaveLayout(tt.template, TextSaveFormat, true)
+			require.NoError(t, err)
+
+			// WHEN
+			got, err := layout.RawPath(getTestMessage(tt.key, `{"id": 1}`))
+
+			// THEN
+			require.NoError(t, err)
+			assert.Equal(t, filepath.FromSlash(tt.expected), got)
+		})
+	}
+}
@@ -8,15 +8,41 @@ import (
 	t "github.com/dhth/kplay/internal/types"
 )
 
-func SaveMessageToFileSystem(msg t.Message, path string) error {
+// rawFileExt is the file extension of the files holding the raw bytes of saved
+// messages
+const rawFileExt = "raw.json"
+
+// saveToFileSystem writes a message's contents to path. If rawPath is
+// provided, the message's raw bytes are written to a JSON envelope at it (see
+// SaveLayout.RawPath).
+func saveToFileSystem(msg t.Message, contents []byte, path, rawPath string) error {
 	dir := filepath.Dir(path)
 	err := os.MkdirAll(dir, 0o755)
 	if err != nil {
 		return fmt.Errorf("%w: %w", t.ErrCouldntCreateDir, err)
 	}
-	details := msg.GetDetails()
 
-	err = os.WriteFile(path, []byte(details), 0o644)
+	err = os.WriteFile(path, contents, 0o644)
+	if err != nil {
+		return fmt.Errorf("%w: %w", t.ErrCouldntWriteToFile, err)
+	}
+
+	if rawPath == "" {
+		return nil
+	}
+
+	rawDetails, err := msg.GetRawDetails()
+	if err != nil {
+		return fmt.Errorf("%w: %w", t.ErrCouldntWriteToFile, err)
+	}
+
+	// templates can refer to .Ext in directories as well
+	err = os.MkdirAll(filepath.Dir(rawPath), 0o755)
+	if err != nil {
+		return fmt.Errorf("%w: %w", t.ErrCouldntCreateDir, err)
+	}
+
+	err = os.WriteFile(rawPath, rawDetails, 0o644)
 	if err != nil {
 		return fmt.Errorf("%w: %w", t.ErrCouldntWriteToFile, err)
 	}
@@ -6,15 +6,24 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"slices"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	t "github.com/dhth/kplay/internal/types"
+	"github.com/twmb/franz-go/pkg/kerr"
 	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/kmsg"
 	kaws "github.com/twmb/franz-go/pkg/sasl/aws"
 )
 
-var errCouldntCreateKafkaClient = errors.New("couldn't create kafka client")
+const topicPartitionsRequestTimeout = 10 * time.Second
+
+var (
+	errCouldntCreateKafkaClient  = errors.New("couldn't create kafka client")
+	errCouldntGetTopicPartitions = errors.New("couldn't get topic partitions")
+	errNoPartitionsToResumeFrom  = errors.New("no partitions to resume consuming from")
+)
 
 type Builder struct {
 	opts []kgo.Opt
@@ -84,6 +93,12 @@ func (b Builder) WithPartitionOffsets(topic string, partitionOffsets map[int32]i
 	return b
 }
 
+func (b Builder) WithPartitionStartOffsets(topic string, offsets map[int32]kgo.Offset) Builder {
+	b.opts = append(b.opts, kgo.ConsumePartitions(map[string]map[int32]kgo.Offset{topic: offsets}))
+
+	return b
+}
+
 func (b Builder) WithStartTimestamp(topic string, timestamp time.Time) Builder {
 	millis := timestamp.UnixMilli()
 	b.opts = append(b.opts, kgo.ConsumeTopics(topic))
@@ -92,6 +107,12 @@ func (b Builder) WithStartTimestamp(topic string, timestamp time.Time) Builder {
 	return b
 }
 
+func (b Builder) WithControlRecords() Builder {
+	b.opts = append(b.opts, kgo.KeepControlRecords())
+
+	return b
+}
+
 func (b Builder) WithConsumerGroup(topic, group string) Builder {
 	b.opts = append(b.opts, kgo.ConsumeTopics(topic))
 	b.opts = append(b.opts, kgo.ConsumerGroup(group))
@@ -107,13 +128,64 @@ func GetKafkaClient(
 	consumeBehaviours t.ConsumeBehaviours,
 	awsCfg *aws.Config,
 ) (*kgo.Client, error) {
+	builder, err := getConsumerBuilder(auth, brokers, topic, consumeBehaviours, awsCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := builder.Build()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errCouldntCreateKafkaClient, err.Error())
+	}
+
+	return client, nil
+}
+
+// GetKafkaClientUpToEndOffsets returns a client for consuming partitions up to
+// their end offsets. Unlike the one returned by GetKafkaClient, it returns
+// transaction markers (which need to be skipped by its users) along with
+// records, so that its users can tell when partitions whose end offsets are
+// preceded by one have been consumed fully.
+func GetKafkaClientUpToEndOffsets(
+	auth t.AuthType,
+	brokers []string,
+	topic string,
+	consumeBehaviours t.ConsumeBehaviours,
+	awsCfg *aws.Config,
+) (*kgo.Client, error) {
+	builder, err := getConsumerBuilder(auth, brokers, topic, consumeBehaviours, awsCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := builder.WithControlRecords().Build()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errCouldntCreateKafkaClient, err.Error())
+	}
+
+	return client, nil
+}
+
+func getConsumerBuilder(
+	auth t.AuthType,
+	brokers []string,
+	topic string,
+	consumeBehaviours t.ConsumeBehaviours,
+	awsCfg *aws.Config,
+) (Builder, error) {
 	builder := NewBuilder(brokers)
 
 	if auth == t.AWSMSKIAM {
 		builder = builder.WithMskIAMAuth(*awsCfg)
 	}
 
-	if consumeBehaviours.StartTimeStamp != nil {
+	if len(consumeBehaviours.ResumeOffsets) > 0 {
+		offsets, err := getResumeOffsets(builder, topic, consumeBehaviours)
+		if err != nil {
+			return builder, err
+		}
+		builder = builder.WithPartitionStartOffsets(topic, offsets)
+	} else if consumeBehaviours.StartTimeStamp != nil {
 		builder = builder.WithStartTimestamp(topic, *consumeBehaviours.StartTimeStamp)
 	} else if consumeBehaviours.StartOffset != nil {
 		builder = builder.WithStartOffset(topic, *consumeBehaviours.StartOffset)
@@ -123,12 +195,7 @@ func GetKafkaClient(
 		builder = builder.WithTopic(topic)
 	}
 
-	client, err := builder.Build()
-	if err != nil {
-		return nil, fmt.Errorf("%w: %s", errCouldntCreateKafkaClient, err.Error())
-	}
-
-	return client, nil
+	return builder, nil
 }
 
 func GetKafkaClientForForwarding(
@@ -157,3 +224,82 @@ func GetKafkaClientForForwarding(
 func (b Builder) Build() (*kgo.Client, error) {
 	return kgo.NewClient(b.opts...)
 }
+
+// getResumeOffsets returns the offsets to consume each of a topic's partitions
+// from when resuming; partitions that weren't consumed from before are
+// consumed as per the remaining consume behaviours.
+func getResumeOffsets(builder Builder, topic string, consumeBehaviours t.ConsumeBehaviours) (map[int32]kgo.Offset, error) {
+	client, err := builder.Build()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errCouldntCreateKafkaClient, err.Error())
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), topicPartitionsRequestTimeout)
+	defer cancel()
+
+	partitions, err := GetTopicPartitions(ctx, client, topic)
+	if err != nil {
+		return nil, err
+	}
+
+	return getPartitionResumeOffsets(partitions, consumeBehaviours)
+}
+
+func getPartitionResumeOffsets(partitions []int32, consumeBehaviours t.ConsumeBehaviours) (map[int32]kgo.Offset, error) {
+	offsets := make(map[int32]kgo.Offset)
+	for _, partition := range partitions {
+		if offset, ok := consumeBehaviours.ResumeOffsets[partition]; ok {
+			offsets[partition] = kgo.NewOffset().At(offset)
+			continue
+		}
+
+		switch {
+		case len(consumeBehaviours.PartitionOffsets) > 0:
+			if offset, ok := consumeBehaviours.PartitionOffsets[partition]; ok {
+				offsets[partition] = kgo.NewOffset().At(offset)
+			}
+		case consumeBehaviours.StartTimeStamp != nil:
+			offsets[partition] = kgo.NewOffset().AfterMilli(consumeBehaviours.StartTimeStamp.UnixMilli())
+		case consumeBehaviours.StartOffset != nil:
+			offsets[partition] = kgo.NewOffset().At(*consumeBehaviours.StartOffset)
+		default:
+			offsets[partition] = kgo.NewOffset().AtStart()
+		}
+	}
+
+	if len(offsets) == 0 {
+		return nil, errNoPartitionsToResumeFrom
+	}
+
+	return offsets, nil
+}
+
+// GetTopicPartitions returns the IDs of a topic's partitions, in ascending
+// order.
+func GetTopicPartitions(ctx context.Context, cl *kgo.Client, topic string) ([]int32, error) {
+	req := kmsg.NewPtrMetadataRequest()
+	reqTopic := kmsg.NewMetadataRequestTopic()
+	reqTopic.Topic = kmsg.StringPtr(topic)
+	req.Topics = append(req.Topics, reqTopic)
+
+	resp, err := req.RequestWith(ctx, cl)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errCouldntGetTopicPartitions, err.Error())
+	}
+
+	var partitions []int32
+	for _, respTopic := range resp.Topics {
+		if err := kerr.ErrorForCode(respTopic.ErrorCode); err != nil {
+			return nil, fmt.Errorf("%w: %s", errCouldntGetTopicPartitions, err.Error())
+		}
+
+		for _, p := range respTopic.Partitions {
+			partitions = append(partitions, p.Partition)
+		}
+	}
+
+	slices.Sort(partitions)
+
+	return partitions, nil
+}
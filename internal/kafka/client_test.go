@@ -0,0 +1,92 @@
+package kafka
+
+import (
+	"testing"
+	"time"
+
+	t "github.com/dhth/kplay/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+func getResumeTestConsumeBehaviours(startOffset *int64, startTimestamp *time.Time, partitionOffsets map[int32]int64) t.ConsumeBehaviours {
+	return t.ConsumeBehaviours{
+		StartOffset:      startOffset,
+		StartTimeStamp:   startTimestamp,
+		PartitionOffsets: partitionOffsets,
+		ResumeOffsets:    map[int32]int64{0: 150, 1: 173},
+	}
+}
+
+func TestGetPartitionResumeOffsets(t *testing.T) {
+	startOffset := int64(100)
+	startTimestamp := time.Date(2025, 4, 6, 10, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name             string
+		startOffset      *int64
+		startTimestamp   *time.Time
+		partitionOffsets map[int32]int64
+		expected         map[int32]kgo.Offset
+	}{
+		{
+			name: "partitions not consumed before start from the beginning",
+			expected: map[int32]kgo.Offset{
+				0: kgo.NewOffset().At(150),
+				1: kgo.NewOffset().At(173),
+				2: kgo.NewOffset().AtStart(),
+			},
+		},
+		{
+			name:        "partitions not consumed before start from the start offset",
+			startOffset: &startOffset,
+			expected: map[int32]kgo.Offset{
+				0: kgo.NewOffset().At(150),
+				1: kgo.NewOffset().At(173),
+				2: kgo.NewOffset().At(100),
+			},
+		},
+		{
+			name:           "partitions not consumed before start from the start timestamp",
+			startTimestamp: &startTimestamp,
+			expected: map[int32]kgo.Offset{
+				0: kgo.NewOffset().At(150),
+				1: kgo.NewOffset().At(173),
+				2: kgo.NewOffset().AfterMilli(startTimestamp.UnixMilli()),
+			},
+		},
+		{
+			name:             "only partitions with offsets are consumed",
+			partitionOffsets: map[int32]int64{1: 20},
+			expected: map[int32]kgo.Offset{
+				0: kgo.NewOffset().At(150),
+				1: kgo.NewOffset().At(173),
+			},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			// WHEN
+			consumeBehaviours := getResumeTestConsumeBehaviours(tt.startOffset, tt.startTimestamp, tt.partitionOffsets)
+			got, err := getPartitionResumeOffsets([]int32{0, 1, 2}, consumeBehaviours)
+
+			// THEN
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestGetPartitionResumeOffsetsFailsWithoutPartitionsToConsume(t *testing.T) {
+	// GIVEN
+	consumeBehaviours := getResumeTestConsumeBehaviours(nil, nil, map[int32]int64{5: 20})
+
+	// WHEN
+	_, err := getPartitionResumeOffsets([]int32{2, 3}, consumeBehaviours)
+
+	// THEN
+	assert.ErrorIs(t, err, errNoPartitionsToResumeFrom)
+}
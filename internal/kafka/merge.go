@@ -0,0 +1,222 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// MergeMode determines how records from different partitions are merged into
+// a single stream ordered by timestamp.
+type MergeMode struct {
+	Lateness time.Duration
+}
+
+func (m MergeMode) String() string {
+	return fmt.Sprintf("by timestamp, with a lateness window of %s", m.Lateness)
+}
+
+// Merger buffers records per partition, and releases them in timestamp order
+// across partitions. A record is held back until a record that's at least the
+// lateness window newer than it has been fetched (from any partition), so that
+// records from partitions that lag behind can be slotted in before it.
+//
+// Records from a partition are always released in offset order. A record that
+// shows up after a newer record has already been released (ie, one that's later
+// than the lateness window allows for) is released as soon as it reaches the
+// head of its partition's buffer, and counted as late.
+type Merger struct {
+	mu           sync.Mutex
+	lateness     time.Duration
+	buffers      map[int32][]*kgo.Record
+	numBuffered  int
+	latest       time.Time
+	lastReleased time.Time
+	numLate      uint
+}
+
+func NewMerger(mode MergeMode) *Merger {
+	return &Merger{
+		lateness: mode.Lateness,
+		buffers:  make(map[int32][]*kgo.Record),
+	}
+}
+
+// Add buffers fetched records.
+func (m *Merger) Add(records []*kgo.Record) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, record := range records {
+		if record == nil {
+			continue
+		}
+
+		m.buffers[record.Partition] = append(m.buffers[record.Partition], record)
+		m.numBuffered++
+		if record.Timestamp.After(m.latest) {
+			m.latest = record.Timestamp
+		}
+	}
+}
+
+// Release returns up to limit records that are past the lateness window, in
+// timestamp order.
+func (m *Merger) Release(limit int) []*kgo.Record {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.release(limit, false, time.Time{})
+}
+
+// ReleaseAsOf is like Release, but treats now as the timestamp of the newest
+// record if no newer record has been fetched. It's meant to be used when fetches
+// come back empty: records fetched later are likely to be newer than now, so
+// buffered records that are at least the lateness window older than now can be
+// released without waiting for them.
+func (m *Merger) ReleaseAsOf(limit int, now time.Time) []*kgo.Record {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.release(limit, false, now)
+}
+
+// Flush returns up to limit buffered records in timestamp order, regardless of
+// the lateness window; it's meant to be used once no more records are being
+// fetched.
+func (m *Merger) Flush(limit int) []*kgo.Record {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.release(limit, true, time.Time{})
+}
+
+// NumReady returns the number of records that can be released, up to limit.
+func (m *Merger) NumReady(limit int) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	heads := make(map[int32]int, len(m.buffers))
+	var numReady int
+	for numReady < limit {
+		partition, ok := m.earliest(heads)
+		if !ok || !m.isReady(m.buffers[partition][heads[partition]], time.Time{}) {
+			break
+		}
+		heads[partition]++
+		numReady++
+	}
+
+	return numReady
+}
+
+func (m *Merger) NumBuffered() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.numBuffered
+}
+
+// NumLate returns the number of records that were released after a newer
+// record.
+func (m *Merger) NumLate() uint {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.numLate
+}
+
+// release returns up to limit records in timestamp order; records are released
+// regardless of the lateness window if flush is set, and treating now as the
+// newest timestamp seen if it's later than that.
+func (m *Merger) release(limit int, flush bool, now time.Time) []*kgo.Record {
+	var released []*kgo.Record
+	for len(released) < limit {
+		partition, ok := m.earliest(nil)
+		if !ok {
+			break
+		}
+
+		record := m.buffers[partition][0]
+		if !flush && !m.isReady(record, now) {
+			break
+		}
+
+		m.buffers[partition] = m.buffers[partition][1:]
+		if len(m.buffers[partition]) == 0 {
+			delete(m.buffers, partition)
+		}
+		m.numBuffered--
+
+		if record.Timestamp.Before(m.lastReleased) {
+			m.numLate++
+		} else {
+			m.lastReleased = record.Timestamp
+		}
+
+		released = append(released, record)
+	}
+
+	return released
+}
+
+func (m *Merger) isReady(record *kgo.Record, now time.Time) bool {
+	latest := m.latest
+	if now.After(latest) {
+		latest = now
+	}
+
+	return !record.Timestamp.After(latest.Add(-m.lateness))
+}
+
+// earliest returns the partition whose next record has the earliest timestamp;
+// ties are broken by partition. heads holds the index of the next record for
+// each partition (which is 0 for partitions missing from it).
+func (m *Merger) earliest(heads map[int32]int) (int32, bool) {
+	var earliest *kgo.Record
+	var earliestPartition int32
+	for partition, buffer := range m.buffers {
+		head := heads[partition]
+		if head >= len(buffer) {
+			continue
+		}
+
+		record := buffer[head]
+		if earliest == nil ||
+			record.Timestamp.Before(earliest.Timestamp) ||
+			(record.Timestamp.Equal(earliest.Timestamp) && partition < earliestPartition) {
+			earliest = record
+			earliestPartition = partition
+		}
+	}
+
+	return earliestPartition, earliest != nil
+}
+
+// FetchMergedRecords fetches records until numRecords of them can be released
+// by the merger. If no records are fetched before the context is done, only
+// the buffered records that are at least the lateness window older than the
+// current time are released, so that records that are still within the
+// lateness window can be slotted in by subsequent calls.
+//
+// A merger can only be used by one call at a time, since records buffered by
+// one call could otherwise be released by another.
+func FetchMergedRecords(ctx context.Context, cl *kgo.Client, merger *Merger, numRecords uint) ([]*kgo.Record, error) {
+	for merger.NumReady(int(numRecords)) < int(numRecords) {
+		records, err := FetchRecords(ctx, cl, numRecords)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(records) == 0 {
+			return merger.ReleaseAsOf(int(numRecords), time.Now()), nil
+		}
+
+		merger.Add(records)
+	}
+
+	return merger.Release(int(numRecords)), nil
+}
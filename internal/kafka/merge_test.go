@@ -0,0 +1,183 @@
+package kafka
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+var mergeTestStart = time.Date(2025, 4, 6, 10, 0, 0, 0, time.UTC)
+
+func getTestRecord(partition int32, offset int64, secondsSinceStart int) *kgo.Record {
+	return &kgo.Record{
+		Partition: partition,
+		Offset:    offset,
+		Timestamp: mergeTestStart.Add(time.Duration(secondsSinceStart) * time.Second),
+	}
+}
+
+func getPositions(records []*kgo.Record) [][2]int64 {
+	positions := make([][2]int64, len(records))
+	for i, record := range records {
+		positions[i] = [2]int64{int64(record.Partition), record.Offset}
+	}
+
+	return positions
+}
+
+func TestMergerReleasesRecordsInTimestampOrder(t *testing.T) {
+	// GIVEN
+	merger := NewMerger(MergeMode{Lateness: 5 * time.Second})
+	merger.Add([]*kgo.Record{
+		getTestRecord(0, 0, 0),
+		getTestRecord(0, 1, 4),
+		getTestRecord(0, 2, 12),
+		getTestRecord(1, 0, 2),
+		getTestRecord(1, 1, 3),
+		getTestRecord(1, 2, 10),
+	})
+
+	// WHEN
+	released := merger.Release(100)
+
+	// THEN
+	// records newer than 12s - 5s = 7s are held back
+	assert.Equal(t, [][2]int64{{0, 0}, {1, 0}, {1, 1}, {0, 1}}, getPositions(released))
+	assert.Equal(t, 2, merger.NumBuffered())
+	assert.Equal(t, uint(0), merger.NumLate())
+}
+
+func TestMergerSlotsInRecordsWithinTheLatenessWindow(t *testing.T) {
+	// GIVEN
+	merger := NewMerger(MergeMode{Lateness: 5 * time.Second})
+	merger.Add([]*kgo.Record{getTestRecord(0, 0, 0), getTestRecord(0, 1, 6)})
+	first := merger.Release(100)
+
+	// WHEN
+	merger.Add([]*kgo.Record{getTestRecord(1, 0, 3)})
+	second := merger.Flush(100)
+
+	// THEN
+	assert.Equal(t, [][2]int64{{0, 0}}, getPositions(first))
+	assert.Equal(t, [][2]int64{{1, 0}, {0, 1}}, getPositions(second))
+	assert.Equal(t, uint(0), merger.NumLate())
+}
+
+func TestMergerCountsRecordsBeyondTheLatenessWindowAsLate(t *testing.T) {
+	// GIVEN
+	merger := NewMerger(MergeMode{Lateness: time.Second})
+	merger.Add([]*kgo.Record{getTestRecord(0, 0, 0), getTestRecord(0, 1, 10)})
+	first := merger.Release(100)
+
+	// WHEN
+	merger.Add([]*kgo.Record{getTestRecord(1, 0, 5)})
+	second := merger.Flush(100)
+
+	// THEN
+	assert.Equal(t, [][2]int64{{0, 0}}, getPositions(first))
+	assert.Equal(t, [][2]int64{{1, 0}, {0, 1}}, getPositions(second))
+	assert.Equal(t, uint(0), merger.NumLate())
+
+	// WHEN
+	merger.Add([]*kgo.Record{getTestRecord(2, 0, 7), getTestRecord(2, 1, 20)})
+	third := merger.Release(100)
+
+	// THEN
+	assert.Equal(t, [][2]int64{{2, 0}}, getPositions(third))
+	assert.Equal(t, uint(1), merger.NumLate())
+}
+
+func TestMergerKeepsPartitionsInOffsetOrder(t *testing.T) {
+	// GIVEN
+	merger := NewMerger(MergeMode{})
+	merger.Add([]*kgo.Record{
+		getTestRecord(0, 0, 5),
+		getTestRecord(0, 1, 1),
+		getTestRecord(1, 0, 3),
+	})
+
+	// WHEN
+	released := merger.Flush(100)
+
+	// THEN
+	assert.Equal(t, [][2]int64{{1, 0}, {0, 0}, {0, 1}}, getPositions(released))
+	assert.Equal(t, uint(1), merger.NumLate())
+}
+
+func TestMergerLimitsReleasedRecords(t *testing.T) {
+	// GIVEN
+	merger := NewMerger(MergeMode{})
+	merger.Add([]*kgo.Record{
+		getTestRecord(0, 0, 0),
+		getTestRecord(1, 0, 1),
+		getTestRecord(0, 1, 2),
+	})
+
+	// WHEN
+	numReady := merger.NumReady(2)
+	released := merger.Release(2)
+
+	// THEN
+	assert.Equal(t, 2, numReady)
+	assert.Equal(t, [][2]int64{{0, 0}, {1, 0}}, getPositions(released))
+	assert.Equal(t, 1, merger.NumBuffered())
+}
+
+func TestMergerReleasesRecordsAsOfTheCurrentTimeOnlyPastTheLatenessWindow(t *testing.T) {
+	// GIVEN
+	merger := NewMerger(MergeMode{Lateness: 5 * time.Second})
+	merger.Add([]*kgo.Record{
+		getTestRecord(0, 0, 0),
+		getTestRecord(1, 0, 3),
+		getTestRecord(0, 1, 8),
+	})
+
+	// WHEN
+	// no newer records have been fetched, and it's now 10s past the start
+	released := merger.ReleaseAsOf(100, mergeTestStart.Add(10*time.Second))
+
+	// THEN
+	// records newer than 10s - 5s = 5s are still held back
+	assert.Equal(t, [][2]int64{{0, 0}, {1, 0}}, getPositions(released))
+	assert.Equal(t, 1, merger.NumBuffered())
+}
+
+func TestMergerReleasesRecordsAsOfTheNewestRecordIfItsLaterThanTheCurrentTime(t *testing.T) {
+	// GIVEN
+	merger := NewMerger(MergeMode{Lateness: 5 * time.Second})
+	merger.Add([]*kgo.Record{getTestRecord(0, 0, 0), getTestRecord(1, 0, 20)})
+
+	// WHEN
+	released := merger.ReleaseAsOf(100, mergeTestStart)
+
+	// THEN
+	assert.Equal(t, [][2]int64{{0, 0}}, getPositions(released))
+	assert.Equal(t, 1, merger.NumBuffered())
+}
+
+func TestMergerOrdersRecordsFetchedInBatchesByTimestamp(t *testing.T) {
+	// GIVEN
+	// partition 1 lags behind partition 0 by up to 4s, which is within the
+	// lateness window
+	merger := NewMerger(MergeMode{Lateness: 5 * time.Second})
+	batches := [][]*kgo.Record{
+		{getTestRecord(0, 0, 0), getTestRecord(0, 1, 2), getTestRecord(0, 2, 6)},
+		{getTestRecord(1, 0, 1), getTestRecord(1, 1, 3), getTestRecord(0, 3, 9)},
+		{getTestRecord(1, 2, 5), getTestRecord(1, 3, 8), getTestRecord(0, 4, 12)},
+	}
+
+	// WHEN
+	var released []*kgo.Record
+	for _, batch := range batches {
+		merger.Add(batch)
+		released = append(released, merger.Release(merger.NumBuffered())...)
+	}
+	released = append(released, merger.Flush(merger.NumBuffered())...)
+
+	// THEN
+	expected := [][2]int64{{0, 0}, {1, 0}, {0, 1}, {1, 1}, {1, 2}, {0, 2}, {1, 3}, {0, 3}, {0, 4}}
+	assert.Equal(t, expected, getPositions(released))
+	assert.Equal(t, uint(0), merger.NumLate())
+}
@@ -8,30 +8,40 @@ import (
 	"google.golang.org/protobuf/reflect/protodesc"
 	"google.golang.org/protobuf/reflect/protoreflect"
 	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
 )
 
 var (
 	errCouldntUnmarshallDescriptorSet  = errors.New("couldn't unmarshal descriptor set file contents")
 	errCouldntCreateProtoRegistryFiles = errors.New("couldn't create proto registry files from descriptor set")
 	errCouldntFindDescriptor           = errors.New("couldn't find descriptor")
+	errDescriptorIsNotAMessage         = errors.New("descriptor is not a message")
 )
 
-func GetDescriptorFromDescriptorSet(descSetBytes []byte, descriptorName protoreflect.FullName) (protoreflect.MessageDescriptor, error) {
+// GetDescriptorFromDescriptorSet looks up a message descriptor in a
+// FileDescriptorSet. It also returns a type resolver for every message in the
+// set, which is needed to decode google.protobuf.Any fields.
+func GetDescriptorFromDescriptorSet(descSetBytes []byte, descriptorName protoreflect.FullName) (protoreflect.MessageDescriptor, *dynamicpb.Types, error) {
 	var fds descriptorpb.FileDescriptorSet
 	err := proto.Unmarshal(descSetBytes, &fds)
 	if err != nil {
-		return nil, fmt.Errorf("%w: %s", errCouldntUnmarshallDescriptorSet, err.Error())
+		return nil, nil, fmt.Errorf("%w: %s", errCouldntUnmarshallDescriptorSet, err.Error())
 	}
 
 	files, err := protodesc.NewFiles(&fds)
 	if err != nil {
-		return nil, fmt.Errorf("%w: %s", errCouldntCreateProtoRegistryFiles, err.Error())
+		return nil, nil, fmt.Errorf("%w: %s", errCouldntCreateProtoRegistryFiles, err.Error())
 	}
 
 	descriptor, err := files.FindDescriptorByName(descriptorName)
 	if err != nil {
-		return nil, fmt.Errorf("%w %q: %s", errCouldntFindDescriptor, descriptorName, err.Error())
+		return nil, nil, fmt.Errorf("%w %q: %s", errCouldntFindDescriptor, descriptorName, err.Error())
 	}
 
-	return descriptor.(protoreflect.MessageDescriptor), nil
+	msgDescriptor, ok := descriptor.(protoreflect.MessageDescriptor)
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: %q", errDescriptorIsNotAMessage, descriptorName)
+	}
+
+	return msgDescriptor, dynamicpb.NewTypes(files), nil
 }
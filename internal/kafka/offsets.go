@@ -0,0 +1,83 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/kmsg"
+)
+
+const (
+	listOffsetsEarliest = -2
+	listOffsetsLatest   = -1
+)
+
+var errCouldntListOffsets = errors.New("couldn't list partition offsets")
+
+// OffsetRange holds the offsets of the first record in a partition, and of the
+// record that's to be produced to it next.
+type OffsetRange struct {
+	Start int64
+	End   int64
+}
+
+// GetPartitionOffsetRanges returns the range of offsets currently held by each
+// of a topic's partitions.
+func GetPartitionOffsetRanges(ctx context.Context, cl *kgo.Client, topic string) (map[int32]OffsetRange, error) {
+	partitions, err := GetTopicPartitions(ctx, cl, topic)
+	if err != nil {
+		return nil, err
+	}
+
+	starts, err := listOffsets(ctx, cl, topic, partitions, listOffsetsEarliest)
+	if err != nil {
+		return nil, err
+	}
+
+	ends, err := listOffsets(ctx, cl, topic, partitions, listOffsetsLatest)
+	if err != nil {
+		return nil, err
+	}
+
+	ranges := make(map[int32]OffsetRange, len(partitions))
+	for _, partition := range partitions {
+		ranges[partition] = OffsetRange{Start: starts[partition], End: ends[partition]}
+	}
+
+	return ranges, nil
+}
+
+func listOffsets(ctx context.Context, cl *kgo.Client, topic string, partitions []int32, timestamp int64) (map[int32]int64, error) {
+	req := kmsg.NewPtrListOffsetsRequest()
+	req.ReplicaID = -1
+	reqTopic := kmsg.NewListOffsetsRequestTopic()
+	reqTopic.Topic = topic
+	for _, partition := range partitions {
+		reqPartition := kmsg.NewListOffsetsRequestTopicPartition()
+		reqPartition.Partition = partition
+		reqPartition.Timestamp = timestamp
+		reqTopic.Partitions = append(reqTopic.Partitions, reqPartition)
+	}
+	req.Topics = append(req.Topics, reqTopic)
+
+	resp, err := req.RequestWith(ctx, cl)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errCouldntListOffsets, err.Error())
+	}
+
+	offsets := make(map[int32]int64, len(partitions))
+	for _, respTopic := range resp.Topics {
+		for _, p := range respTopic.Partitions {
+			if err := kerr.ErrorForCode(p.ErrorCode); err != nil {
+				return nil, fmt.Errorf("%w: partition %d: %s", errCouldntListOffsets, p.Partition, err.Error())
+			}
+
+			offsets[p.Partition] = p.Offset
+		}
+	}
+
+	return offsets, nil
+}
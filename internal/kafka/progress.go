@@ -0,0 +1,136 @@
+package kafka
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+var errPartitionsNotConsumedFully = errors.New("some partitions weren't consumed up to their end offset")
+
+// PartitionProgress tracks how far each of a topic's partitions has been
+// consumed, relative to the offset it's to be consumed up to.
+type PartitionProgress struct {
+	endOffsets map[int32]int64
+	// the offset each partition's fetch position has moved past
+	reached  map[int32]int64
+	done     map[int32]bool
+	finished []int32
+}
+
+// NewPartitionProgress returns a tracker for consuming each partition up to
+// the end of its offset range (or up to, and including, maxOffset, if it's
+// provided).
+func NewPartitionProgress(offsetRanges map[int32]OffsetRange, maxOffset *int64) *PartitionProgress {
+	p := &PartitionProgress{
+		endOffsets: make(map[int32]int64, len(offsetRanges)),
+		reached:    make(map[int32]int64, len(offsetRanges)),
+		done:       make(map[int32]bool, len(offsetRanges)),
+	}
+
+	for partition, offsetRange := range offsetRanges {
+		endOffset := offsetRange.End
+		if maxOffset != nil {
+			endOffset = min(endOffset, *maxOffset+1)
+		}
+		p.endOffsets[partition] = endOffset
+
+		if endOffset <= offsetRange.Start {
+			p.done[partition] = true
+		}
+	}
+
+	return p
+}
+
+// Done reports whether every partition has been consumed fully.
+func (p *PartitionProgress) Done() bool {
+	return len(p.done) >= len(p.endOffsets)
+}
+
+// Accepts reports whether a record is yet to be consumed; records past the end
+// offset of their partition mark the partition as finished.
+func (p *PartitionProgress) Accepts(record *kgo.Record) bool {
+	if p.done[record.Partition] {
+		return false
+	}
+
+	if record.Offset >= p.endOffsets[record.Partition] {
+		p.Finish(record.Partition)
+		return false
+	}
+
+	return true
+}
+
+// Consumed records that a record has been consumed, and marks its partition as
+// finished if it's the last record to be consumed from it.
+func (p *PartitionProgress) Consumed(record *kgo.Record) {
+	p.Advance(record.Partition, record.Offset+1)
+}
+
+// Advance records that a partition's fetch position (ie, the offset to be
+// consumed next) has moved to position, and marks the partition as finished if
+// that's at or past its end offset. This is needed for offsets that don't hold
+// messages, such as the transaction markers (control records) that commit or
+// abort transactions; a partition whose end offset is preceded by one of these
+// would otherwise never be finished.
+func (p *PartitionProgress) Advance(partition int32, position int64) {
+	if p.done[partition] {
+		return
+	}
+
+	p.reached[partition] = position - 1
+
+	if position >= p.endOffsets[partition] {
+		p.Finish(partition)
+	}
+}
+
+// Finish marks a partition as finished, regardless of the offset it reached.
+func (p *PartitionProgress) Finish(partition int32) {
+	if p.done[partition] {
+		return
+	}
+
+	p.done[partition] = true
+	p.finished = append(p.finished, partition)
+}
+
+// TakeFinished returns the partitions that were finished since the last call.
+func (p *PartitionProgress) TakeFinished() []int32 {
+	finished := p.finished
+	p.finished = nil
+
+	return finished
+}
+
+// Err returns an error naming the partitions that haven't been consumed up to
+// their end offset, along with the offset each of them reached.
+func (p *PartitionProgress) Err() error {
+	if p.Done() {
+		return nil
+	}
+
+	var unfinished []int32
+	for partition := range p.endOffsets {
+		if !p.done[partition] {
+			unfinished = append(unfinished, partition)
+		}
+	}
+	slices.Sort(unfinished)
+
+	details := make([]string, len(unfinished))
+	for i, partition := range unfinished {
+		reached := "no messages consumed"
+		if offset, ok := p.reached[partition]; ok {
+			reached = fmt.Sprintf("reached offset %d", offset)
+		}
+		details[i] = fmt.Sprintf("partition %d (%s; end offset: %d)", partition, reached, p.endOffsets[partition])
+	}
+
+	return fmt.Errorf("%w: %s", errPartitionsNotConsumedFully, strings.Join(details, ", "))
+}
@@ -0,0 +1,131 @@
+package kafka
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func getTestOffsetRanges() map[int32]OffsetRange {
+	return map[int32]OffsetRange{
+		0: {Start: 0, End: 2},
+		1: {Start: 5, End: 8},
+		2: {Start: 3, End: 3},
+	}
+}
+
+func TestPartitionProgressIsDoneOnceEachPartitionReachesItsEndOffset(t *testing.T) {
+	// GIVEN
+	progress := NewPartitionProgress(getTestOffsetRanges(), nil)
+	records := []int64{0, 1}
+
+	// WHEN
+	for _, offset := range records {
+		record := getTestRecord(0, offset, 0)
+		require.True(t, progress.Accepts(record))
+		progress.Consumed(record)
+	}
+	assert.False(t, progress.Done())
+	for offset := int64(5); offset < 8; offset++ {
+		record := getTestRecord(1, offset, 0)
+		require.True(t, progress.Accepts(record))
+		progress.Consumed(record)
+	}
+
+	// THEN
+	assert.True(t, progress.Done())
+	assert.NoError(t, progress.Err())
+	assert.ElementsMatch(t, []int32{0, 1}, progress.TakeFinished())
+	assert.Empty(t, progress.TakeFinished())
+}
+
+func TestPartitionProgressReportsPartitionsShortOfTheirEndOffset(t *testing.T) {
+	// GIVEN
+	progress := NewPartitionProgress(getTestOffsetRanges(), nil)
+
+	// WHEN
+	// partition 0 is consumed fully, while partition 1 stalls before its end
+	// offset
+	for _, record := range []struct {
+		partition int32
+		offset    int64
+	}{{0, 0}, {0, 1}, {1, 5}, {1, 6}} {
+		r := getTestRecord(record.partition, record.offset, 0)
+		require.True(t, progress.Accepts(r))
+		progress.Consumed(r)
+	}
+
+	// THEN
+	assert.False(t, progress.Done())
+	err := progress.Err()
+	require.ErrorIs(t, err, errPartitionsNotConsumedFully)
+	assert.Contains(t, err.Error(), "partition 1 (reached offset 6; end offset: 8)")
+	assert.NotContains(t, err.Error(), "partition 0")
+}
+
+func TestPartitionProgressReportsPartitionsWithNoMessagesConsumed(t *testing.T) {
+	// GIVEN
+	progress := NewPartitionProgress(getTestOffsetRanges(), nil)
+
+	// WHEN
+	err := progress.Err()
+
+	// THEN
+	require.ErrorIs(t, err, errPartitionsNotConsumedFully)
+	assert.Contains(t, err.Error(), "partition 0 (no messages consumed; end offset: 2), partition 1 (no messages consumed; end offset: 8)")
+}
+
+func TestPartitionProgressRespectsMaxOffset(t *testing.T) {
+	// GIVEN
+	maxOffset := int64(5)
+	progress := NewPartitionProgress(getTestOffsetRanges(), &maxOffset)
+
+	// WHEN
+	record := getTestRecord(1, 5, 0)
+	require.True(t, progress.Accepts(record))
+	progress.Consumed(record)
+	progress.Finish(0)
+
+	// THEN
+	assert.True(t, progress.Done())
+	assert.False(t, progress.Accepts(getTestRecord(1, 6, 0)))
+}
+
+func TestPartitionProgressIsDoneOnceAPartitionsPositionMovesPastAGapAtItsEnd(t *testing.T) {
+	// GIVEN
+	progress := NewPartitionProgress(getTestOffsetRanges(), nil)
+	for _, offset := range []int64{0, 1} {
+		progress.Consumed(getTestRecord(0, offset, 0))
+	}
+
+	// WHEN
+	// partition 1's last message is at offset 5; offset 6 holds aborted
+	// records, which aren't returned, and offset 7 holds the transaction
+	// marker that aborts them
+	record := getTestRecord(1, 5, 0)
+	require.True(t, progress.Accepts(record))
+	progress.Consumed(record)
+	require.False(t, progress.Done())
+	progress.Advance(1, 8)
+
+	// THEN
+	assert.True(t, progress.Done())
+	assert.NoError(t, progress.Err())
+	assert.ElementsMatch(t, []int32{0, 1}, progress.TakeFinished())
+}
+
+func TestPartitionProgressReportsThePositionPartitionsReached(t *testing.T) {
+	// GIVEN
+	progress := NewPartitionProgress(getTestOffsetRanges(), nil)
+
+	// WHEN
+	progress.Consumed(getTestRecord(0, 0, 0))
+	progress.Consumed(getTestRecord(0, 1, 0))
+	progress.Advance(1, 7)
+
+	// THEN
+	err := progress.Err()
+	require.ErrorIs(t, err, errPartitionsNotConsumedFully)
+	assert.Contains(t, err.Error(), "partition 1 (reached offset 6; end offset: 8)")
+}
@@ -3,7 +3,11 @@ package scan
 import (
 	"fmt"
 	"regexp"
+	"strings"
 
+	"github.com/dhth/kplay/internal/filter"
+	"github.com/dhth/kplay/internal/fs"
+	k "github.com/dhth/kplay/internal/kafka"
 	t "github.com/dhth/kplay/internal/types"
 )
 
@@ -14,9 +18,56 @@ const (
 type Behaviours struct {
 	NumMessages    uint
 	KeyFilterRegex *regexp.Regexp
+	HeaderFilters  []HeaderFilter
+	Filter         *filter.Filter
+	Sample         *Sample
+	HeaderColumns  []string
+	Format         OutputFormat
+	SingleFile     bool
+	SQLitePath     string
+	Stats          bool
+	Duplicates     *DuplicateDetection
+	Merge          *k.MergeMode
+	InferSchema    bool
+	Resume         *Checkpoint
 	SaveMessages   bool
+	Save           fs.SaveLayout
 	Decode         bool
 	BatchSize      uint
+	Workers        uint
+}
+
+// HeaderFilter matches messages that have a header with a given key. If
+// ValueRegex is set, the header's decoded value needs to match it as well.
+type HeaderFilter struct {
+	Key        string
+	ValueRegex *regexp.Regexp
+}
+
+func (f HeaderFilter) matches(headers []t.Header) bool {
+	for _, h := range headers {
+		if h.Key != f.Key {
+			continue
+		}
+
+		if f.ValueRegex == nil || f.ValueRegex.MatchString(h.Value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (f HeaderFilter) String() string {
+	if f.ValueRegex == nil {
+		return fmt.Sprintf("%s (present)", f.Key)
+	}
+
+	return fmt.Sprintf("%s=%s", f.Key, f.ValueRegex.String())
+}
+
+func (b Behaviours) isFiltering() bool {
+	return b.KeyFilterRegex != nil || len(b.HeaderFilters) > 0 || b.Filter != nil
 }
 
 func (b Behaviours) Display() string {
@@ -25,17 +76,116 @@ func (b Behaviours) Display() string {
 		keyFilterRegex = b.KeyFilterRegex.String()
 	}
 
+	headerFilters := t.NotProvided
+	if len(b.HeaderFilters) > 0 {
+		filters := make([]string, len(b.HeaderFilters))
+		for i, f := range b.HeaderFilters {
+			filters[i] = f.String()
+		}
+		headerFilters = strings.Join(filters, "\n                          ")
+	}
+
+	filterExpr := t.NotProvided
+	if b.Filter != nil {
+		filterExpr = b.Filter.String()
+	}
+
+	sample := t.NotProvided
+	if b.Sample != nil {
+		sample = b.Sample.String()
+	}
+
+	sqlitePath := t.NotProvided
+	if b.SQLitePath != "" {
+		sqlitePath = b.SQLitePath
+	}
+
+	duplicates := t.NotProvided
+	if b.Duplicates != nil {
+		duplicates = b.Duplicates.String()
+	}
+
+	merge := t.NotProvided
+	if b.Merge != nil {
+		merge = b.Merge.String()
+	}
+
+	resume := t.NotProvided
+	if b.Resume != nil {
+		resume = b.Resume.ResultsFilePath
+	}
+
+	headerColumns := t.NotProvided
+	if len(b.HeaderColumns) > 0 {
+		headerColumns = strings.Join(b.HeaderColumns, ", ")
+	}
+
 	value := fmt.Sprintf(`Scan Behaviours:
   number of messages      %d
   key filter regex        %s
+  header filters          %s
+  filter                  %s
+  sample                  %s
+  header columns          %s
+  output format           %s
+  single file             %v
+  sqlite database         %s
+  compute stats           %v
+  detect duplicates       %s
+  merge partitions        %s
+  resume scan results     %s
   save messages           %v
+  save path template      %s
+  save format             %s
+  save raw bytes          %v
   decode values           %v
-  batch size              %d`,
+  batch size              %d
+  workers                 %d`,
 		b.NumMessages,
 		keyFilterRegex,
+		headerFilters,
+		filterExpr,
+		sample,
+		headerColumns,
+		b.Format.String(),
+		b.SingleFile,
+		sqlitePath,
+		b.Stats,
+		duplicates,
+		merge,
+		resume,
 		b.SaveMessages,
+		b.Save.PathTemplate(),
+		b.Save.Format.String(),
+		b.Save.Raw,
 		b.Decode,
 		b.BatchSize,
+		b.Workers,
+	)
+
+	return value
+}
+
+func (b Behaviours) InferSchemaDisplay() string {
+	keyFilterRegex := t.NotProvided
+	if b.KeyFilterRegex != nil {
+		keyFilterRegex = b.KeyFilterRegex.String()
+	}
+
+	filterExpr := t.NotProvided
+	if b.Filter != nil {
+		filterExpr = b.Filter.String()
+	}
+
+	value := fmt.Sprintf(`Schema Inference Behaviours:
+  number of messages      %d
+  key filter regex        %s
+  filter                  %s
+  batch size              %d`,
+		b.NumMessages,
+		keyFilterRegex,
+		filterExpr,
+		b.BatchSize,
 	)
 
 	return value
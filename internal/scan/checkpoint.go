@@ -0,0 +1,237 @@
+package scan
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	t "github.com/dhth/kplay/internal/types"
+)
+
+const checkpointInterval = 5 * time.Second
+
+var (
+	errCouldntReadCheckpoint   = errors.New("couldn't read checkpoint file")
+	errCheckpointIsInvalid     = errors.New("checkpoint file is invalid")
+	errCouldntWriteCheckpoint  = errors.New("couldn't write checkpoint file")
+	errCouldntTruncateResults  = errors.New("couldn't discard results written after the checkpoint")
+	errCheckpointColumnsDiffer = errors.New("scan results columns don't match the checkpoint's")
+)
+
+// Checkpoint records the state of a scan, so that it can be resumed from where
+// it stopped.
+type Checkpoint struct {
+	Topic string `json:"topic"`
+	// the consume behaviours the scan started with; these determine where
+	// partitions that haven't been consumed from yet are consumed from
+	StartOffset      *int64          `json:"start_offset,omitempty"`
+	StartTimestamp   *time.Time      `json:"start_timestamp,omitempty"`
+	PartitionOffsets map[int32]int64 `json:"partition_offsets,omitempty"`
+	// the offset to consume each partition from next
+	NextOffsets     map[int32]int64    `json:"next_offsets"`
+	Progress        CheckpointProgress `json:"progress"`
+	ResultsFilePath string             `json:"results_file"`
+	// size of the results file as of the checkpoint; anything written to it
+	// after that is discarded when resuming, since the messages it's for are
+	// consumed again
+	ResultsFileSize *int64 `json:"results_file_size,omitempty"`
+	Format          string `json:"format"`
+	// the header of CSV/TSV results, which rows appended when resuming need to
+	// match
+	Columns    []string `json:"columns,omitempty"`
+	SQLitePath string   `json:"sqlite_path,omitempty"`
+	// the number of messages an "every:N" sample has seen, so that the sample
+	// carries on with the same spacing
+	SampleNumSeen uint      `json:"sample_num_seen,omitempty"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+type CheckpointProgress struct {
+	NumRecordsConsumed     uint   `json:"num_records_consumed"`
+	NumRecordsMatched      uint   `json:"num_records_matched"`
+	NumRecordsSampled      uint   `json:"num_records_sampled,omitempty"`
+	NumHeaderMatches       uint   `json:"num_header_matches"`
+	NumHeaderFilterMatches []uint `json:"num_header_filter_matches,omitempty"`
+	NumBytesConsumed       uint64 `json:"num_bytes_consumed"`
+	NumDecodeErrors        uint   `json:"num_decode_errors"`
+	NumKeyDecodeErrors     uint   `json:"num_key_decode_errors"`
+	NumViolations          uint   `json:"num_violations"`
+	NumSQLiteRowsInserted  uint   `json:"num_sqlite_rows_inserted"`
+	NumDuplicates          uint   `json:"num_duplicates,omitempty"`
+	NumOutOfOrder          uint   `json:"num_out_of_order,omitempty"`
+}
+
+func ReadCheckpoint(path string) (Checkpoint, error) {
+	var checkpoint Checkpoint
+
+	checkpointBytes, err := os.ReadFile(path)
+	if err != nil {
+		return checkpoint, fmt.Errorf("%w: %s", errCouldntReadCheckpoint, err.Error())
+	}
+
+	err = json.Unmarshal(checkpointBytes, &checkpoint)
+	if err != nil {
+		return checkpoint, fmt.Errorf("%w: %s", errCheckpointIsInvalid, err.Error())
+	}
+
+	if checkpoint.Topic == "" || checkpoint.ResultsFilePath == "" {
+		return checkpoint, fmt.Errorf("%w: topic and results file are required", errCheckpointIsInvalid)
+	}
+
+	if _, err := ValidateOutputFormatValue(checkpoint.Format); err != nil {
+		return checkpoint, fmt.Errorf("%w: %s", errCheckpointIsInvalid, err.Error())
+	}
+
+	return checkpoint, nil
+}
+
+// ConsumeBehaviours returns the consume behaviours to resume the scan with.
+func (c Checkpoint) ConsumeBehaviours() t.ConsumeBehaviours {
+	return t.ConsumeBehaviours{
+		StartOffset:      c.StartOffset,
+		StartTimeStamp:   c.StartTimestamp,
+		PartitionOffsets: c.PartitionOffsets,
+		ResumeOffsets:    c.NextOffsets,
+	}
+}
+
+// checkColumns returns an error if the columns of CSV/TSV results differ from
+// the ones the checkpoint's results were written with (eg. because of a
+// different --header-columns value), since appending rows with a different
+// layout would leave the results file inconsistent.
+func (c Checkpoint) checkColumns(format OutputFormat, columns resultColumns) error {
+	if format == JSONLFormat || c.Columns == nil {
+		return nil
+	}
+
+	names := columns.names()
+	if slices.Equal(c.Columns, names) {
+		return nil
+	}
+
+	return fmt.Errorf("%w: checkpoint's results have the columns [%s], while the provided flags result in [%s]",
+		errCheckpointColumnsDiffer,
+		strings.Join(c.Columns, ", "),
+		strings.Join(names, ", "),
+	)
+}
+
+// truncateToCheckpoint discards anything written to a file after the
+// checkpoint it was saved with; files whose size wasn't saved are left as is.
+func truncateToCheckpoint(filePath string, size *int64) error {
+	if size == nil {
+		return nil
+	}
+
+	if err := os.Truncate(filePath, *size); err != nil {
+		return fmt.Errorf("%w: %s", errCouldntTruncateResults, err.Error())
+	}
+
+	return nil
+}
+
+func getFileSize(filePath string) (int64, error) {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return 0, err
+	}
+
+	return info.Size(), nil
+}
+
+func getCheckpointFilePath(scanOutputFilePath string) string {
+	return fmt.Sprintf("%s-checkpoint.json", strings.TrimSuffix(scanOutputFilePath, filepath.Ext(scanOutputFilePath)))
+}
+
+func (p scanProgress) toCheckpoint() CheckpointProgress {
+	return CheckpointProgress{
+		NumRecordsConsumed:     p.numRecordsConsumed,
+		NumRecordsMatched:      p.numRecordsMatched,
+		NumRecordsSampled:      p.numRecordsSampled,
+		NumHeaderMatches:       p.numHeaderMatches,
+		NumHeaderFilterMatches: p.numHeaderFilterMatches,
+		NumBytesConsumed:       p.numBytesConsumed,
+		NumDecodeErrors:        p.numDecodeErrors,
+		NumKeyDecodeErrors:     p.numKeyDecodeErrors,
+		NumViolations:          p.numViolations,
+		NumSQLiteRowsInserted:  p.numSQLiteRowsInserted,
+		NumDuplicates:          p.numDuplicates,
+		NumOutOfOrder:          p.numOutOfOrder,
+	}
+}
+
+// restore sets the counters saved in a checkpoint; header filter match counts
+// are only restored if the same number of header filters is in use.
+func (p *scanProgress) restore(checkpoint CheckpointProgress) {
+	p.numRecordsConsumed = checkpoint.NumRecordsConsumed
+	p.numRecordsMatched = checkpoint.NumRecordsMatched
+	p.numRecordsSampled = checkpoint.NumRecordsSampled
+	p.numHeaderMatches = checkpoint.NumHeaderMatches
+	p.numBytesConsumed = checkpoint.NumBytesConsumed
+	p.numDecodeErrors = checkpoint.NumDecodeErrors
+	p.numKeyDecodeErrors = checkpoint.NumKeyDecodeErrors
+	p.numViolations = checkpoint.NumViolations
+	p.numSQLiteRowsInserted = checkpoint.NumSQLiteRowsInserted
+	p.numDuplicates = checkpoint.NumDuplicates
+	p.numOutOfOrder = checkpoint.NumOutOfOrder
+
+	if len(checkpoint.NumHeaderFilterMatches) == len(p.numHeaderFilterMatches) {
+		copy(p.numHeaderFilterMatches, checkpoint.NumHeaderFilterMatches)
+	}
+}
+
+// writeCheckpoint saves the scan's current state (which includes the sizes of
+// the results files, and thus expects them to be flushed); the checkpoint is
+// written to a temporary file first, so that an interrupted write doesn't
+// corrupt an existing checkpoint.
+func (s *Scanner) writeCheckpoint(checkpointFilePath, scanOutputFilePath string) error {
+	resultsFileSize, err := getFileSize(scanOutputFilePath)
+	if err != nil {
+		return fmt.Errorf("%w: %s", errCouldntWriteCheckpoint, err.Error())
+	}
+
+	checkpoint := Checkpoint{
+		Topic:            s.config.Topic,
+		StartOffset:      s.consumeBehaviours.StartOffset,
+		StartTimestamp:   s.consumeBehaviours.StartTimeStamp,
+		PartitionOffsets: s.consumeBehaviours.PartitionOffsets,
+		NextOffsets:      s.nextOffsets,
+		Progress:         s.progress.toCheckpoint(),
+		ResultsFilePath:  scanOutputFilePath,
+		ResultsFileSize:  &resultsFileSize,
+		Format:           s.behaviours.Format.String(),
+		SQLitePath:       s.behaviours.SQLitePath,
+		UpdatedAt:        time.Now(),
+	}
+
+	if s.behaviours.Format != JSONLFormat {
+		checkpoint.Columns = s.columns.names()
+	}
+
+	if s.sampler != nil && s.sampler.sample.Mode == SampleEveryNth {
+		checkpoint.SampleNumSeen = s.sampler.numSeen
+	}
+
+	checkpointBytes, err := json.MarshalIndent(checkpoint, "", "  ")
+	if err != nil {
+		return fmt.Errorf("%w: %s", errCouldntWriteCheckpoint, err.Error())
+	}
+
+	tempFilePath := checkpointFilePath + ".tmp"
+	err = os.WriteFile(tempFilePath, append(checkpointBytes, '\n'), 0o644)
+	if err != nil {
+		return fmt.Errorf("%w: %s", errCouldntWriteCheckpoint, err.Error())
+	}
+
+	err = os.Rename(tempFilePath, checkpointFilePath)
+	if err != nil {
+		return fmt.Errorf("%w: %s", errCouldntWriteCheckpoint, err.Error())
+	}
+
+	return nil
+}
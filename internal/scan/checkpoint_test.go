@@ -0,0 +1,258 @@
+package scan
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	t "github.com/dhth/kplay/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckpointCanBeReadBack(t *testing.T) {
+	// GIVEN
+	tempDir := t.TempDir()
+	checkpointFilePath := filepath.Join(tempDir, "scan-1-checkpoint.json")
+	scanOutputFilePath := filepath.Join(tempDir, "scan-1.tsv")
+	startTimestamp := time.Date(2025, 4, 6, 11, 18, 3, 0, time.UTC)
+
+	scanner := New(
+		nil,
+		getCheckpointTestConfig(),
+		Behaviours{Format: TSVFormat, SQLitePath: "orders.db", HeaderFilters: []HeaderFilter{{Key: "tenant"}}},
+		getCheckpointTestConsumeBehaviours(startTimestamp),
+		tempDir,
+	)
+	scanner.nextOffsets = map[int32]int64{0: 101, 2: 57}
+	scanner.progress.numRecordsConsumed = 156
+	scanner.progress.numRecordsMatched = 20
+	scanner.progress.numHeaderFilterMatches[0] = 20
+	scanner.progress.numBytesConsumed = 2048
+	scanner.progress.numDecodeErrors = 3
+	scanner.columns = resultColumns{decodeErrors: true, headers: []string{"tenant"}}
+	require.NoError(t, os.WriteFile(scanOutputFilePath, []byte("partition\toffset\n"), 0o644))
+
+	// WHEN
+	err := scanner.writeCheckpoint(checkpointFilePath, scanOutputFilePath)
+	require.NoError(t, err)
+	got, err := ReadCheckpoint(checkpointFilePath)
+
+	// THEN
+	require.NoError(t, err)
+	assert.Equal(t, "orders", got.Topic)
+	assert.Equal(t, scanOutputFilePath, got.ResultsFilePath)
+	assert.Equal(t, "tsv", got.Format)
+	assert.Equal(t, "orders.db", got.SQLitePath)
+	require.NotNil(t, got.ResultsFileSize)
+	assert.Equal(t, int64(17), *got.ResultsFileSize)
+	assert.Equal(t, []string{"partition", "offset", "timestamp", "key", "tombstone", "decode_error", "header:tenant"}, got.Columns)
+	consumeBehaviours := got.ConsumeBehaviours()
+	require.NotNil(t, consumeBehaviours.StartTimeStamp)
+	assert.True(t, startTimestamp.Equal(*consumeBehaviours.StartTimeStamp))
+	assert.Nil(t, consumeBehaviours.StartOffset)
+	assert.Empty(t, consumeBehaviours.PartitionOffsets)
+	assert.Equal(t, map[int32]int64{0: 101, 2: 57}, consumeBehaviours.ResumeOffsets)
+
+	resumed := New(nil, getCheckpointTestConfig(), Behaviours{HeaderFilters: []HeaderFilter{{Key: "tenant"}}, Resume: &got}, got.ConsumeBehaviours(), tempDir)
+	assert.Equal(t, scanner.progress.toCheckpoint(), resumed.progress.toCheckpoint())
+	assert.Equal(t, map[int32]int64{0: 101, 2: 57}, resumed.nextOffsets)
+	assert.NoFileExists(t, checkpointFilePath+".tmp")
+}
+
+func TestReadCheckpointFailsForInvalidFiles(t *testing.T) {
+	testCases := []struct {
+		name     string
+		contents string
+	}{
+		{name: "invalid json", contents: `{"topic": "orders"`},
+		{name: "missing topic", contents: `{"results_file": "scan-1.csv", "format": "csv"}`},
+		{name: "missing results file", contents: `{"topic": "orders", "format": "csv"}`},
+		{name: "incorrect format", contents: `{"topic": "orders", "results_file": "scan-1.csv", "format": "xml"}`},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			checkpointFilePath := filepath.Join(t.TempDir(), "checkpoint.json")
+			require.NoError(t, os.WriteFile(checkpointFilePath, []byte(tt.contents), 0o644))
+
+			_, err := ReadCheckpoint(checkpointFilePath)
+
+			assert.ErrorIs(t, err, errCheckpointIsInvalid)
+		})
+	}
+}
+
+func TestMessageWriterAppendsWithoutHeader(t *testing.T) {
+	// GIVEN
+	filePath := filepath.Join(t.TempDir(), "results.csv")
+	messages := getTestMessages()
+
+	rw, err := newMessageWriter(filePath, CSVFormat, resultColumns{}, false)
+	require.NoError(t, err)
+	require.NoError(t, rw.writeMsg(messages[0]))
+	require.NoError(t, rw.close())
+
+	// WHEN
+	rw, err = newMessageWriter(filePath, CSVFormat, resultColumns{}, true)
+	require.NoError(t, err)
+	require.NoError(t, rw.writeMsg(messages[1]))
+	require.NoError(t, rw.close())
+
+	// THEN
+	contents, err := os.ReadFile(filePath)
+	require.NoError(t, err)
+	expected := `partition,offset,timestamp,key,tombstone
+0,10,2025-04-06T11:18:03Z,order-1,false
+1,20,2025-04-06T11:18:03Z,order-2,true
+`
+	assert.Equal(t, expected, string(contents))
+}
+
+func TestResumingDiscardsResultsWrittenAfterTheCheckpoint(t *testing.T) {
+	// GIVEN
+	tempDir := t.TempDir()
+	scanOutputFilePath := filepath.Join(tempDir, "scan-1.csv")
+	checkpointFilePath := getCheckpointFilePath(scanOutputFilePath)
+	messages := getTestMessages()
+
+	scanner := New(nil, getCheckpointTestConfig(), Behaviours{Format: CSVFormat}, getCheckpointTestConsumeBehaviours(time.Now()), tempDir)
+	rw, err := newMessageWriter(scanOutputFilePath, CSVFormat, resultColumns{}, false)
+	require.NoError(t, err)
+	require.NoError(t, rw.writeMsg(messages[0]))
+	require.NoError(t, rw.flush())
+	require.NoError(t, scanner.writeCheckpoint(checkpointFilePath, scanOutputFilePath))
+
+	// the second message makes it to the results file, but the scan stops
+	// before the next checkpoint
+	require.NoError(t, rw.writeMsg(messages[1]))
+	require.NoError(t, rw.close())
+
+	// WHEN
+	checkpoint, err := ReadCheckpoint(checkpointFilePath)
+	require.NoError(t, err)
+	require.NoError(t, truncateToCheckpoint(scanOutputFilePath, checkpoint.ResultsFileSize))
+	rw, err = newMessageWriter(scanOutputFilePath, CSVFormat, resultColumns{}, true)
+	require.NoError(t, err)
+	require.NoError(t, rw.writeMsg(messages[1]))
+	require.NoError(t, rw.close())
+
+	// THEN
+	contents, err := os.ReadFile(scanOutputFilePath)
+	require.NoError(t, err)
+	expected := `partition,offset,timestamp,key,tombstone
+0,10,2025-04-06T11:18:03Z,order-1,false
+1,20,2025-04-06T11:18:03Z,order-2,true
+`
+	assert.Equal(t, expected, string(contents))
+}
+
+func TestCheckpointColumnsNeedToMatchWhenResuming(t *testing.T) {
+	checkpoint := Checkpoint{Columns: []string{"partition", "offset", "timestamp", "key", "tombstone", "decode_error", "value"}}
+
+	testCases := []struct {
+		name       string
+		checkpoint Checkpoint
+		format     OutputFormat
+		columns    resultColumns
+		expectErr  bool
+	}{
+		{
+			name:       "same columns",
+			checkpoint: checkpoint,
+			format:     CSVFormat,
+			columns:    resultColumns{decodeErrors: true, values: true},
+		},
+		{
+			name:       "value column missing",
+			checkpoint: checkpoint,
+			format:     CSVFormat,
+			columns:    resultColumns{decodeErrors: true},
+			expectErr:  true,
+		},
+		{
+			name:       "extra header column",
+			checkpoint: checkpoint,
+			format:     TSVFormat,
+			columns:    resultColumns{decodeErrors: true, values: true, headers: []string{"tenant"}},
+			expectErr:  true,
+		},
+		{
+			name:       "jsonl results",
+			checkpoint: Checkpoint{},
+			format:     JSONLFormat,
+			columns:    resultColumns{headers: []string{"tenant"}},
+		},
+		{
+			name:       "checkpoint without columns",
+			checkpoint: Checkpoint{},
+			format:     CSVFormat,
+			columns:    resultColumns{values: true},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.checkpoint.checkColumns(tt.format, tt.columns)
+
+			if tt.expectErr {
+				assert.ErrorIs(t, err, errCheckpointColumnsDiffer)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestResumedScanCarriesOnWithTheSameSampleSpacing(t *testing.T) {
+	// GIVEN
+	tempDir := t.TempDir()
+	scanOutputFilePath := filepath.Join(tempDir, "scan-1.csv")
+	checkpointFilePath := getCheckpointFilePath(scanOutputFilePath)
+	require.NoError(t, os.WriteFile(scanOutputFilePath, nil, 0o644))
+	behaviours := Behaviours{Format: CSVFormat, Sample: &Sample{Mode: SampleEveryNth, Size: 3}}
+	messages := getTestMessages()
+
+	scanner := New(nil, getCheckpointTestConfig(), behaviours, getCheckpointTestConsumeBehaviours(time.Now()), tempDir)
+	var sampled []bool
+	for range 4 {
+		sampled = append(sampled, scanner.sampler.add(messages[0]))
+	}
+	require.NoError(t, scanner.writeCheckpoint(checkpointFilePath, scanOutputFilePath))
+
+	// WHEN
+	checkpoint, err := ReadCheckpoint(checkpointFilePath)
+	require.NoError(t, err)
+	behaviours.Resume = &checkpoint
+	resumed := New(nil, getCheckpointTestConfig(), behaviours, checkpoint.ConsumeBehaviours(), tempDir)
+	for range 3 {
+		sampled = append(sampled, resumed.sampler.add(messages[0]))
+	}
+
+	// THEN
+	assert.Equal(t, []bool{true, false, false, true, false, false, true}, sampled)
+}
+
+func TestTruncateToCheckpointLeavesFilesWithoutASavedSizeAsIs(t *testing.T) {
+	// GIVEN
+	filePath := filepath.Join(t.TempDir(), "results.csv")
+	require.NoError(t, os.WriteFile(filePath, []byte("partition,offset\n"), 0o644))
+
+	// WHEN
+	err := truncateToCheckpoint(filePath, nil)
+
+	// THEN
+	require.NoError(t, err)
+	contents, err := os.ReadFile(filePath)
+	require.NoError(t, err)
+	assert.Equal(t, "partition,offset\n", string(contents))
+}
+
+func getCheckpointTestConfig() t.Config {
+	return t.Config{Topic: "orders", Encoding: t.JSON}
+}
+
+func getCheckpointTestConsumeBehaviours(startTimestamp time.Time) t.ConsumeBehaviours {
+	return t.ConsumeBehaviours{StartTimeStamp: &startTimestamp}
+}
@@ -0,0 +1,345 @@
+package scan
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	s "github.com/dhth/kplay/internal/serde"
+	t "github.com/dhth/kplay/internal/types"
+)
+
+const (
+	DuplicateWindowDefault = time.Hour
+	// numeric event times below this are treated as seconds since the epoch,
+	// and as milliseconds otherwise
+	eventTimeMillisThreshold = 1e11
+)
+
+var (
+	errCouldntCreateDuplicatesReport = errors.New("couldn't create duplicates report")
+	errCouldntWriteDuplicatesReport  = errors.New("couldn't write to duplicates report")
+)
+
+const (
+	findingDuplicate  = "duplicate"
+	findingOutOfOrder = "out_of_order"
+)
+
+// DuplicateDetection configures the detection of duplicate messages, and of
+// messages whose event time goes backwards for their key. Messages are
+// identified by a hash of their key and value, unless IDPath is set, in which
+// case they're identified by the value of that field. Event times are read
+// from the field at EventTimePath if it's set, and record timestamps are used
+// otherwise.
+type DuplicateDetection struct {
+	IDPath        []string
+	EventTimePath []string
+	Window        time.Duration
+}
+
+func (d DuplicateDetection) String() string {
+	identifiedBy := "key and value hash"
+	if len(d.IDPath) > 0 {
+		identifiedBy = fmt.Sprintf("field %q", strings.Join(d.IDPath, "."))
+	}
+
+	eventTime := "record timestamps"
+	if len(d.EventTimePath) > 0 {
+		eventTime = fmt.Sprintf("field %q", strings.Join(d.EventTimePath, "."))
+	}
+
+	return fmt.Sprintf("by %s, within %s; event time from %s", identifiedBy, d.Window, eventTime)
+}
+
+func (d DuplicateDetection) needsDecoding() bool {
+	return len(d.IDPath) > 0 || len(d.EventTimePath) > 0
+}
+
+type messageRef struct {
+	Partition int32     `json:"partition"`
+	Offset    int64     `json:"offset"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// duplicateFinding is a line in the duplicates report. For duplicates,
+// Previous refers to the first message seen with the same ID; for messages
+// that are out of order, it refers to the message with the latest event time
+// seen for the same key.
+type duplicateFinding struct {
+	Kind string `json:"kind"`
+	Key  string `json:"key"`
+	messageRef
+	ID                string     `json:"id,omitempty"`
+	EventTime         *time.Time `json:"event_time,omitempty"`
+	Previous          messageRef `json:"previous"`
+	PreviousEventTime *time.Time `json:"previous_event_time,omitempty"`
+}
+
+type seenID struct {
+	id  string
+	ref messageRef
+}
+
+type keyEventTime struct {
+	ref       messageRef
+	eventTime time.Time
+}
+
+// duplicateDetector keeps track of the IDs seen within a window of record
+// timestamps, and of the latest event time seen for each key.
+type duplicateDetector struct {
+	detection           DuplicateDetection
+	seen                map[string]messageRef
+	seenQueue           []seenID
+	seenQueueStart      int
+	latestTimestamp     time.Time
+	latestEventTimes    map[string]keyEventTime
+	numWithoutID        uint
+	numWithoutEventTime uint
+}
+
+func newDuplicateDetector(detection DuplicateDetection) *duplicateDetector {
+	return &duplicateDetector{
+		detection:        detection,
+		seen:             make(map[string]messageRef),
+		latestEventTimes: make(map[string]keyEventTime),
+	}
+}
+
+func (d *duplicateDetector) check(msg t.Message) []duplicateFinding {
+	ref := messageRef{Partition: msg.Partition, Offset: msg.Offset, Timestamp: msg.Metadata.Timestamp}
+
+	var value any
+	if d.detection.needsDecoding() {
+		value = parseJSONValue(msg)
+	}
+
+	var findings []duplicateFinding
+
+	if finding, ok := d.checkDuplicate(msg, ref, value); ok {
+		findings = append(findings, finding)
+	}
+
+	if finding, ok := d.checkOrder(msg, ref, value); ok {
+		findings = append(findings, finding)
+	}
+
+	return findings
+}
+
+func (d *duplicateDetector) checkDuplicate(msg t.Message, ref messageRef, value any) (duplicateFinding, bool) {
+	// tombstones for a key aren't duplicates of each other
+	if len(msg.Value) == 0 {
+		return duplicateFinding{}, false
+	}
+
+	var id string
+	var idForReport string
+	if len(d.detection.IDPath) > 0 {
+		fieldValue, ok := s.LookupJSONField(value, d.detection.IDPath)
+		if !ok || fieldValue == nil {
+			d.numWithoutID++
+			return duplicateFinding{}, false
+		}
+		id = s.JSONValueString(fieldValue)
+		idForReport = id
+	} else {
+		id = keyValueHash(msg)
+	}
+
+	if ref.Timestamp.After(d.latestTimestamp) {
+		d.latestTimestamp = ref.Timestamp
+	}
+	d.evict()
+
+	first, ok := d.seen[id]
+	if ok && absDuration(ref.Timestamp.Sub(first.Timestamp)) <= d.detection.Window {
+		return duplicateFinding{
+			Kind:       findingDuplicate,
+			Key:        msg.Key,
+			messageRef: ref,
+			ID:         idForReport,
+			Previous:   first,
+		}, true
+	}
+
+	d.seen[id] = ref
+	d.seenQueue = append(d.seenQueue, seenID{id: id, ref: ref})
+
+	return duplicateFinding{}, false
+}
+
+// evict forgets IDs seen before the window; since messages from different
+// partitions are interleaved, IDs are evicted in the order they were seen in.
+func (d *duplicateDetector) evict() {
+	cutoff := d.latestTimestamp.Add(-d.detection.Window)
+	for d.seenQueueStart < len(d.seenQueue) {
+		oldest := d.seenQueue[d.seenQueueStart]
+		if !oldest.ref.Timestamp.Before(cutoff) {
+			break
+		}
+
+		if d.seen[oldest.id] == oldest.ref {
+			delete(d.seen, oldest.id)
+		}
+		d.seenQueue[d.seenQueueStart] = seenID{}
+		d.seenQueueStart++
+	}
+
+	if d.seenQueueStart > len(d.seenQueue)/2 {
+		d.seenQueue = append(d.seenQueue[:0], d.seenQueue[d.seenQueueStart:]...)
+		d.seenQueueStart = 0
+	}
+}
+
+func (d *duplicateDetector) checkOrder(msg t.Message, ref messageRef, value any) (duplicateFinding, bool) {
+	if msg.RawKey == nil {
+		return duplicateFinding{}, false
+	}
+
+	eventTime := ref.Timestamp
+	if len(d.detection.EventTimePath) > 0 {
+		fieldValue, ok := s.LookupJSONField(value, d.detection.EventTimePath)
+		if !ok {
+			d.numWithoutEventTime++
+			return duplicateFinding{}, false
+		}
+
+		eventTime, ok = parseEventTime(fieldValue)
+		if !ok {
+			d.numWithoutEventTime++
+			return duplicateFinding{}, false
+		}
+	}
+
+	latest, ok := d.latestEventTimes[msg.Key]
+	if ok && eventTime.Before(latest.eventTime) {
+		finding := duplicateFinding{
+			Kind:       findingOutOfOrder,
+			Key:        msg.Key,
+			messageRef: ref,
+			Previous:   latest.ref,
+		}
+		if len(d.detection.EventTimePath) > 0 {
+			finding.EventTime = &eventTime
+			finding.PreviousEventTime = &latest.eventTime
+		}
+
+		return finding, true
+	}
+
+	d.latestEventTimes[msg.Key] = keyEventTime{ref: ref, eventTime: eventTime}
+
+	return duplicateFinding{}, false
+}
+
+// keyValueHash returns a hash of a message's raw key and value.
+func keyValueHash(msg t.Message) string {
+	value := msg.RawValue
+	if value == nil {
+		value = msg.Value
+	}
+
+	h := fnv.New128a()
+	_ = binary.Write(h, binary.BigEndian, uint64(len(msg.RawKey)))
+	h.Write(msg.RawKey)
+	h.Write(value)
+
+	return string(h.Sum(nil))
+}
+
+func parseJSONValue(msg t.Message) any {
+	if msg.DecodeErr != nil || len(msg.Value) == 0 {
+		return nil
+	}
+
+	value, err := s.DecodeJSON(msg.Value)
+	if err != nil {
+		return nil
+	}
+
+	return value
+}
+
+// parseEventTime parses RFC3339 timestamps, and numbers of seconds or
+// milliseconds since the epoch.
+func parseEventTime(value any) (time.Time, bool) {
+	switch v := value.(type) {
+	case string:
+		parsed, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			return time.Time{}, false
+		}
+		return parsed, true
+	case json.Number:
+		number, err := v.Float64()
+		if err != nil {
+			return time.Time{}, false
+		}
+		if math.Abs(number) < eventTimeMillisThreshold {
+			return time.UnixMilli(int64(number * 1000)).UTC(), true
+		}
+		return time.UnixMilli(int64(number)).UTC(), true
+	default:
+		return time.Time{}, false
+	}
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+
+	return d
+}
+
+func getDuplicatesReportFilePath(scanOutputFilePath string) string {
+	return fmt.Sprintf("%s-duplicates.jsonl", strings.TrimSuffix(scanOutputFilePath, filepath.Ext(scanOutputFilePath)))
+}
+
+type findingsWriter struct {
+	file    *os.File
+	writer  *bufio.Writer
+	encoder *json.Encoder
+}
+
+func newFindingsWriter(filePath string) (*findingsWriter, error) {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errCouldntCreateDuplicatesReport, err.Error())
+	}
+
+	writer := bufio.NewWriter(file)
+	encoder := json.NewEncoder(writer)
+	encoder.SetEscapeHTML(false)
+
+	return &findingsWriter{file: file, writer: writer, encoder: encoder}, nil
+}
+
+func (w *findingsWriter) write(finding duplicateFinding) error {
+	if err := w.encoder.Encode(finding); err != nil {
+		return fmt.Errorf("%w: %s", errCouldntWriteDuplicatesReport, err.Error())
+	}
+
+	return nil
+}
+
+func (w *findingsWriter) flush() error {
+	return w.writer.Flush()
+}
+
+func (w *findingsWriter) close() error {
+	flushErr := w.writer.Flush()
+	closeErr := w.file.Close()
+
+	return errors.Join(flushErr, closeErr)
+}
@@ -0,0 +1,266 @@
+package scan
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	t "github.com/dhth/kplay/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+var duplicatesTestStart = time.Date(2025, 4, 6, 11, 0, 0, 0, time.UTC)
+
+func getTestDuplicateMessage(key, value string, offset int64, minutes int) t.Message {
+	var keyBytes []byte
+	if key != "" {
+		keyBytes = []byte(key)
+	}
+
+	var valueBytes []byte
+	if value != "" {
+		valueBytes = []byte(value)
+	}
+
+	return t.GetMessageFromRecord(kgo.Record{
+		Key:       keyBytes,
+		Value:     valueBytes,
+		Offset:    offset,
+		Timestamp: duplicatesTestStart.Add(time.Duration(minutes) * time.Minute),
+	}, t.Config{Encoding: t.JSON}, true)
+}
+
+func getTestDuplicateMessages(messages ...t.Message) []t.Message {
+	return messages
+}
+
+func checkTestMessages(detector *duplicateDetector, messages []t.Message) []duplicateFinding {
+	var findings []duplicateFinding
+	for _, msg := range messages {
+		findings = append(findings, detector.check(msg)...)
+	}
+
+	return findings
+}
+
+func TestDuplicateDetectorFindsDuplicatesByKeyAndValue(t *testing.T) {
+	// GIVEN
+	detector := newDuplicateDetector(DuplicateDetection{Window: 10 * time.Minute})
+	messages := getTestDuplicateMessages(
+		getTestDuplicateMessage("order-1", `{"status": "PAID"}`, 1, 0),
+		getTestDuplicateMessage("order-1", `{"status": "PAID"}`, 2, 5),
+		getTestDuplicateMessage("order-2", `{"status": "PAID"}`, 3, 6),
+		getTestDuplicateMessage("order-1", `{"status": "SHIPPED"}`, 4, 7),
+		// outside the window of the first message
+		getTestDuplicateMessage("order-1", `{"status": "PAID"}`, 5, 30),
+		// tombstones aren't duplicates
+		getTestDuplicateMessage("order-1", "", 6, 31),
+		getTestDuplicateMessage("order-1", "", 7, 32),
+	)
+
+	// WHEN
+	findings := checkTestMessages(detector, messages)
+
+	// THEN
+	var duplicates []duplicateFinding
+	for _, f := range findings {
+		if f.Kind == findingDuplicate {
+			duplicates = append(duplicates, f)
+		}
+	}
+	require.Len(t, duplicates, 1)
+	assert.Equal(t, int64(2), duplicates[0].Offset)
+	assert.Equal(t, int64(1), duplicates[0].Previous.Offset)
+	assert.Empty(t, duplicates[0].ID)
+}
+
+func TestDuplicateDetectorFindsDuplicatesByIDField(t *testing.T) {
+	// GIVEN
+	detector := newDuplicateDetector(DuplicateDetection{IDPath: []string{"event", "id"}, Window: time.Hour})
+	messages := getTestDuplicateMessages(
+		getTestDuplicateMessage("order-1", `{"event": {"id": "e-1"}, "attempt": 1}`, 1, 0),
+		getTestDuplicateMessage("order-1", `{"event": {"id": "e-1"}, "attempt": 2}`, 2, 1),
+		getTestDuplicateMessage("order-2", `{"event": {"id": 42}}`, 3, 2),
+		getTestDuplicateMessage("order-3", `{"event": {"id": 42}}`, 4, 3),
+		getTestDuplicateMessage("order-4", `{"event": {}}`, 5, 4),
+	)
+
+	// WHEN
+	findings := checkTestMessages(detector, messages)
+
+	// THEN
+	require.Len(t, findings, 2)
+	assert.Equal(t, findingDuplicate, findings[0].Kind)
+	assert.Equal(t, "e-1", findings[0].ID)
+	assert.Equal(t, int64(2), findings[0].Offset)
+	assert.Equal(t, "42", findings[1].ID)
+	assert.Equal(t, int64(3), findings[1].Previous.Offset)
+	assert.Equal(t, uint(1), detector.numWithoutID)
+}
+
+func TestDuplicateDetectorFindsOutOfOrderRecordTimestamps(t *testing.T) {
+	// GIVEN
+	detector := newDuplicateDetector(DuplicateDetection{Window: time.Minute})
+	messages := getTestDuplicateMessages(
+		getTestDuplicateMessage("order-1", `{"v": 1}`, 1, 10),
+		getTestDuplicateMessage("order-2", `{"v": 2}`, 2, 5),
+		getTestDuplicateMessage("order-1", `{"v": 3}`, 3, 8),
+		getTestDuplicateMessage("order-1", `{"v": 4}`, 4, 9),
+		getTestDuplicateMessage("order-1", `{"v": 5}`, 5, 11),
+	)
+
+	// WHEN
+	findings := checkTestMessages(detector, messages)
+
+	// THEN
+	require.Len(t, findings, 2)
+	for i, offset := range []int64{3, 4} {
+		assert.Equal(t, findingOutOfOrder, findings[i].Kind)
+		assert.Equal(t, offset, findings[i].Offset)
+		assert.Equal(t, int64(1), findings[i].Previous.Offset)
+		assert.Nil(t, findings[i].EventTime)
+	}
+}
+
+func TestDuplicateDetectorFindsOutOfOrderEventTimes(t *testing.T) {
+	// GIVEN
+	detector := newDuplicateDetector(DuplicateDetection{EventTimePath: []string{"occurredAt"}, Window: time.Minute})
+	messages := getTestDuplicateMessages(
+		getTestDuplicateMessage("order-1", `{"occurredAt": "2025-04-06T11:00:00Z"}`, 1, 0),
+		getTestDuplicateMessage("order-1", `{"occurredAt": 1743937140000}`, 2, 1),
+		getTestDuplicateMessage("order-1", `{"occurredAt": 1743937260}`, 3, 2),
+		getTestDuplicateMessage("order-1", `{"occurredAt": "yesterday"}`, 4, 3),
+		getTestDuplicateMessage("order-1", `{}`, 5, 4),
+	)
+
+	// WHEN
+	findings := checkTestMessages(detector, messages)
+
+	// THEN
+	require.Len(t, findings, 1)
+	assert.Equal(t, findingOutOfOrder, findings[0].Kind)
+	assert.Equal(t, int64(2), findings[0].Offset)
+	require.NotNil(t, findings[0].EventTime)
+	assert.Equal(t, "2025-04-06T10:59:00Z", findings[0].EventTime.Format(time.RFC3339))
+	require.NotNil(t, findings[0].PreviousEventTime)
+	assert.Equal(t, "2025-04-06T11:00:00Z", findings[0].PreviousEventTime.Format(time.RFC3339))
+	assert.Equal(t, uint(2), detector.numWithoutEventTime)
+}
+
+type duplicateWindowTestCase struct {
+	name     string
+	messages []t.Message
+	// offsets of duplicates, along with the offsets of the messages they
+	// duplicate
+	expected [][2]int64
+}
+
+func getDuplicateWindowTestCases() []duplicateWindowTestCase {
+	return []duplicateWindowTestCase{
+		{
+			name: "duplicate at the edge of the window",
+			messages: getTestDuplicateMessages(
+				getTestDuplicateMessage("order-1", `{"status": "PAID"}`, 1, 0),
+				getTestDuplicateMessage("order-1", `{"status": "PAID"}`, 2, 10),
+			),
+			expected: [][2]int64{{2, 1}},
+		},
+		{
+			name: "duplicate past the window",
+			messages: getTestDuplicateMessages(
+				getTestDuplicateMessage("order-1", `{"status": "PAID"}`, 1, 0),
+				getTestDuplicateMessage("order-1", `{"status": "PAID"}`, 2, 11),
+			),
+		},
+		{
+			name: "duplicates past the window are compared with the latest copy",
+			messages: getTestDuplicateMessages(
+				getTestDuplicateMessage("order-1", `{"status": "PAID"}`, 1, 0),
+				getTestDuplicateMessage("order-1", `{"status": "PAID"}`, 2, 11),
+				getTestDuplicateMessage("order-1", `{"status": "PAID"}`, 3, 15),
+			),
+			expected: [][2]int64{{3, 2}},
+		},
+		{
+			name: "duplicate with an earlier timestamp within the window",
+			messages: getTestDuplicateMessages(
+				getTestDuplicateMessage("order-1", `{"status": "PAID"}`, 1, 20),
+				getTestDuplicateMessage("order-2", `{"status": "PAID"}`, 2, 25),
+				getTestDuplicateMessage("order-1", `{"status": "PAID"}`, 3, 12),
+			),
+			expected: [][2]int64{{3, 1}},
+		},
+	}
+}
+
+func TestDuplicateDetectorReportsDuplicatesWithinTheWindowOnly(t *testing.T) {
+	testCases := getDuplicateWindowTestCases()
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			detector := newDuplicateDetector(DuplicateDetection{Window: 10 * time.Minute})
+
+			// WHEN
+			findings := checkTestMessages(detector, tt.messages)
+
+			// THEN
+			var got [][2]int64
+			for _, finding := range findings {
+				if finding.Kind == findingDuplicate {
+					got = append(got, [2]int64{finding.Offset, finding.Previous.Offset})
+				}
+			}
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestDuplicateDetectorEvictsIDsOutsideTheWindow(t *testing.T) {
+	// GIVEN
+	detector := newDuplicateDetector(DuplicateDetection{Window: time.Minute})
+
+	// WHEN
+	for i := range 100 {
+		detector.check(getTestDuplicateMessage("key", strings.Repeat("x", i+1), int64(i), i))
+	}
+
+	// THEN
+	assert.LessOrEqual(t, len(detector.seen), 2)
+	assert.LessOrEqual(t, len(detector.seenQueue), 4)
+}
+
+func TestFindingsWriterWritesJSONLines(t *testing.T) {
+	// GIVEN
+	filePath := filepath.Join(t.TempDir(), "scan-duplicates.jsonl")
+	fw, err := newFindingsWriter(filePath)
+	require.NoError(t, err)
+	detector := newDuplicateDetector(DuplicateDetection{Window: time.Hour})
+	findings := checkTestMessages(detector, getTestDuplicateMessages(
+		getTestDuplicateMessage("order-1", `{"status": "PAID"}`, 1, 0),
+		getTestDuplicateMessage("order-1", `{"status": "PAID"}`, 2, 1),
+	))
+	require.Len(t, findings, 1)
+
+	// WHEN
+	require.NoError(t, fw.write(findings[0]))
+	require.NoError(t, fw.close())
+
+	// THEN
+	contents, err := os.ReadFile(filePath)
+	require.NoError(t, err)
+	var got map[string]any
+	require.NoError(t, json.Unmarshal(contents, &got))
+	assert.Equal(t, "duplicate", got["kind"])
+	assert.Equal(t, "order-1", got["key"])
+	assert.InDelta(t, 2, got["offset"], 0)
+	assert.Equal(t, "2025-04-06T11:01:00Z", got["timestamp"])
+	previous, ok := got["previous"].(map[string]any)
+	require.True(t, ok)
+	assert.InDelta(t, 1, previous["offset"], 0)
+}
@@ -0,0 +1,224 @@
+package scan
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	k "github.com/dhth/kplay/internal/kafka"
+	t "github.com/dhth/kplay/internal/types"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+const fetchTimeout = 5 * time.Second
+
+// Messages are scanned via a pipeline: batches of records are fetched in the
+// background, the records in each batch are handed to a long-lived pool of
+// decode workers, and decoded batches pass through a reorder buffer (keyed by
+// the batch's sequence number) before being handled, in the order in which
+// they were fetched. This keeps messages from each partition in offset order,
+// while fetching, decoding, and writing results overlap.
+
+type fetchedBatch struct {
+	records []*kgo.Record
+	err     error
+}
+
+type decodedBatch struct {
+	seq     uint64
+	records []*kgo.Record
+	// in the same order as records
+	decoded []decodedRecord
+	err     error
+	// the number of records yet to be decoded
+	pending atomic.Int64
+}
+
+type decodeJob struct {
+	batch *decodedBatch
+	index int
+}
+
+type decodedRecord struct {
+	record *kgo.Record
+	msg    t.Message
+	// whether the message matches the filter expression, if there's one;
+	// filters are evaluated by the decode workers, since CEL evaluation can be
+	// as expensive as decoding
+	matchesFilter bool
+}
+
+// fetchBatches fetches batches of records until numToFetch records have been
+// fetched, or the context is cancelled. The channel is closed when it's done.
+//
+// When merging partitions, fetched records are sent once the merger releases
+// them; records still buffered are flushed whenever a fetch comes back empty,
+// and once all records have been fetched.
+func (s *Scanner) fetchBatches(ctx context.Context, numToFetch uint, batches chan<- fetchedBatch) {
+	defer close(batches)
+
+	send := func(records []*kgo.Record) bool {
+		if len(records) == 0 {
+			return true
+		}
+
+		select {
+		case batches <- fetchedBatch{records: records}:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	if s.merger != nil {
+		defer func() {
+			send(s.merger.Flush(s.merger.NumBuffered()))
+		}()
+	}
+
+	var numFetched uint
+	for numFetched < numToFetch {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		toFetch := min(numToFetch-numFetched, s.behaviours.BatchSize)
+
+		fetchCtx, cancel := context.WithTimeout(ctx, fetchTimeout)
+		records, err := k.FetchRecords(fetchCtx, s.client, toFetch)
+		cancel()
+
+		if err != nil {
+			select {
+			case batches <- fetchedBatch{err: err}:
+			case <-ctx.Done():
+			}
+			return
+		}
+
+		if len(records) == 0 {
+			if s.merger != nil && !send(s.merger.Flush(s.merger.NumBuffered())) {
+				return
+			}
+			continue
+		}
+
+		numFetched += uint(len(records))
+
+		if s.merger != nil {
+			s.merger.Add(records)
+			records = s.merger.Release(s.merger.NumBuffered())
+		}
+
+		if !send(records) {
+			return
+		}
+	}
+}
+
+// decodeBatches decodes the records of fetched batches using the configured
+// number of workers, and sends the decoded batches in the order they were
+// fetched in. The returned channel is closed once all batches have been
+// decoded, or the context is cancelled; wg tracks the goroutines involved.
+func (s *Scanner) decodeBatches(ctx context.Context, wg *sync.WaitGroup, batches <-chan fetchedBatch, decode bool) <-chan *decodedBatch {
+	numWorkers := max(1, s.behaviours.Workers)
+	jobs := make(chan decodeJob, numWorkers)
+	done := make(chan *decodedBatch, numWorkers)
+	ordered := make(chan *decodedBatch, 1)
+
+	sendDone := func(batch *decodedBatch) {
+		select {
+		case done <- batch:
+		case <-ctx.Done():
+		}
+	}
+
+	wg.Go(func() {
+		defer close(jobs)
+
+		var seq uint64
+		for batch := range batches {
+			decoded := &decodedBatch{
+				seq:     seq,
+				records: batch.records,
+				decoded: make([]decodedRecord, len(batch.records)),
+				err:     batch.err,
+			}
+			seq++
+
+			if len(batch.records) == 0 {
+				sendDone(decoded)
+				continue
+			}
+
+			decoded.pending.Store(int64(len(batch.records)))
+			for i := range batch.records {
+				select {
+				case jobs <- decodeJob{batch: decoded, index: i}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	})
+
+	var workersWG sync.WaitGroup
+	for range numWorkers {
+		workersWG.Go(func() {
+			for job := range jobs {
+				job.batch.decoded[job.index] = s.decodeRecord(job.batch.records[job.index], decode)
+				if job.batch.pending.Add(-1) == 0 {
+					sendDone(job.batch)
+				}
+			}
+		})
+	}
+
+	wg.Go(func() {
+		workersWG.Wait()
+		close(done)
+	})
+
+	wg.Go(func() {
+		defer close(ordered)
+
+		var next uint64
+		waiting := make(map[uint64]*decodedBatch)
+		for batch := range done {
+			waiting[batch.seq] = batch
+			for {
+				ready, ok := waiting[next]
+				if !ok {
+					break
+				}
+
+				select {
+				case ordered <- ready:
+				case <-ctx.Done():
+					return
+				}
+				delete(waiting, next)
+				next++
+			}
+		}
+	})
+
+	return ordered
+}
+
+func (s *Scanner) decodeRecord(record *kgo.Record, decode bool) decodedRecord {
+	if record == nil {
+		return decodedRecord{}
+	}
+
+	msg := t.GetMessageFromRecord(*record, s.config, decode)
+
+	return decodedRecord{
+		record:        record,
+		msg:           msg,
+		matchesFilter: s.behaviours.Filter == nil || s.behaviours.Filter.Matches(msg),
+	}
+}
@@ -0,0 +1,184 @@
+package scan
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/dhth/kplay/internal/filter"
+	t "github.com/dhth/kplay/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+func getTestRecords(num int) []*kgo.Record {
+	records := make([]*kgo.Record, num)
+	for i := range num {
+		records[i] = &kgo.Record{
+			Key:       fmt.Appendf(nil, "key-%d", i),
+			Value:     fmt.Appendf(nil, `{"id": %d}`, i),
+			Partition: int32(i % 3),
+			Offset:    int64(i / 3),
+		}
+	}
+
+	return records
+}
+
+func getTestScanner(workers uint, msgFilter *filter.Filter) *Scanner {
+	return &Scanner{
+		config:     t.Config{Encoding: t.JSON},
+		behaviours: Behaviours{Workers: workers, Filter: msgFilter},
+	}
+}
+
+// decodeTestBatches runs batches of records through the decode stage of the
+// pipeline, and returns the decoded batches in the order they were sent in.
+func decodeTestBatches(scanner *Scanner, batches []fetchedBatch) []*decodedBatch {
+	fetched := make(chan fetchedBatch)
+	var wg sync.WaitGroup
+	decoded := scanner.decodeBatches(context.Background(), &wg, fetched, true)
+
+	go func() {
+		for _, batch := range batches {
+			fetched <- batch
+		}
+		close(fetched)
+	}()
+
+	var results []*decodedBatch
+	for batch := range decoded {
+		results = append(results, batch)
+	}
+	wg.Wait()
+
+	return results
+}
+
+func splitIntoBatches(records []*kgo.Record, batchSize int) []fetchedBatch {
+	var batches []fetchedBatch
+	for start := 0; start < len(records); start += batchSize {
+		batches = append(batches, fetchedBatch{records: records[start:min(start+batchSize, len(records))]})
+	}
+
+	return batches
+}
+
+func TestDecodeBatchesPreservesOrder(t *testing.T) {
+	records := getTestRecords(50)
+
+	testCases := []struct {
+		name      string
+		workers   uint
+		batchSize int
+	}{
+		{name: "a single worker", workers: 1, batchSize: 7},
+		{name: "several workers", workers: 4, batchSize: 7},
+		{name: "more workers than records in a batch", workers: 100, batchSize: 3},
+		{name: "batches of a single record", workers: 4, batchSize: 1},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			scanner := getTestScanner(tt.workers, nil)
+			batches := splitIntoBatches(records, tt.batchSize)
+
+			// WHEN
+			decoded := decodeTestBatches(scanner, batches)
+
+			// THEN
+			require.Len(t, decoded, len(batches))
+			i := 0
+			for seq, batch := range decoded {
+				assert.Equal(t, uint64(seq), batch.seq)
+				require.Len(t, batch.decoded, len(batches[seq].records))
+				for _, d := range batch.decoded {
+					assert.Same(t, records[i], d.record)
+					assert.Equal(t, fmt.Sprintf("key-%d", i), d.msg.Key)
+					assert.Equal(t, records[i].Offset, d.msg.Offset)
+					assert.NoError(t, d.msg.DecodeErr)
+					assert.True(t, d.matchesFilter)
+					i++
+				}
+			}
+			assert.Equal(t, len(records), i)
+		})
+	}
+}
+
+func TestDecodeBatchesEvaluatesFilter(t *testing.T) {
+	// GIVEN
+	msgFilter, err := filter.New("int(value.id) % 2 == 0")
+	require.NoError(t, err)
+	scanner := getTestScanner(4, msgFilter)
+	records := getTestRecords(10)
+
+	// WHEN
+	decoded := decodeTestBatches(scanner, splitIntoBatches(records, 10))
+
+	// THEN
+	require.Len(t, decoded, 1)
+	require.Len(t, decoded[0].decoded, len(records))
+	for i, d := range decoded[0].decoded {
+		assert.Equal(t, i%2 == 0, d.matchesFilter, "record %d", i)
+	}
+}
+
+func TestDecodeBatchesSkipsNilRecords(t *testing.T) {
+	// GIVEN
+	scanner := getTestScanner(2, nil)
+	records := []*kgo.Record{nil, {Key: []byte("key"), Value: []byte(`{}`)}}
+
+	// WHEN
+	decoded := decodeTestBatches(scanner, []fetchedBatch{{records: records}})
+
+	// THEN
+	require.Len(t, decoded, 1)
+	require.Len(t, decoded[0].decoded, 2)
+	assert.Nil(t, decoded[0].decoded[0].record)
+	assert.Equal(t, "key", decoded[0].decoded[1].msg.Key)
+}
+
+func TestDecodeBatchesPassesOnFetchErrorsInOrder(t *testing.T) {
+	// GIVEN
+	scanner := getTestScanner(4, nil)
+	fetchErr := errors.New("couldn't fetch")
+	batches := append(splitIntoBatches(getTestRecords(20), 5), fetchedBatch{err: fetchErr})
+
+	// WHEN
+	decoded := decodeTestBatches(scanner, batches)
+
+	// THEN
+	require.Len(t, decoded, 5)
+	for _, batch := range decoded[:4] {
+		assert.NoError(t, batch.err)
+		assert.Len(t, batch.decoded, 5)
+	}
+	assert.ErrorIs(t, decoded[4].err, fetchErr)
+}
+
+func TestDecodeBatchesStopsWhenTheContextIsCancelled(t *testing.T) {
+	// GIVEN
+	scanner := getTestScanner(2, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	fetched := make(chan fetchedBatch)
+	var wg sync.WaitGroup
+	decoded := scanner.decodeBatches(ctx, &wg, fetched, true)
+
+	fetched <- fetchedBatch{records: getTestRecords(5)}
+	<-decoded
+
+	// WHEN
+	// the fetcher stops once the context is cancelled
+	cancel()
+	close(fetched)
+
+	// THEN
+	wg.Wait()
+	_, ok := <-decoded
+	assert.False(t, ok)
+}
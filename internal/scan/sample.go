@@ -0,0 +1,309 @@
+package scan
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"time"
+
+	k "github.com/dhth/kplay/internal/kafka"
+	t "github.com/dhth/kplay/internal/types"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// the maximum amount of time spent waiting for records from a round of seeks
+// when sampling evenly spaced offsets
+const spacedSampleRoundTimeout = 30 * time.Second
+
+type SampleMode uint
+
+const (
+	SampleEveryNth SampleMode = iota
+	SampleProbability
+	SampleReservoir
+	SampleSpaced
+)
+
+func ValidateSampleModeValue(value string) (SampleMode, error) {
+	switch value {
+	case "every":
+		return SampleEveryNth, nil
+	case "probability":
+		return SampleProbability, nil
+	case "reservoir":
+		return SampleReservoir, nil
+	case "spaced":
+		return SampleSpaced, nil
+	default:
+		return SampleEveryNth, fmt.Errorf("sample mode is incorrect; possible values: [every, probability, reservoir, spaced]")
+	}
+}
+
+func (m SampleMode) String() string {
+	switch m {
+	case SampleEveryNth:
+		return "every"
+	case SampleProbability:
+		return "probability"
+	case SampleReservoir:
+		return "reservoir"
+	case SampleSpaced:
+		return "spaced"
+	default:
+		return "unknown"
+	}
+}
+
+// Sample determines which of the scanned messages make it to the scan's
+// results. Size is the N in "every Nth message", the size of a reservoir, or
+// the number of offsets to sample per partition, depending on the mode.
+type Sample struct {
+	Mode        SampleMode
+	Size        uint
+	Probability float64
+}
+
+func (s Sample) String() string {
+	switch s.Mode {
+	case SampleEveryNth:
+		return fmt.Sprintf("1 in every %d messages", s.Size)
+	case SampleProbability:
+		return fmt.Sprintf("each message with a probability of %s", strconv.FormatFloat(s.Probability, 'f', -1, 64))
+	case SampleReservoir:
+		return fmt.Sprintf("reservoir of %d messages", s.Size)
+	case SampleSpaced:
+		return fmt.Sprintf("%d evenly spaced offsets per partition", s.Size)
+	default:
+		return "unknown"
+	}
+}
+
+// IsResumable reports whether a scan using the sample can be resumed from a
+// checkpoint; reservoirs are only written once a scan finishes, and evenly
+// spaced offsets are computed for the whole range of each partition.
+func (s Sample) IsResumable() bool {
+	return s.Mode == SampleEveryNth || s.Mode == SampleProbability
+}
+
+type sampledMessage struct {
+	seq uint
+	msg t.Message
+}
+
+// sampler picks messages that match a scan's filters as per a sample.
+// Evenly spaced offsets are picked while fetching records, so every message
+// that reaches the sampler is kept in that mode.
+type sampler struct {
+	sample    Sample
+	numSeen   uint
+	rng       *rand.Rand
+	reservoir []sampledMessage
+}
+
+func newSampler(sample Sample) *sampler {
+	return &sampler{
+		sample: sample,
+		rng:    rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64())),
+	}
+}
+
+// add reports whether a message is part of the sample. Messages added to the
+// reservoir are held back (and false is returned for them), since they can
+// still be replaced; they're returned by reservoirMessages once the scan
+// finishes.
+func (sm *sampler) add(msg t.Message) bool {
+	sm.numSeen++
+
+	switch sm.sample.Mode {
+	case SampleEveryNth:
+		return (sm.numSeen-1)%sm.sample.Size == 0
+	case SampleProbability:
+		return sm.rng.Float64() < sm.sample.Probability
+	case SampleReservoir:
+		if uint(len(sm.reservoir)) < sm.sample.Size {
+			sm.reservoir = append(sm.reservoir, sampledMessage{seq: sm.numSeen, msg: msg})
+			return false
+		}
+
+		if j := sm.rng.UintN(sm.numSeen); j < sm.sample.Size {
+			sm.reservoir[j] = sampledMessage{seq: sm.numSeen, msg: msg}
+		}
+		return false
+	default:
+		return true
+	}
+}
+
+func (sm *sampler) numInReservoir() uint {
+	return uint(len(sm.reservoir))
+}
+
+// reservoirMessages returns the messages in the reservoir, in the order they
+// were consumed in.
+func (sm *sampler) reservoirMessages() []t.Message {
+	slices.SortFunc(sm.reservoir, func(a, b sampledMessage) int {
+		return cmp.Compare(a.seq, b.seq)
+	})
+
+	messages := make([]t.Message, len(sm.reservoir))
+	for i, sampled := range sm.reservoir {
+		messages[i] = sampled.msg
+	}
+
+	return messages
+}
+
+// spacedOffsets returns numOffsets offsets spread evenly across the range
+// [start, end), starting at start; every offset in the range is returned if
+// it holds fewer offsets than requested.
+func spacedOffsets(start, end int64, numOffsets uint) []int64 {
+	if end <= start || numOffsets == 0 {
+		return nil
+	}
+
+	rangeSize := end - start
+	if rangeSize <= int64(numOffsets) {
+		offsets := make([]int64, 0, rangeSize)
+		for offset := start; offset < end; offset++ {
+			offsets = append(offsets, offset)
+		}
+		return offsets
+	}
+
+	step := float64(rangeSize) / float64(numOffsets)
+	offsets := make([]int64, numOffsets)
+	for i := range numOffsets {
+		offsets[i] = start + int64(float64(i)*step)
+	}
+
+	return offsets
+}
+
+// fetchSpacedSamples fetches records at evenly spaced offsets of each
+// partition, instead of reading through partitions. Offsets are visited in
+// rounds; each round seeks every partition to its next offset, and fetches
+// the first record at or after it. The channel is closed when it's done.
+func (s *Scanner) fetchSpacedSamples(ctx context.Context, numToFetch uint, batches chan<- fetchedBatch) {
+	defer close(batches)
+
+	sendErr := func(err error) {
+		select {
+		case batches <- fetchedBatch{err: err}:
+		case <-ctx.Done():
+		}
+	}
+
+	offsetRanges, err := k.GetPartitionOffsetRanges(ctx, s.client, s.config.Topic)
+	if err != nil {
+		sendErr(err)
+		return
+	}
+
+	partitions := make([]int32, 0, len(offsetRanges))
+	targets := make(map[int32][]int64, len(offsetRanges))
+	var numRounds int
+	for partition, offsetRange := range offsetRanges {
+		partitions = append(partitions, partition)
+		targets[partition] = spacedOffsets(offsetRange.Start, offsetRange.End, s.behaviours.Sample.Size)
+		numRounds = max(numRounds, len(targets[partition]))
+	}
+	slices.Sort(partitions)
+
+	var numFetched uint
+	for round := range numRounds {
+		if numFetched >= numToFetch {
+			return
+		}
+
+		roundTargets := make(map[int32]int64)
+		var idlePartitions []int32
+		for _, partition := range partitions {
+			if round < len(targets[partition]) {
+				roundTargets[partition] = targets[partition][round]
+			} else {
+				idlePartitions = append(idlePartitions, partition)
+			}
+		}
+
+		// partitions are consumed from their start to begin with, which is
+		// where the first round's offsets are
+		if round > 0 {
+			s.seek(roundTargets, idlePartitions)
+		}
+
+		records, err := s.fetchSampleRound(ctx, roundTargets, partitions)
+		if err != nil {
+			sendErr(err)
+			return
+		}
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		if len(records) == 0 {
+			continue
+		}
+
+		records = records[:min(uint(len(records)), numToFetch-numFetched)]
+		numFetched += uint(len(records))
+
+		select {
+		case batches <- fetchedBatch{records: records}:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (s *Scanner) seek(offsets map[int32]int64, idlePartitions []int32) {
+	toSet := make(map[int32]kgo.EpochOffset, len(offsets))
+	for partition, offset := range offsets {
+		toSet[partition] = kgo.EpochOffset{Epoch: -1, Offset: offset}
+	}
+
+	s.client.SetOffsets(map[string]map[int32]kgo.EpochOffset{s.config.Topic: toSet})
+
+	if len(idlePartitions) > 0 {
+		s.client.PauseFetchPartitions(map[string][]int32{s.config.Topic: idlePartitions})
+	}
+}
+
+// fetchSampleRound fetches the first record at or after the target offset of
+// each partition. Partitions that don't return such a record in time (eg.
+// because records were deleted in the meantime) are skipped.
+func (s *Scanner) fetchSampleRound(ctx context.Context, targets map[int32]int64, partitions []int32) ([]*kgo.Record, error) {
+	roundCtx, cancel := context.WithTimeout(ctx, spacedSampleRoundTimeout)
+	defer cancel()
+
+	sampled := make(map[int32]*kgo.Record, len(targets))
+	for len(sampled) < len(targets) && roundCtx.Err() == nil {
+		records, err := k.FetchRecords(roundCtx, s.client, s.behaviours.BatchSize)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, record := range records {
+			target, ok := targets[record.Partition]
+			if !ok || record.Offset < target {
+				continue
+			}
+
+			if _, ok := sampled[record.Partition]; !ok {
+				sampled[record.Partition] = record
+			}
+		}
+	}
+
+	records := make([]*kgo.Record, 0, len(sampled))
+	for _, partition := range partitions {
+		if record, ok := sampled[partition]; ok {
+			records = append(records, record)
+		}
+	}
+
+	return records, nil
+}
@@ -0,0 +1,143 @@
+package scan
+
+import (
+	"testing"
+
+	t "github.com/dhth/kplay/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func getTestSampleMessages(num int) []t.Message {
+	messages := make([]t.Message, num)
+	for i := range num {
+		messages[i] = t.Message{Partition: int32(i % 2), Offset: int64(i)}
+	}
+
+	return messages
+}
+
+func TestSamplerEveryNth(t *testing.T) {
+	// GIVEN
+	sm := newSampler(Sample{Mode: SampleEveryNth, Size: 3})
+
+	// WHEN
+	var sampled []int64
+	for _, msg := range getTestSampleMessages(10) {
+		if sm.add(msg) {
+			sampled = append(sampled, msg.Offset)
+		}
+	}
+
+	// THEN
+	assert.Equal(t, []int64{0, 3, 6, 9}, sampled)
+}
+
+func TestSamplerProbability(t *testing.T) {
+	testCases := []struct {
+		name        string
+		probability float64
+		minSampled  int
+		maxSampled  int
+	}{
+		{name: "probability of 1", probability: 1, minSampled: 1000, maxSampled: 1000},
+		{name: "probability of 0.5", probability: 0.5, minSampled: 400, maxSampled: 600},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			// GIVEN
+			sm := newSampler(Sample{Mode: SampleProbability, Probability: tt.probability})
+
+			// WHEN
+			var numSampled int
+			for _, msg := range getTestSampleMessages(1000) {
+				if sm.add(msg) {
+					numSampled++
+				}
+			}
+
+			// THEN
+			assert.GreaterOrEqual(t, numSampled, tt.minSampled)
+			assert.LessOrEqual(t, numSampled, tt.maxSampled)
+		})
+	}
+}
+
+func TestSamplerReservoir(t *testing.T) {
+	// GIVEN
+	sm := newSampler(Sample{Mode: SampleReservoir, Size: 20})
+
+	// WHEN
+	for _, msg := range getTestSampleMessages(1000) {
+		assert.False(t, sm.add(msg))
+	}
+	messages := sm.reservoirMessages()
+
+	// THEN
+	require.Len(t, messages, 20)
+	assert.Equal(t, uint(20), sm.numInReservoir())
+	for i := 1; i < len(messages); i++ {
+		assert.Less(t, messages[i-1].Offset, messages[i].Offset)
+	}
+	// it's very unlikely for a uniform sample of 20 out of 1000 messages to
+	// only hold messages from the first half
+	assert.GreaterOrEqual(t, messages[len(messages)-1].Offset, int64(500))
+}
+
+func TestSamplerReservoirWithFewerMessagesThanItsSize(t *testing.T) {
+	// GIVEN
+	sm := newSampler(Sample{Mode: SampleReservoir, Size: 20})
+
+	// WHEN
+	for _, msg := range getTestSampleMessages(5) {
+		sm.add(msg)
+	}
+	messages := sm.reservoirMessages()
+
+	// THEN
+	require.Len(t, messages, 5)
+	for i, msg := range messages {
+		assert.Equal(t, int64(i), msg.Offset)
+	}
+}
+
+func TestSpacedOffsets(t *testing.T) {
+	testCases := []struct {
+		name       string
+		start      int64
+		end        int64
+		numOffsets uint
+		expected   []int64
+	}{
+		{name: "evenly divisible range", start: 0, end: 100, numOffsets: 4, expected: []int64{0, 25, 50, 75}},
+		{name: "range with an offset", start: 1000, end: 1010, numOffsets: 3, expected: []int64{1000, 1003, 1006}},
+		{name: "range smaller than the number of offsets", start: 5, end: 8, numOffsets: 10, expected: []int64{5, 6, 7}},
+		{name: "empty range", start: 10, end: 10, numOffsets: 5, expected: nil},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			got := spacedOffsets(tt.start, tt.end, tt.numOffsets)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestSampleString(t *testing.T) {
+	testCases := []struct {
+		sample   Sample
+		expected string
+	}{
+		{sample: Sample{Mode: SampleEveryNth, Size: 10}, expected: "1 in every 10 messages"},
+		{sample: Sample{Mode: SampleProbability, Probability: 0.05}, expected: "each message with a probability of 0.05"},
+		{sample: Sample{Mode: SampleReservoir, Size: 500}, expected: "reservoir of 500 messages"},
+		{sample: Sample{Mode: SampleSpaced, Size: 20}, expected: "20 evenly spaced offsets per partition"},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.expected, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.sample.String())
+		})
+	}
+}
@@ -1,21 +1,22 @@
 package scan
 
 import (
-	"bufio"
 	"context"
-	"encoding/csv"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"maps"
 	"os"
 	"os/signal"
 	"path/filepath"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/charmbracelet/lipgloss"
-	"github.com/dhth/kplay/internal/fs"
 	k "github.com/dhth/kplay/internal/kafka"
+	"github.com/dhth/kplay/internal/schema"
 	t "github.com/dhth/kplay/internal/types"
 	"github.com/dhth/kplay/internal/utils"
 	"github.com/twmb/franz-go/pkg/kgo"
@@ -24,35 +25,73 @@ import (
 var errCouldntWriteRecordToFile = errors.New("couldn't write record to file")
 
 type Scanner struct {
-	client     *kgo.Client
-	config     t.Config
-	behaviours Behaviours
-	outputDir  string
-	progress   scanProgress
-}
-
-type messageWriter struct {
-	file      *os.File
-	writer    *bufio.Writer
-	csvWriter *csv.Writer
+	client            *kgo.Client
+	config            t.Config
+	behaviours        Behaviours
+	consumeBehaviours t.ConsumeBehaviours
+	outputDir         string
+	progress          scanProgress
+	nextOffsets       map[int32]int64
+	checkpointErr     error
+	stats             *topicStats
+	inferrer          *schema.Inferrer
+	sampler           *sampler
+	duplicates        *duplicateDetector
+	findings          *findingsWriter
+	merger            *k.Merger
+	columns           resultColumns
 }
 
 type scanProgress struct {
-	numRecordsConsumed uint
-	numRecordsMatched  uint
-	numBytesConsumed   uint64
-	lastOffsetDetails  string
-	lastTimeStampSeen  time.Time
-	numDecodeErrors    uint
-	fsErrors           []fsError
+	numRecordsConsumed     uint
+	numRecordsMatched      uint
+	numRecordsSampled      uint
+	numHeaderMatches       uint
+	numHeaderFilterMatches []uint
+	numBytesConsumed       uint64
+	lastOffsetDetails      string
+	lastTimeStampSeen      time.Time
+	numDecodeErrors        uint
+	numKeyDecodeErrors     uint
+	numViolations          uint
+	numSQLiteRowsInserted  uint
+	numDuplicates          uint
+	numOutOfOrder          uint
+	numSchemaSkips         uint
+	fsErrors               []fsError
 }
 
-func New(client *kgo.Client, config t.Config, behaviours Behaviours, outputDir string) Scanner {
+func New(client *kgo.Client, config t.Config, behaviours Behaviours, consumeBehaviours t.ConsumeBehaviours, outputDir string) Scanner {
 	scanner := Scanner{
-		client:     client,
-		config:     config,
-		behaviours: behaviours,
-		outputDir:  outputDir,
+		client:            client,
+		config:            config,
+		behaviours:        behaviours,
+		consumeBehaviours: consumeBehaviours,
+		outputDir:         outputDir,
+		progress: scanProgress{
+			numHeaderFilterMatches: make([]uint, len(behaviours.HeaderFilters)),
+		},
+		nextOffsets: make(map[int32]int64),
+	}
+
+	if behaviours.Sample != nil {
+		scanner.sampler = newSampler(*behaviours.Sample)
+	}
+
+	if behaviours.Duplicates != nil {
+		scanner.duplicates = newDuplicateDetector(*behaviours.Duplicates)
+	}
+
+	if behaviours.Merge != nil {
+		scanner.merger = k.NewMerger(*behaviours.Merge)
+	}
+
+	if behaviours.Resume != nil {
+		scanner.progress.restore(behaviours.Resume.Progress)
+		maps.Copy(scanner.nextOffsets, behaviours.Resume.NextOffsets)
+		if scanner.sampler != nil && scanner.sampler.sample.Mode == SampleEveryNth {
+			scanner.sampler.numSeen = behaviours.Resume.SampleNumSeen
+		}
 	}
 
 	return scanner
@@ -97,31 +136,121 @@ func (s *Scanner) Execute() error {
 	}
 }
 
+// includesValues reports whether values are part of the scan results. Values
+// saved to a SQLite database (or as separate files) don't need a results column.
+func (s *Scanner) includesValues() bool {
+	return s.behaviours.SingleFile || s.behaviours.Format == JSONLFormat
+}
+
+func (s *Scanner) needsDecoding() bool {
+	if !s.behaviours.Decode {
+		return false
+	}
+
+	return s.behaviours.SaveMessages ||
+		s.includesValues() ||
+		s.behaviours.SQLitePath != "" ||
+		s.config.Validation != nil ||
+		s.behaviours.Filter != nil ||
+		s.behaviours.Stats ||
+		s.behaviours.InferSchema ||
+		(s.behaviours.Duplicates != nil && s.behaviours.Duplicates.needsDecoding())
+}
+
+func (s *Scanner) getResultColumns(decode bool) resultColumns {
+	return resultColumns{
+		decodeErrors:    decode,
+		keyDecodeErrors: s.config.KeyEncoding != t.KeyString,
+		violations:      decode && s.config.Validation != nil,
+		values:          s.includesValues(),
+		headers:         s.behaviours.HeaderColumns,
+	}
+}
+
 func (s *Scanner) scan(ctx context.Context) error {
 	var recordWriter *messageWriter
 
 	now := time.Now().Unix()
 	scanOutputDir := filepath.Join(s.outputDir, "messages", s.config.Topic)
 
-	err := os.MkdirAll(scanOutputDir, 0o755)
-	if err != nil {
-		return fmt.Errorf("%w: %s", t.ErrCouldntCreateDir, err.Error())
+	decode := s.needsDecoding()
+
+	if s.behaviours.Stats {
+		s.stats = newTopicStats(decode)
 	}
 
-	scanOutputFilePath := filepath.Join(scanOutputDir, fmt.Sprintf("scan-%d.csv", now))
+	var schemaFilePath string
+	if s.behaviours.InferSchema {
+		s.inferrer = schema.New()
+		schemaFilePath = filepath.Join(s.outputDir, "schemas", s.config.Topic, fmt.Sprintf("schema-%d.json", now))
+	}
 
-	decode := s.behaviours.SaveMessages && s.behaviours.Decode
+	// scan results (and checkpoints) aren't written when inferring a schema
+	var scanOutputFilePath string
+	var checkpointFilePath string
+	if !s.behaviours.InferSchema {
+		err := os.MkdirAll(scanOutputDir, 0o755)
+		if err != nil {
+			return fmt.Errorf("%w: %s", t.ErrCouldntCreateDir, err.Error())
+		}
 
-	rw, err := newMessageWriter(scanOutputFilePath, decode)
-	if err != nil {
-		return err
+		scanOutputFilePath = filepath.Join(scanOutputDir, fmt.Sprintf("scan-%d.%s", now, s.behaviours.Format.extension()))
+		if s.behaviours.Resume != nil {
+			scanOutputFilePath = s.behaviours.Resume.ResultsFilePath
+		}
+		if s.behaviours.Sample == nil || s.behaviours.Sample.IsResumable() {
+			checkpointFilePath = getCheckpointFilePath(scanOutputFilePath)
+		}
+
+		s.columns = s.getResultColumns(decode)
+
+		if s.behaviours.Resume != nil {
+			if err := s.behaviours.Resume.checkColumns(s.behaviours.Format, s.columns); err != nil {
+				return err
+			}
+
+			if err := truncateToCheckpoint(scanOutputFilePath, s.behaviours.Resume.ResultsFileSize); err != nil {
+				return err
+			}
+		}
+
+		rw, err := newMessageWriter(scanOutputFilePath, s.behaviours.Format, s.columns, s.behaviours.Resume != nil)
+		if err != nil {
+			return err
+		}
+
+		defer func() {
+			_ = rw.close()
+		}()
+
+		recordWriter = rw
+
+		if s.duplicates != nil {
+			fw, err := newFindingsWriter(getDuplicatesReportFilePath(scanOutputFilePath))
+			if err != nil {
+				return err
+			}
+
+			defer func() {
+				_ = fw.close()
+			}()
+
+			s.findings = fw
+		}
 	}
 
-	defer func() {
-		_ = rw.close()
-	}()
+	var dbWriter *sqliteWriter
+	if s.behaviours.SQLitePath != "" {
+		var err error
+		dbWriter, err = newSQLiteWriter(s.behaviours.SQLitePath)
+		if err != nil {
+			return err
+		}
 
-	recordWriter = rw
+		defer func() {
+			_ = dbWriter.close()
+		}()
+	}
 
 	progressChan := make(chan scanProgress, 1)
 	spinnerDone := make(chan struct{})
@@ -132,185 +261,339 @@ func (s *Scanner) scan(ctx context.Context) error {
 		spinnerDone <- struct{}{}
 		close(spinnerDone)
 		close(progressChan)
-		s.reportResults(scanOutputDir, scanOutputFilePath)
+		if checkpointFilePath != "" {
+			s.saveCheckpoint(recordWriter, checkpointFilePath, scanOutputFilePath)
+		}
+		s.reportResults(scanOutputFilePath, schemaFilePath, checkpointFilePath)
 	}()
 
-	for s.progress.numRecordsConsumed < s.behaviours.NumMessages {
-		select {
-		case <-ctx.Done():
-			return nil
-		default:
+	lastCheckpointAt := time.Now()
+
+	// records are fetched and decoded in the background while the previous
+	// batch is being processed; the pipeline is stopped before results are
+	// reported
+	pipelineCtx, cancelPipeline := context.WithCancel(ctx)
+	batches := make(chan fetchedBatch, 1)
+	numToFetch := s.behaviours.NumMessages - min(s.progress.numRecordsConsumed, s.behaviours.NumMessages)
+
+	var pipelineWG sync.WaitGroup
+	pipelineWG.Go(func() {
+		if s.behaviours.Sample != nil && s.behaviours.Sample.Mode == SampleSpaced {
+			s.fetchSpacedSamples(pipelineCtx, numToFetch, batches)
+			return
 		}
+		s.fetchBatches(pipelineCtx, numToFetch, batches)
+	})
+	decodedBatches := s.decodeBatches(pipelineCtx, &pipelineWG, batches, decode)
+	defer func() {
+		cancelPipeline()
+		pipelineWG.Wait()
+	}()
 
-		toFetch := min(s.behaviours.NumMessages-s.progress.numRecordsConsumed, s.behaviours.BatchSize)
+	var toInsert []t.Message
 
-		fetchCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
-		records, err := k.FetchRecords(fetchCtx, s.client, toFetch)
-		cancel()
+	// emit adds a message to the scan's results; messages are inserted into
+	// SQLite in batches, via insertPending
+	emit := func(msg t.Message) error {
+		if recordWriter != nil {
+			err := recordWriter.writeMsg(msg)
+			if err != nil {
+				return fmt.Errorf("%w: %s", errCouldntWriteRecordToFile, err.Error())
+			}
+		}
+
+		if s.stats != nil {
+			s.stats.add(msg)
+		}
+
+		if s.inferrer != nil {
+			s.addToSchema(msg)
+		}
+
+		if s.duplicates != nil {
+			for _, finding := range s.duplicates.check(msg) {
+				if finding.Kind == findingDuplicate {
+					s.progress.numDuplicates++
+				} else {
+					s.progress.numOutOfOrder++
+				}
+
+				if err := s.findings.write(finding); err != nil {
+					return err
+				}
+			}
+		}
+
+		if dbWriter != nil {
+			toInsert = append(toInsert, msg)
+		}
+
+		if s.behaviours.SaveMessages {
+			_, err := s.behaviours.Save.Save(msg, filepath.Join(s.outputDir, "messages"))
+			if err != nil {
+				s.progress.fsErrors = append(s.progress.fsErrors, fsError{offset: msg.Offset, key: msg.Key, err: err})
+			}
+		}
 
+		return nil
+	}
+
+	insertPending := func() error {
+		if len(toInsert) == 0 {
+			return nil
+		}
+
+		numInserted, err := dbWriter.write(s.config.Topic, toInsert)
 		if err != nil {
 			return err
 		}
+		s.progress.numSQLiteRowsInserted += numInserted
+		toInsert = nil
+
+		return nil
+	}
 
-		if len(records) == 0 {
-			continue
+loop:
+	for {
+		var batch *decodedBatch
+		var ok bool
+		select {
+		case <-ctx.Done():
+			break loop
+		case batch, ok = <-decodedBatches:
+		}
+
+		if !ok {
+			break loop
 		}
 
+		if batch.err != nil {
+			return batch.err
+		}
+
+		records := batch.records
 		lastRecord := records[len(records)-1]
 
-		for _, record := range records {
+		for _, decoded := range batch.decoded {
+			record := decoded.record
 			if record == nil {
 				continue
 			}
 
-			msg := t.GetMessageFromRecord(*record, s.config, decode)
+			msg := decoded.msg
 			if msg.DecodeErr != nil {
 				s.progress.numDecodeErrors++
 			}
 
-			keyMatches := s.behaviours.KeyFilterRegex != nil && s.behaviours.KeyFilterRegex.MatchString(msg.Key)
-			if keyMatches {
-				s.progress.numRecordsMatched++
+			if msg.KeyDecodeErr != nil {
+				s.progress.numKeyDecodeErrors++
 			}
 
-			saveMsg := s.behaviours.KeyFilterRegex == nil || keyMatches
+			if len(msg.Violations) > 0 {
+				s.progress.numViolations++
+			}
 
-			if recordWriter != nil && saveMsg {
-				err := recordWriter.writeMsg(msg, decode)
-				if err != nil {
-					return fmt.Errorf("%w: %s", errCouldntWriteRecordToFile, err.Error())
-				}
+			saveMsg := s.matches(msg, decoded.matchesFilter)
+			if s.behaviours.isFiltering() && saveMsg {
+				s.progress.numRecordsMatched++
 			}
 
-			if s.behaviours.SaveMessages && saveMsg {
-				filePath := filepath.Join(
-					scanOutputDir,
-					fmt.Sprintf("partition-%d", msg.Partition),
-					fmt.Sprintf("offset-%d.txt", msg.Offset),
-				)
+			if s.sampler != nil && saveMsg {
+				saveMsg = s.sampler.add(msg)
+				if saveMsg {
+					s.progress.numRecordsSampled++
+				}
+			}
 
-				err := fs.SaveMessageToFileSystem(msg, filePath)
-				if err != nil {
-					s.progress.fsErrors = append(s.progress.fsErrors, fsError{offset: msg.Offset, key: msg.Key, err: err})
+			if saveMsg {
+				if err := emit(msg); err != nil {
+					return err
 				}
 			}
 
+			s.nextOffsets[record.Partition] = record.Offset + 1
 			s.progress.numBytesConsumed += uint64(len(record.Value))
 			s.progress.lastOffsetDetails = fmt.Sprintf("%d:%d", lastRecord.Partition, lastRecord.Offset)
 			s.progress.lastTimeStampSeen = lastRecord.Timestamp
 		}
 
+		if err := insertPending(); err != nil {
+			return err
+		}
+
 		s.progress.numRecordsConsumed += uint(len(records))
 
+		if s.sampler != nil && s.behaviours.Sample.Mode == SampleReservoir {
+			s.progress.numRecordsSampled = s.sampler.numInReservoir()
+		}
+
+		if checkpointFilePath != "" && time.Since(lastCheckpointAt) >= checkpointInterval {
+			s.saveCheckpoint(recordWriter, checkpointFilePath, scanOutputFilePath)
+			lastCheckpointAt = time.Now()
+		}
+
 		progressChan <- s.progress
+	}
 
+	// a reservoir holds the sample of the messages scanned so far, which is
+	// only final once the scan stops
+	if s.sampler != nil && s.behaviours.Sample.Mode == SampleReservoir {
+		for _, msg := range s.sampler.reservoirMessages() {
+			if err := emit(msg); err != nil {
+				return err
+			}
+		}
+
+		if err := insertPending(); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
-func (s *Scanner) reportResults(scanOutputDir, scanOutputFilePath string) {
+// matches reports whether a message satisfies the key regex, the header
+// filters, and the filter expression (which has already been evaluated),
+// whichever of these are provided. Header filters are all evaluated, so that
+// matches can be counted for each of them.
+func (s *Scanner) matches(msg t.Message, matchesFilter bool) bool {
+	headersMatch := true
+	for i, f := range s.behaviours.HeaderFilters {
+		if f.matches(msg.Headers) {
+			s.progress.numHeaderFilterMatches[i]++
+		} else {
+			headersMatch = false
+		}
+	}
+
+	if len(s.behaviours.HeaderFilters) > 0 && headersMatch {
+		s.progress.numHeaderMatches++
+	}
+
+	if !headersMatch {
+		return false
+	}
+
+	if s.behaviours.KeyFilterRegex != nil && !s.behaviours.KeyFilterRegex.MatchString(msg.Key) {
+		return false
+	}
+
+	return matchesFilter
+}
+
+// saveCheckpoint flushes the scan results, so that they include every message
+// accounted for in the checkpoint, and then writes the checkpoint.
+func (s *Scanner) saveCheckpoint(recordWriter *messageWriter, checkpointFilePath, scanOutputFilePath string) {
+	if recordWriter != nil {
+		if err := recordWriter.flush(); err != nil {
+			s.checkpointErr = fmt.Errorf("%w: %s", errCouldntWriteCheckpoint, err.Error())
+			return
+		}
+	}
+
+	if s.findings != nil {
+		if err := s.findings.flush(); err != nil {
+			s.checkpointErr = fmt.Errorf("%w: %s", errCouldntWriteCheckpoint, err.Error())
+			return
+		}
+	}
+
+	s.checkpointErr = s.writeCheckpoint(checkpointFilePath, scanOutputFilePath)
+}
+
+func (s *Scanner) reportResults(scanOutputFilePath, schemaFilePath, checkpointFilePath string) {
 	fmt.Fprint(os.Stderr, "\r\033[K")
 
 	if s.progress.numRecordsConsumed == 0 {
 		return
 	}
 
-	fmt.Printf(`Summary:
+	fmt.Print("Summary:\n\n")
 
-Scan Results File:             %s
-Number of messages scanned:    %d
+	if scanOutputFilePath != "" {
+		fmt.Printf("Scan Results File:             %s\n", scanOutputFilePath)
+	}
+
+	fmt.Printf(`Number of messages scanned:    %d
 Value bytes consumed:          %s
-`, scanOutputFilePath, s.progress.numRecordsConsumed, utils.HumanReadableBytes(s.progress.numBytesConsumed))
+`, s.progress.numRecordsConsumed, utils.HumanReadableBytes(s.progress.numBytesConsumed))
 
-	if s.behaviours.KeyFilterRegex != nil {
+	if s.behaviours.isFiltering() {
 		fmt.Printf("Number of matches:             %d\n", s.progress.numRecordsMatched)
 	}
 
-	if s.behaviours.SaveMessages && len(s.progress.fsErrors) < int(s.progress.numRecordsConsumed) {
-		fmt.Printf("Messages saved in:             %s\n", scanOutputDir)
+	if s.behaviours.Sample != nil {
+		fmt.Printf("Number of sampled messages:    %d\n", s.progress.numRecordsSampled)
 	}
 
-	if s.progress.numDecodeErrors > 0 {
-		fmt.Printf("Decode errors:                 %d\n", s.progress.numDecodeErrors)
+	if s.merger != nil {
+		fmt.Printf("Messages merged out of order:  %d\n", s.merger.NumLate())
 	}
 
-	if len(s.progress.fsErrors) > 0 {
-		errStrs := make([]string, len(s.progress.fsErrors))
-		for i, err := range s.progress.fsErrors {
-			errStrs[i] = fmt.Sprintf("- offset: %d, key: %s, error: %s", err.offset, err.key, err.err.Error())
+	if len(s.behaviours.HeaderFilters) > 0 {
+		fmt.Printf("Header filter matches:         %d\n", s.progress.numHeaderMatches)
+		for i, f := range s.behaviours.HeaderFilters {
+			fmt.Printf("  - %s: %d\n", f.String(), s.progress.numHeaderFilterMatches[i])
 		}
-
-		fmt.Printf("\nEncountered the following errors while saving values to the local filesystem:\n%s", strings.Join(errStrs, "\n"))
 	}
-}
 
-func newMessageWriter(filePath string, decode bool) (*messageWriter, error) {
-	file, err := os.Create(filePath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create output file: %w", err)
+	if checkpointFilePath != "" {
+		if s.checkpointErr != nil {
+			fmt.Printf("Checkpoint:                    %s\n", s.checkpointErr.Error())
+		} else {
+			fmt.Printf("Checkpoint:                    %s\n", checkpointFilePath)
+		}
 	}
 
-	rw := &messageWriter{
-		file:   file,
-		writer: bufio.NewWriter(file),
+	if s.behaviours.SQLitePath != "" {
+		fmt.Printf("SQLite database:               %s\n", s.behaviours.SQLitePath)
+		fmt.Printf("Rows added to SQLite database: %d\n", s.progress.numSQLiteRowsInserted)
 	}
 
-	rw.csvWriter = csv.NewWriter(rw.writer)
-	headers := []string{"partition", "offset", "timestamp", "key", "tombstone"}
-	if decode {
-		headers = append(headers, "decode_error")
+	if s.behaviours.SaveMessages && len(s.progress.fsErrors) < int(s.progress.numRecordsConsumed) {
+		fmt.Printf("Messages saved as:             %s\n", filepath.Join(s.outputDir, "messages", s.behaviours.Save.PathTemplate()))
 	}
 
-	err = rw.csvWriter.Write(headers)
-	if err != nil {
-		file.Close()
-		return nil, fmt.Errorf("failed to write CSV header: %w", err)
+	if s.duplicates != nil {
+		fmt.Printf("Duplicates report:             %s\n", getDuplicatesReportFilePath(scanOutputFilePath))
+		fmt.Printf("Duplicates:                    %d\n", s.progress.numDuplicates)
+		fmt.Printf("Out-of-order messages:         %d\n", s.progress.numOutOfOrder)
+		if s.duplicates.numWithoutID > 0 {
+			fmt.Printf("Messages without an ID:        %d\n", s.duplicates.numWithoutID)
+		}
+		if s.duplicates.numWithoutEventTime > 0 {
+			fmt.Printf("Messages without event time:   %d\n", s.duplicates.numWithoutEventTime)
+		}
 	}
 
-	return rw, nil
-}
+	if s.progress.numDecodeErrors > 0 {
+		fmt.Printf("Decode errors:                 %d\n", s.progress.numDecodeErrors)
+	}
 
-func (rw *messageWriter) writeMsg(msg t.Message, decode bool) error {
-	return rw.writeCSV(msg, decode)
-}
+	if s.progress.numKeyDecodeErrors > 0 {
+		fmt.Printf("Key decode errors:             %d\n", s.progress.numKeyDecodeErrors)
+	}
 
-func (rw *messageWriter) writeCSV(msg t.Message, decode bool) error {
-	tombstone := "false"
-	if msg.Value == nil {
-		tombstone = "true"
+	if s.progress.numViolations > 0 {
+		fmt.Printf("Messages with violations:      %d\n", s.progress.numViolations)
 	}
 
-	row := []string{
-		fmt.Sprintf("%d", msg.Partition),
-		fmt.Sprintf("%d", msg.Offset),
-		msg.Timestamp.Format(time.RFC3339),
-		msg.Key,
-		tombstone,
+	if s.stats != nil {
+		s.reportStats(scanOutputFilePath)
 	}
 
-	if decode {
-		var decodeErrStr string
-		if msg.DecodeErr != nil {
-			decodeErrStr = msg.DecodeErr.Error()
-		}
-		row = append(row, decodeErrStr)
+	if s.inferrer != nil {
+		s.reportSchema(schemaFilePath)
 	}
 
-	return rw.csvWriter.Write(row)
-}
+	if len(s.progress.fsErrors) > 0 {
+		errStrs := make([]string, len(s.progress.fsErrors))
+		for i, err := range s.progress.fsErrors {
+			errStrs[i] = fmt.Sprintf("- offset: %d, key: %s, error: %s", err.offset, err.key, err.err.Error())
+		}
 
-func (rw *messageWriter) close() error {
-	if rw.csvWriter != nil {
-		rw.csvWriter.Flush()
-	}
-	if rw.writer != nil {
-		rw.writer.Flush()
-	}
-	if rw.file != nil {
-		return rw.file.Close()
+		fmt.Printf("\nEncountered the following errors while saving values to the local filesystem:\n%s", strings.Join(errStrs, "\n"))
 	}
-	return nil
 }
 
 func showSpinner(doneChan chan struct{}, progressChan chan scanProgress, behaviours Behaviours) {
@@ -337,13 +620,26 @@ func showSpinner(doneChan chan struct{}, progressChan chan scanProgress, behavio
 
 			bytesConsumed := utils.HumanReadableBytes(progress.numBytesConsumed)
 			var matchInfo string
-			if behaviours.KeyFilterRegex != nil {
+			if behaviours.isFiltering() {
 				matchInfo = fmt.Sprintf("; %d matches", progress.numRecordsMatched)
 			}
+			if len(behaviours.HeaderFilters) > 0 {
+				matchInfo += fmt.Sprintf(", %d header matches", progress.numHeaderMatches)
+			}
+			if behaviours.Sample != nil {
+				if matchInfo == "" {
+					matchInfo = fmt.Sprintf("; %d sampled", progress.numRecordsSampled)
+				} else {
+					matchInfo += fmt.Sprintf(", %d sampled", progress.numRecordsSampled)
+				}
+			}
 
-			var decodeErrorsSection string
+			var errorsSection string
 			if progress.numDecodeErrors > 0 {
-				decodeErrorsSection = fmt.Sprintf(", decode errors: %s", errorStyle.Render(fmt.Sprintf("%d", progress.numDecodeErrors)))
+				errorsSection = fmt.Sprintf(", decode errors: %s", errorStyle.Render(fmt.Sprintf("%d", progress.numDecodeErrors)))
+			}
+			if progress.numViolations > 0 {
+				errorsSection += fmt.Sprintf(", violations: %s", errorStyle.Render(fmt.Sprintf("%d", progress.numViolations)))
 			}
 			progressLine = fmt.Sprintf("%s messages scanned%s (offset: %s, timestamp: %s, bytes consumed: %s%s)",
 				numRecordsStyle.Render(fmt.Sprintf("%d", progress.numRecordsConsumed)),
@@ -351,7 +647,7 @@ func showSpinner(doneChan chan struct{}, progressChan chan scanProgress, behavio
 				offsetStyle.Render(progress.lastOffsetDetails),
 				timestampStyle.Render(progress.lastTimeStampSeen.Format(time.RFC3339)),
 				bytesStyle.Render(bytesConsumed),
-				decodeErrorsSection,
+				errorsSection,
 			)
 		default:
 			if spinnerIndex >= len(spinnerRunes)-1 {
@@ -372,3 +668,63 @@ func showSpinner(doneChan chan struct{}, progressChan chan scanProgress, behavio
 		}
 	}
 }
+
+// reportStats prints the statistics computed during the scan, and saves them
+// as JSON next to the scan results file.
+func (s *Scanner) reportStats(scanOutputFilePath string) {
+	report := s.stats.report()
+
+	statsFilePath := fmt.Sprintf("%s-stats.json", strings.TrimSuffix(scanOutputFilePath, filepath.Ext(scanOutputFilePath)))
+
+	fmt.Printf("\n%s", report.Display())
+
+	reportBytes, err := json.MarshalIndent(report, "", "  ")
+	if err == nil {
+		err = os.WriteFile(statsFilePath, reportBytes, 0o644)
+	}
+	if err != nil {
+		fmt.Printf("\nCouldn't save stats to the local filesystem: %s\n", err.Error())
+		return
+	}
+
+	fmt.Printf("\nStats saved in:                %s\n", statsFilePath)
+}
+
+// addToSchema merges a message's decoded value into the inferred schema;
+// tombstones, and values that couldn't be decoded as JSON are skipped.
+func (s *Scanner) addToSchema(msg t.Message) {
+	if msg.DecodeErr != nil || len(msg.Value) == 0 {
+		s.progress.numSchemaSkips++
+		return
+	}
+
+	if err := s.inferrer.Add(msg.Value); err != nil {
+		s.progress.numSchemaSkips++
+	}
+}
+
+// reportSchema saves the inferred schema to the local filesystem.
+func (s *Scanner) reportSchema(schemaFilePath string) {
+	fmt.Printf("Messages used for the schema:  %d\n", s.inferrer.NumValues())
+	if s.progress.numSchemaSkips > 0 {
+		fmt.Printf("Messages skipped:              %d (tombstones, or values that couldn't be decoded as JSON)\n", s.progress.numSchemaSkips)
+	}
+
+	if s.inferrer.NumValues() == 0 {
+		return
+	}
+
+	schemaBytes, err := s.inferrer.Schema(s.config.Topic)
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(schemaFilePath), 0o755)
+	}
+	if err == nil {
+		err = os.WriteFile(schemaFilePath, append(schemaBytes, '\n'), 0o644)
+	}
+	if err != nil {
+		fmt.Printf("\nCouldn't save the inferred schema to the local filesystem: %s\n", err.Error())
+		return
+	}
+
+	fmt.Printf("Inferred schema saved in:      %s\n", schemaFilePath)
+}
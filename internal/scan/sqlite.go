@@ -0,0 +1,138 @@
+package scan
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	t "github.com/dhth/kplay/internal/types"
+	_ "modernc.org/sqlite" // registers the "sqlite" driver
+)
+
+var (
+	errCouldntOpenSQLiteDB     = errors.New("couldn't open SQLite database")
+	errCouldntWriteToSQLiteDB  = errors.New("couldn't write to SQLite database")
+	errCouldntSerializeHeaders = errors.New("couldn't serialize headers")
+)
+
+// sqliteTimestampLayout is understood by SQLite's date and time functions, and
+// sorts the same way as the timestamps it represents (when in UTC).
+const sqliteTimestampLayout = "2006-01-02T15:04:05.000Z"
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS messages (
+    topic TEXT NOT NULL,
+    partition INTEGER NOT NULL,
+    offset INTEGER NOT NULL,
+    timestamp TEXT NOT NULL,
+    key TEXT NOT NULL,
+    headers TEXT NOT NULL,
+    value TEXT,
+    decode_error TEXT,
+    PRIMARY KEY (topic, partition, offset)
+);
+
+CREATE INDEX IF NOT EXISTS idx_messages_topic_timestamp ON messages (topic, timestamp);
+CREATE INDEX IF NOT EXISTS idx_messages_topic_key ON messages (topic, key);
+`
+
+// messages that are already present in the database (eg. from a previous
+// scan) are skipped
+const sqliteInsertQuery = `
+INSERT OR IGNORE INTO messages (topic, partition, offset, timestamp, key, headers, value, decode_error)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+`
+
+type sqliteWriter struct {
+	db *sql.DB
+}
+
+func newSQLiteWriter(dbPath string) (*sqliteWriter, error) {
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errCouldntOpenSQLiteDB, err.Error())
+	}
+
+	_, err = db.Exec(sqliteSchema)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("%w: %s", errCouldntOpenSQLiteDB, err.Error())
+	}
+
+	return &sqliteWriter{db: db}, nil
+}
+
+// write inserts messages in a single transaction, and returns the number of
+// rows inserted; the rest were already present in the database.
+func (sw *sqliteWriter) write(topic string, messages []t.Message) (uint, error) {
+	tx, err := sw.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s", errCouldntWriteToSQLiteDB, err.Error())
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	stmt, err := tx.Prepare(sqliteInsertQuery)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s", errCouldntWriteToSQLiteDB, err.Error())
+	}
+	defer stmt.Close()
+
+	var numInserted uint
+	for _, msg := range messages {
+		headers := make([]t.SerializableHeader, len(msg.Headers))
+		for i, h := range msg.Headers {
+			headers[i] = h.ToSerializable()
+		}
+
+		headersJSON, err := json.Marshal(headers)
+		if err != nil {
+			return 0, fmt.Errorf("%w: %s", errCouldntSerializeHeaders, err.Error())
+		}
+
+		var value *string
+		if len(msg.Value) > 0 {
+			valueStr := getValueColumn(msg)
+			value = &valueStr
+		}
+
+		var decodeErr *string
+		if msg.DecodeErr != nil {
+			errStr := msg.DecodeErr.Error()
+			decodeErr = &errStr
+		}
+
+		result, err := stmt.Exec(
+			topic,
+			msg.Partition,
+			msg.Offset,
+			msg.Metadata.Timestamp.UTC().Format(sqliteTimestampLayout),
+			msg.Key,
+			string(headersJSON),
+			value,
+			decodeErr,
+		)
+		if err != nil {
+			return 0, fmt.Errorf("%w: %s", errCouldntWriteToSQLiteDB, err.Error())
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err == nil && rowsAffected > 0 {
+			numInserted++
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s", errCouldntWriteToSQLiteDB, err.Error())
+	}
+
+	return numInserted, nil
+}
+
+func (sw *sqliteWriter) close() error {
+	return sw.db.Close()
+}
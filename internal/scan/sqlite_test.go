@@ -0,0 +1,91 @@
+package scan
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSQLiteWriterWritesMessages(t *testing.T) {
+	// GIVEN
+	dbPath := filepath.Join(t.TempDir(), "scan.db")
+	sw, err := newSQLiteWriter(dbPath)
+	require.NoError(t, err)
+	defer sw.close()
+
+	// WHEN
+	numInserted, err := sw.write("orders", getTestMessages())
+
+	// THEN
+	require.NoError(t, err)
+	assert.Equal(t, uint(2), numInserted)
+
+	rows, err := sw.db.Query(`
+SELECT partition, offset, timestamp, key, headers, value, decode_error
+FROM messages
+WHERE topic = 'orders'
+ORDER BY partition, offset`)
+	require.NoError(t, err)
+	defer rows.Close()
+
+	type row struct {
+		partition   int
+		offset      int
+		timestamp   string
+		key         string
+		headers     string
+		value       *string
+		decodeError *string
+	}
+
+	var got []row
+	for rows.Next() {
+		var r row
+		require.NoError(t, rows.Scan(&r.partition, &r.offset, &r.timestamp, &r.key, &r.headers, &r.value, &r.decodeError))
+		got = append(got, r)
+	}
+	require.NoError(t, rows.Err())
+
+	value := `{"id":1,"status":"FAILED"}`
+	expected := []row{
+		{0, 10, "2025-04-06T11:18:03.000Z", "order-1", `[{"key":"tenant","value":"acme"}]`, &value, nil},
+		{1, 20, "2025-04-06T11:18:03.000Z", "order-2", `[]`, nil, nil},
+	}
+	assert.Equal(t, expected, got)
+
+	var status string
+	err = sw.db.QueryRow(`SELECT value ->> '$.status' FROM messages WHERE key = 'order-1'`).Scan(&status)
+	require.NoError(t, err)
+	assert.Equal(t, "FAILED", status)
+}
+
+func TestSQLiteWriterSkipsDuplicatesAcrossRuns(t *testing.T) {
+	// GIVEN
+	dbPath := filepath.Join(t.TempDir(), "scan.db")
+	sw, err := newSQLiteWriter(dbPath)
+	require.NoError(t, err)
+	_, err = sw.write("orders", getTestMessages())
+	require.NoError(t, err)
+	require.NoError(t, sw.close())
+
+	// WHEN
+	sw, err = newSQLiteWriter(dbPath)
+	require.NoError(t, err)
+	defer sw.close()
+
+	messages := getTestMessages()
+	numInsertedSameTopic, errSameTopic := sw.write("orders", messages)
+	numInsertedOtherTopic, errOtherTopic := sw.write("refunds", messages[:1])
+
+	// THEN
+	require.NoError(t, errSameTopic)
+	require.NoError(t, errOtherTopic)
+	assert.Equal(t, uint(0), numInsertedSameTopic)
+	assert.Equal(t, uint(1), numInsertedOtherTopic)
+
+	var count int
+	require.NoError(t, sw.db.QueryRow(`SELECT count(*) FROM messages`).Scan(&count))
+	assert.Equal(t, 3, count)
+}
@@ -0,0 +1,450 @@
+package scan
+
+import (
+	"container/heap"
+	"fmt"
+	"math"
+	"math/bits"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/axiomhq/hyperloglog"
+	t "github.com/dhth/kplay/internal/types"
+	"github.com/dhth/kplay/internal/utils"
+)
+
+const (
+	// number of keys tracked when looking for the most frequent ones; keys
+	// with counts well above numMessages/statsTopKeysCapacity are guaranteed
+	// to be tracked
+	statsTopKeysCapacity = 1000
+	statsNumTopKeys      = 10
+	statsMaxRateBuckets  = 30
+)
+
+// bucket widths used for the message rate, from finest to coarsest
+var statsRateBucketWidths = []time.Duration{
+	time.Second,
+	5 * time.Second,
+	10 * time.Second,
+	30 * time.Second,
+	time.Minute,
+	5 * time.Minute,
+	10 * time.Minute,
+	30 * time.Minute,
+	time.Hour,
+	3 * time.Hour,
+	6 * time.Hour,
+	12 * time.Hour,
+	24 * time.Hour,
+	7 * 24 * time.Hour,
+	30 * 24 * time.Hour,
+	365 * 24 * time.Hour,
+}
+
+// topicStats computes statistics for the messages seen during a scan using
+// structures whose size doesn't grow with the number of messages (apart from
+// per-partition counts).
+type topicStats struct {
+	numMessages     uint
+	numTombstones   uint
+	numDecodeErrors uint
+	decoding        bool
+	keys            *hyperloglog.Sketch
+	topKeys         *topKeysTracker
+	partitions      map[int32]uint
+	valueSizes      *sizeHistogram
+	rate            *rateHistogram
+}
+
+func newTopicStats(decoding bool) *topicStats {
+	return &topicStats{
+		decoding:   decoding,
+		keys:       hyperloglog.New14(),
+		topKeys:    newTopKeysTracker(statsTopKeysCapacity),
+		partitions: make(map[int32]uint),
+		valueSizes: &sizeHistogram{},
+		rate:       newRateHistogram(statsMaxRateBuckets),
+	}
+}
+
+func (st *topicStats) add(msg t.Message) {
+	st.numMessages++
+
+	if len(msg.Value) == 0 {
+		st.numTombstones++
+	}
+
+	if msg.DecodeErr != nil {
+		st.numDecodeErrors++
+	}
+
+	st.keys.Insert([]byte(msg.Key))
+	st.topKeys.add(msg.Key)
+	st.partitions[msg.Partition]++
+	st.valueSizes.add(uint64(msg.Metadata.ValueSize))
+	st.rate.add(msg.Metadata.Timestamp)
+}
+
+type statsReport struct {
+	NumMessages     uint             `json:"num_messages"`
+	KeyCardinality  uint64           `json:"key_cardinality_estimate"`
+	TopKeys         []keyCount       `json:"top_keys"`
+	Partitions      []partitionCount `json:"partitions"`
+	PartitionSkew   float64          `json:"partition_skew"`
+	ValueSizes      valueSizeStats   `json:"value_size_bytes"`
+	TombstoneRatio  float64          `json:"tombstone_ratio"`
+	DecodeErrorRate *float64         `json:"decode_error_rate"`
+	Rate            rateStats        `json:"rate"`
+}
+
+type keyCount struct {
+	Key string `json:"key"`
+	// Count is an upper bound on the number of messages with the key, which
+	// exceeds the actual number by at most MaxOvercount
+	Count        uint `json:"count"`
+	MaxOvercount uint `json:"max_overcount"`
+}
+
+type partitionCount struct {
+	Partition int32 `json:"partition"`
+	Count     uint  `json:"count"`
+}
+
+type valueSizeStats struct {
+	Min  uint64  `json:"min"`
+	Mean float64 `json:"mean"`
+	P50  uint64  `json:"p50"`
+	P90  uint64  `json:"p90"`
+	P99  uint64  `json:"p99"`
+	Max  uint64  `json:"max"`
+}
+
+type rateStats struct {
+	BucketSeconds     float64      `json:"bucket_seconds"`
+	MessagesPerSecond float64      `json:"messages_per_second"`
+	Buckets           []rateBucket `json:"buckets"`
+}
+
+type rateBucket struct {
+	Start time.Time `json:"start"`
+	Count uint      `json:"count"`
+}
+
+func (st *topicStats) report() statsReport {
+	report := statsReport{
+		NumMessages:    st.numMessages,
+		KeyCardinality: st.keys.Estimate(),
+		TopKeys:        st.topKeys.top(statsNumTopKeys),
+		ValueSizes:     st.valueSizes.stats(),
+		Rate:           st.rate.stats(),
+	}
+
+	if st.numMessages == 0 {
+		return report
+	}
+
+	report.TombstoneRatio = float64(st.numTombstones) / float64(st.numMessages)
+	if st.decoding {
+		decodeErrorRate := float64(st.numDecodeErrors) / float64(st.numMessages)
+		report.DecodeErrorRate = &decodeErrorRate
+	}
+
+	var maxCount uint
+	for partition, count := range st.partitions {
+		report.Partitions = append(report.Partitions, partitionCount{Partition: partition, Count: count})
+		maxCount = max(maxCount, count)
+	}
+	slices.SortFunc(report.Partitions, func(a, b partitionCount) int {
+		return int(a.Partition) - int(b.Partition)
+	})
+
+	// skew is the ratio of the largest partition count to the mean count; 1
+	// means messages are spread evenly across the partitions seen
+	meanCount := float64(st.numMessages) / float64(len(st.partitions))
+	report.PartitionSkew = float64(maxCount) / meanCount
+
+	return report
+}
+
+func (r statsReport) Display() string {
+	var sb strings.Builder
+
+	sb.WriteString("Topic Statistics:\n")
+	fmt.Fprintf(&sb, "  messages                %d\n", r.NumMessages)
+	fmt.Fprintf(&sb, "  distinct keys (approx)  %d\n", r.KeyCardinality)
+	fmt.Fprintf(&sb, "  tombstones              %.2f%%\n", r.TombstoneRatio*100)
+
+	decodeErrorRate := t.NotProvided
+	if r.DecodeErrorRate != nil {
+		decodeErrorRate = fmt.Sprintf("%.2f%%", *r.DecodeErrorRate*100)
+	}
+	fmt.Fprintf(&sb, "  decode errors           %s\n", decodeErrorRate)
+
+	fmt.Fprintf(&sb, "  value sizes             min: %s, mean: %s, p50: %s, p90: %s, p99: %s, max: %s\n",
+		utils.HumanReadableBytes(r.ValueSizes.Min),
+		utils.HumanReadableBytes(uint64(math.Round(r.ValueSizes.Mean))),
+		utils.HumanReadableBytes(r.ValueSizes.P50),
+		utils.HumanReadableBytes(r.ValueSizes.P90),
+		utils.HumanReadableBytes(r.ValueSizes.P99),
+		utils.HumanReadableBytes(r.ValueSizes.Max),
+	)
+
+	fmt.Fprintf(&sb, "\n  Partitions (skew: %.2f)\n", r.PartitionSkew)
+	for _, p := range r.Partitions {
+		fmt.Fprintf(&sb, "    %-6d  %-10d  %5.1f%%\n", p.Partition, p.Count, float64(p.Count)*100/float64(r.NumMessages))
+	}
+
+	sb.WriteString("\n  Top keys\n")
+	for _, k := range r.TopKeys {
+		count := fmt.Sprintf("%d", k.Count)
+		if k.MaxOvercount > 0 {
+			count = fmt.Sprintf("%d (±%d)", k.Count, k.MaxOvercount)
+		}
+		fmt.Fprintf(&sb, "    %-16s  %s\n", count, k.Key)
+	}
+
+	fmt.Fprintf(&sb, "\n  Message rate (%.2f/s on average; per %s)\n", r.Rate.MessagesPerSecond, time.Duration(r.Rate.BucketSeconds*float64(time.Second)))
+	for _, b := range r.Rate.Buckets {
+		fmt.Fprintf(&sb, "    %s  %-10d  %.2f/s\n", b.Start.Format(time.RFC3339), b.Count, float64(b.Count)/r.Rate.BucketSeconds)
+	}
+
+	return sb.String()
+}
+
+// topKeysTracker implements the space-saving algorithm: it tracks a fixed
+// number of keys, and when a new key is seen while at capacity, it replaces
+// the key with the lowest count, inheriting that count as its error.
+type topKeysTracker struct {
+	capacity int
+	items    map[string]*topKeyItem
+	heap     topKeyHeap
+}
+
+type topKeyItem struct {
+	key   string
+	count uint
+	err   uint
+	index int
+}
+
+type topKeyHeap []*topKeyItem
+
+func (h topKeyHeap) Len() int           { return len(h) }
+func (h topKeyHeap) Less(i, j int) bool { return h[i].count < h[j].count }
+func (h topKeyHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *topKeyHeap) Push(x any) {
+	item, _ := x.(*topKeyItem)
+	item.index = len(*h)
+	*h = append(*h, item)
+}
+
+func (h *topKeyHeap) Pop() any {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	*h = old[:n-1]
+	return item
+}
+
+func newTopKeysTracker(capacity int) *topKeysTracker {
+	return &topKeysTracker{
+		capacity: capacity,
+		items:    make(map[string]*topKeyItem, capacity),
+	}
+}
+
+func (tr *topKeysTracker) add(key string) {
+	if item, ok := tr.items[key]; ok {
+		item.count++
+		heap.Fix(&tr.heap, item.index)
+		return
+	}
+
+	if len(tr.heap) < tr.capacity {
+		item := &topKeyItem{key: key, count: 1}
+		tr.items[key] = item
+		heap.Push(&tr.heap, item)
+		return
+	}
+
+	item := tr.heap[0]
+	delete(tr.items, item.key)
+	item.key = key
+	item.err = item.count
+	item.count++
+	tr.items[key] = item
+	heap.Fix(&tr.heap, 0)
+}
+
+func (tr *topKeysTracker) top(n int) []keyCount {
+	items := slices.Clone(tr.heap)
+	slices.SortFunc(items, func(a, b *topKeyItem) int {
+		if a.count != b.count {
+			return int(b.count) - int(a.count)
+		}
+		return strings.Compare(a.key, b.key)
+	})
+
+	result := make([]keyCount, 0, min(n, len(items)))
+	for _, item := range items[:min(n, len(items))] {
+		result = append(result, keyCount{Key: item.key, Count: item.count, MaxOvercount: item.err})
+	}
+
+	return result
+}
+
+// sizeHistogram approximates the distribution of sizes using logarithmic
+// buckets; sizes below 16 get their own buckets, and every power of two above
+// that is split into 16 buckets, which keeps the relative error of
+// percentiles under 6.25%.
+type sizeHistogram struct {
+	counts []uint
+	total  uint
+	sum    uint64
+	min    uint64
+	max    uint64
+}
+
+const sizeHistogramSubBuckets = 16
+
+func sizeBucket(size uint64) int {
+	if size < sizeHistogramSubBuckets {
+		return int(size)
+	}
+
+	exponent := bits.Len64(size) - 1
+	mantissa := (size >> (exponent - 4)) & (sizeHistogramSubBuckets - 1)
+
+	return sizeHistogramSubBuckets + (exponent-4)*sizeHistogramSubBuckets + int(mantissa)
+}
+
+func sizeBucketBounds(bucket int) (uint64, uint64) {
+	if bucket < sizeHistogramSubBuckets {
+		return uint64(bucket), uint64(bucket)
+	}
+
+	exponent := (bucket-sizeHistogramSubBuckets)/sizeHistogramSubBuckets + 4
+	mantissa := uint64((bucket - sizeHistogramSubBuckets) % sizeHistogramSubBuckets)
+	width := uint64(1) << (exponent - 4)
+	lower := (sizeHistogramSubBuckets + mantissa) * width
+
+	return lower, lower + width - 1
+}
+
+func (h *sizeHistogram) add(size uint64) {
+	bucket := sizeBucket(size)
+	if bucket >= len(h.counts) {
+		h.counts = append(h.counts, make([]uint, bucket-len(h.counts)+1)...)
+	}
+	h.counts[bucket]++
+
+	if h.total == 0 || size < h.min {
+		h.min = size
+	}
+	h.max = max(h.max, size)
+	h.total++
+	h.sum += size
+}
+
+func (h *sizeHistogram) percentile(p float64) uint64 {
+	if h.total == 0 {
+		return 0
+	}
+
+	rank := uint(math.Ceil(p * float64(h.total)))
+	var seen uint
+	for bucket, count := range h.counts {
+		seen += count
+		if seen >= rank && count > 0 {
+			lower, upper := sizeBucketBounds(bucket)
+			return min(max(lower+(upper-lower)/2, h.min), h.max)
+		}
+	}
+
+	return h.max
+}
+
+func (h *sizeHistogram) stats() valueSizeStats {
+	if h.total == 0 {
+		return valueSizeStats{}
+	}
+
+	return valueSizeStats{
+		Min:  h.min,
+		Mean: float64(h.sum) / float64(h.total),
+		P50:  h.percentile(0.5),
+		P90:  h.percentile(0.9),
+		P99:  h.percentile(0.99),
+		Max:  h.max,
+	}
+}
+
+// rateHistogram counts messages per time bucket, switching to coarser buckets
+// whenever the number of buckets would exceed its limit.
+type rateHistogram struct {
+	maxBuckets int
+	widthIndex int
+	counts     map[int64]uint
+	first      time.Time
+	last       time.Time
+	total      uint
+}
+
+func newRateHistogram(maxBuckets int) *rateHistogram {
+	return &rateHistogram{
+		maxBuckets: maxBuckets,
+		counts:     make(map[int64]uint),
+	}
+}
+
+func (h *rateHistogram) width() time.Duration {
+	return statsRateBucketWidths[h.widthIndex]
+}
+
+func (h *rateHistogram) add(timestamp time.Time) {
+	if h.total == 0 || timestamp.Before(h.first) {
+		h.first = timestamp
+	}
+	if timestamp.After(h.last) {
+		h.last = timestamp
+	}
+	h.total++
+
+	h.counts[timestamp.Truncate(h.width()).Unix()]++
+
+	for len(h.counts) > h.maxBuckets && h.widthIndex < len(statsRateBucketWidths)-1 {
+		h.widthIndex++
+		coarser := make(map[int64]uint)
+		for start, count := range h.counts {
+			coarser[time.Unix(start, 0).Truncate(h.width()).Unix()] += count
+		}
+		h.counts = coarser
+	}
+}
+
+func (h *rateHistogram) stats() rateStats {
+	stats := rateStats{
+		BucketSeconds: h.width().Seconds(),
+		Buckets:       make([]rateBucket, 0, len(h.counts)),
+	}
+
+	for start, count := range h.counts {
+		stats.Buckets = append(stats.Buckets, rateBucket{Start: time.Unix(start, 0).UTC(), Count: count})
+	}
+	slices.SortFunc(stats.Buckets, func(a, b rateBucket) int {
+		return a.Start.Compare(b.Start)
+	})
+
+	if elapsed := h.last.Sub(h.first).Seconds(); elapsed > 0 {
+		stats.MessagesPerSecond = float64(h.total) / elapsed
+	}
+
+	return stats
+}
@@ -0,0 +1,146 @@
+package scan
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	t "github.com/dhth/kplay/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTopicStatsReport(t *testing.T) {
+	// GIVEN
+	stats := newTopicStats(true)
+	start := time.Date(2025, 4, 6, 11, 0, 0, 0, time.UTC)
+	for i := range 100 {
+		msg := getStatsTestMessage(fmt.Sprintf("key-%d", i%10), int32(i%4), 100, start.Add(time.Duration(i)*time.Second))
+		if i < 40 {
+			msg.Partition = 0
+		}
+		if i%10 == 0 {
+			// tombstones can have nil as well as empty values
+			msg.Value = nil
+			if i%20 == 0 {
+				msg.Value = []byte{}
+			}
+			msg.Metadata.ValueSize = 0
+		}
+		if i%25 == 0 {
+			msg.DecodeErr = errors.New("couldn't decode value")
+		}
+		stats.add(msg)
+	}
+
+	// WHEN
+	got := stats.report()
+
+	// THEN
+	assert.Equal(t, uint(100), got.NumMessages)
+	assert.Equal(t, uint64(10), got.KeyCardinality)
+	require.Len(t, got.TopKeys, 10)
+	assert.Equal(t, keyCount{Key: "key-0", Count: 10}, got.TopKeys[0])
+	assert.Equal(t, []partitionCount{{0, 55}, {1, 15}, {2, 15}, {3, 15}}, got.Partitions)
+	assert.InDelta(t, 2.2, got.PartitionSkew, 0.001)
+	assert.InDelta(t, 0.1, got.TombstoneRatio, 0.001)
+	require.NotNil(t, got.DecodeErrorRate)
+	assert.InDelta(t, 0.04, *got.DecodeErrorRate, 0.001)
+	assert.Equal(t, uint64(0), got.ValueSizes.Min)
+	assert.Equal(t, uint64(100), got.ValueSizes.P50)
+	assert.Equal(t, uint64(100), got.ValueSizes.Max)
+	assert.InDelta(t, 90, got.ValueSizes.Mean, 0.001)
+	assert.InDelta(t, 100.0/99.0, got.Rate.MessagesPerSecond, 0.001)
+	assert.LessOrEqual(t, len(got.Rate.Buckets), statsMaxRateBuckets)
+}
+
+func TestTopicStatsReportWithoutDecoding(t *testing.T) {
+	// GIVEN
+	stats := newTopicStats(false)
+	stats.add(getStatsTestMessage("key", 0, 10, time.Now()))
+
+	// WHEN
+	got := stats.report()
+
+	// THEN
+	assert.Nil(t, got.DecodeErrorRate)
+	assert.InDelta(t, 1.0, got.PartitionSkew, 0.001)
+}
+
+func TestTopKeysTrackerKeepsFrequentKeys(t *testing.T) {
+	// GIVEN
+	tracker := newTopKeysTracker(10)
+
+	// WHEN
+	for i := range 1000 {
+		tracker.add("frequent")
+		if i%2 == 0 {
+			tracker.add("common")
+		}
+		tracker.add(fmt.Sprintf("rare-%d", i))
+	}
+
+	// THEN
+	got := tracker.top(2)
+	require.Len(t, got, 2)
+	assert.Equal(t, "frequent", got[0].Key)
+	assert.GreaterOrEqual(t, got[0].Count, uint(1000))
+	assert.LessOrEqual(t, got[0].Count-got[0].MaxOvercount, uint(1000))
+	assert.Equal(t, "common", got[1].Key)
+}
+
+func TestSizeHistogramPercentiles(t *testing.T) {
+	// GIVEN
+	histogram := &sizeHistogram{}
+
+	// WHEN
+	for size := range uint64(10_000) {
+		histogram.add(size + 1)
+	}
+
+	// THEN
+	got := histogram.stats()
+	assert.Equal(t, uint64(1), got.Min)
+	assert.Equal(t, uint64(10_000), got.Max)
+	assert.InEpsilon(t, 5_000, got.P50, 0.0625)
+	assert.InEpsilon(t, 9_000, got.P90, 0.0625)
+	assert.InEpsilon(t, 9_900, got.P99, 0.0625)
+}
+
+func TestSizeBucketBoundsContainSizes(t *testing.T) {
+	for _, size := range []uint64{0, 1, 15, 16, 17, 31, 32, 100, 1_000, 123_456, 1 << 40} {
+		lower, upper := sizeBucketBounds(sizeBucket(size))
+		assert.LessOrEqual(t, lower, size, "size: %d", size)
+		assert.GreaterOrEqual(t, upper, size, "size: %d", size)
+	}
+}
+
+func TestRateHistogramSwitchesToCoarserBuckets(t *testing.T) {
+	// GIVEN
+	histogram := newRateHistogram(10)
+	start := time.Date(2025, 4, 6, 11, 0, 0, 0, time.UTC)
+
+	// WHEN
+	for i := range 120 {
+		histogram.add(start.Add(time.Duration(i) * time.Minute))
+	}
+
+	// THEN
+	got := histogram.stats()
+	assert.InDelta(t, (30 * time.Minute).Seconds(), got.BucketSeconds, 0)
+	require.Len(t, got.Buckets, 4)
+	assert.Equal(t, rateBucket{Start: start, Count: 30}, got.Buckets[0])
+}
+
+func getStatsTestMessage(key string, partition int32, size int, timestamp time.Time) t.Message {
+	return t.Message{
+		Key:       key,
+		Partition: partition,
+		Value:     make([]byte, size),
+		Metadata: t.Metadata{
+			Timestamp: timestamp,
+			ValueSize: size,
+		},
+	}
+}
@@ -0,0 +1,245 @@
+package scan
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	s "github.com/dhth/kplay/internal/serde"
+	t "github.com/dhth/kplay/internal/types"
+)
+
+type OutputFormat uint
+
+const (
+	CSVFormat OutputFormat = iota
+	TSVFormat
+	JSONLFormat
+)
+
+func ValidateOutputFormatValue(value string) (OutputFormat, error) {
+	switch value {
+	case "csv":
+		return CSVFormat, nil
+	case "tsv":
+		return TSVFormat, nil
+	case "jsonl":
+		return JSONLFormat, nil
+	default:
+		return CSVFormat, fmt.Errorf("output format is incorrect; possible values: [csv, tsv, jsonl]")
+	}
+}
+
+func (f OutputFormat) String() string {
+	switch f {
+	case CSVFormat:
+		return "csv"
+	case TSVFormat:
+		return "tsv"
+	case JSONLFormat:
+		return "jsonl"
+	default:
+		return "unknown"
+	}
+}
+
+func (f OutputFormat) extension() string {
+	return f.String()
+}
+
+// resultColumns determines the optional columns written to CSV/TSV results;
+// JSONL results always contain every message's full details.
+type resultColumns struct {
+	decodeErrors    bool
+	keyDecodeErrors bool
+	violations      bool
+	values          bool
+	headers         []string
+}
+
+// names returns the header of CSV/TSV results.
+func (c resultColumns) names() []string {
+	names := []string{"partition", "offset", "timestamp", "key", "tombstone"}
+	if c.decodeErrors {
+		names = append(names, "decode_error")
+	}
+	if c.keyDecodeErrors {
+		names = append(names, "key_decode_error")
+	}
+	if c.violations {
+		names = append(names, "violations")
+	}
+	for _, key := range c.headers {
+		names = append(names, fmt.Sprintf("header:%s", key))
+	}
+	if c.values {
+		names = append(names, "value")
+	}
+
+	return names
+}
+
+type messageWriter struct {
+	file        *os.File
+	writer      *bufio.Writer
+	csvWriter   *csv.Writer
+	jsonEncoder *json.Encoder
+	format      OutputFormat
+	columns     resultColumns
+}
+
+// newMessageWriter creates a writer for scan results; when appending to an
+// existing file (eg. when resuming a scan), the CSV/TSV header isn't written
+// again.
+func newMessageWriter(filePath string, format OutputFormat, columns resultColumns, appendToFile bool) (*messageWriter, error) {
+	var file *os.File
+	var err error
+	if appendToFile {
+		file, err = os.OpenFile(filePath, os.O_WRONLY|os.O_APPEND, 0)
+	} else {
+		file, err = os.Create(filePath)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open output file: %w", err)
+	}
+
+	rw := &messageWriter{
+		file:    file,
+		writer:  bufio.NewWriter(file),
+		format:  format,
+		columns: columns,
+	}
+
+	if format == JSONLFormat {
+		rw.jsonEncoder = json.NewEncoder(rw.writer)
+		rw.jsonEncoder.SetEscapeHTML(false)
+		return rw, nil
+	}
+
+	rw.csvWriter = csv.NewWriter(rw.writer)
+	if format == TSVFormat {
+		rw.csvWriter.Comma = '\t'
+	}
+
+	if appendToFile {
+		return rw, nil
+	}
+
+	err = rw.csvWriter.Write(columns.names())
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to write CSV header: %w", err)
+	}
+
+	return rw, nil
+}
+
+func (rw *messageWriter) writeMsg(msg t.Message) error {
+	if rw.format == JSONLFormat {
+		return rw.jsonEncoder.Encode(msg.ToSerializable())
+	}
+
+	return rw.writeCSV(msg)
+}
+
+func (rw *messageWriter) writeCSV(msg t.Message) error {
+	tombstone := "false"
+	if len(msg.Value) == 0 {
+		tombstone = "true"
+	}
+
+	row := []string{
+		fmt.Sprintf("%d", msg.Partition),
+		fmt.Sprintf("%d", msg.Offset),
+		msg.Metadata.Timestamp.Format(time.RFC3339),
+		msg.Key,
+		tombstone,
+	}
+
+	if rw.columns.decodeErrors {
+		var decodeErrStr string
+		if msg.DecodeErr != nil {
+			decodeErrStr = msg.DecodeErr.Error()
+		}
+		row = append(row, decodeErrStr)
+	}
+
+	if rw.columns.keyDecodeErrors {
+		var keyDecodeErrStr string
+		if msg.KeyDecodeErr != nil {
+			keyDecodeErrStr = msg.KeyDecodeErr.Error()
+		}
+		row = append(row, keyDecodeErrStr)
+	}
+
+	if rw.columns.violations {
+		row = append(row, strings.Join(msg.Violations, "; "))
+	}
+
+	for _, key := range rw.columns.headers {
+		var values []string
+		for _, h := range msg.Headers {
+			if h.Key == key {
+				values = append(values, h.Value)
+			}
+		}
+		row = append(row, strings.Join(values, "; "))
+	}
+
+	if rw.columns.values {
+		row = append(row, getValueColumn(msg))
+	}
+
+	return rw.csvWriter.Write(row)
+}
+
+// getValueColumn returns a message's value as a single cell; decoded JSON
+// values are compacted so that each message fits on one line, and binary
+// values (eg. ones that couldn't be decoded) are written as hex, prefixed with
+// "0x".
+func getValueColumn(msg t.Message) string {
+	if len(msg.Value) == 0 {
+		return ""
+	}
+
+	if msg.DecodeErr == nil {
+		if compacted, err := s.CompactJSON(msg.Value); err == nil {
+			return string(compacted)
+		}
+	}
+
+	if msg.IsBinary() {
+		return "0x" + hex.EncodeToString(msg.Value)
+	}
+
+	return string(msg.Value)
+}
+
+func (rw *messageWriter) flush() error {
+	if rw.csvWriter != nil {
+		rw.csvWriter.Flush()
+		if err := rw.csvWriter.Error(); err != nil {
+			return err
+		}
+	}
+
+	return rw.writer.Flush()
+}
+
+func (rw *messageWriter) close() error {
+	if rw.csvWriter != nil {
+		rw.csvWriter.Flush()
+	}
+	if rw.writer != nil {
+		rw.writer.Flush()
+	}
+	if rw.file != nil {
+		return rw.file.Close()
+	}
+	return nil
+}
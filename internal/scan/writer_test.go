@@ -0,0 +1,174 @@
+package scan
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	t "github.com/dhth/kplay/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+func getTestMessages() []t.Message {
+	config := t.Config{Encoding: t.JSON}
+	timestamp := time.Date(2025, 4, 6, 11, 18, 3, 0, time.UTC)
+
+	return []t.Message{
+		t.GetMessageFromRecord(kgo.Record{
+			Key:       []byte("order-1"),
+			Value:     []byte(`{"id": 1, "status": "FAILED"}`),
+			Headers:   []kgo.RecordHeader{{Key: "tenant", Value: []byte("acme")}},
+			Timestamp: timestamp,
+			Partition: 0,
+			Offset:    10,
+		}, config, true),
+		t.GetMessageFromRecord(kgo.Record{
+			Key:       []byte("order-2"),
+			Timestamp: timestamp,
+			Partition: 1,
+			Offset:    20,
+		}, config, true),
+	}
+}
+
+func writeTestMessages(t *testing.T, format OutputFormat, columns resultColumns) string {
+	t.Helper()
+
+	filePath := filepath.Join(t.TempDir(), "results")
+	rw, err := newMessageWriter(filePath, format, columns, false)
+	require.NoError(t, err)
+
+	for _, msg := range getTestMessages() {
+		require.NoError(t, rw.writeMsg(msg))
+	}
+	require.NoError(t, rw.close())
+
+	contents, err := os.ReadFile(filePath)
+	require.NoError(t, err)
+
+	return string(contents)
+}
+
+func TestMessageWriterWritesCSVAndTSV(t *testing.T) {
+	testCases := []struct {
+		name     string
+		format   OutputFormat
+		columns  resultColumns
+		expected string
+	}{
+		{
+			name:   "csv without values",
+			format: CSVFormat,
+			columns: resultColumns{
+				decodeErrors: true,
+			},
+			expected: `partition,offset,timestamp,key,tombstone,decode_error
+0,10,2025-04-06T11:18:03Z,order-1,false,
+1,20,2025-04-06T11:18:03Z,order-2,true,
+`,
+		},
+		{
+			name:   "csv with headers and values",
+			format: CSVFormat,
+			columns: resultColumns{
+				values:  true,
+				headers: []string{"tenant"},
+			},
+			expected: `partition,offset,timestamp,key,tombstone,header:tenant,value
+0,10,2025-04-06T11:18:03Z,order-1,false,acme,"{""id"":1,""status"":""FAILED""}"
+1,20,2025-04-06T11:18:03Z,order-2,true,,
+`,
+		},
+		{
+			name:   "tsv with values",
+			format: TSVFormat,
+			columns: resultColumns{
+				values: true,
+			},
+			expected: "partition\toffset\ttimestamp\tkey\ttombstone\tvalue\n" +
+				"0\t10\t2025-04-06T11:18:03Z\torder-1\tfalse\t\"{\"\"id\"\":1,\"\"status\"\":\"\"FAILED\"\"}\"\n" +
+				"1\t20\t2025-04-06T11:18:03Z\torder-2\ttrue\t\n",
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			got := writeTestMessages(t, tt.format, tt.columns)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func getTestBinaryMessage() t.Message {
+	return t.GetMessageFromRecord(kgo.Record{
+		Key:       []byte("order-3"),
+		Value:     []byte{0xff, 0xfe, 0x0a, 0x00, 0x01},
+		Timestamp: time.Date(2025, 4, 6, 11, 18, 3, 0, time.UTC),
+		Partition: 2,
+		Offset:    30,
+	}, t.Config{Encoding: t.JSON}, true)
+}
+
+func TestMessageWriterWritesBinaryValuesOnASingleLine(t *testing.T) {
+	// GIVEN
+	msg := getTestBinaryMessage()
+	require.Error(t, msg.DecodeErr)
+	filePath := filepath.Join(t.TempDir(), "results")
+	rw, err := newMessageWriter(filePath, CSVFormat, resultColumns{values: true}, false)
+	require.NoError(t, err)
+
+	// WHEN
+	require.NoError(t, rw.writeMsg(msg))
+	require.NoError(t, rw.close())
+
+	// THEN
+	contents, err := os.ReadFile(filePath)
+	require.NoError(t, err)
+	expected := `partition,offset,timestamp,key,tombstone,value
+2,30,2025-04-06T11:18:03Z,order-3,false,0xfffe0a0001
+`
+	assert.Equal(t, expected, string(contents))
+}
+
+func TestMessageWriterWritesJSONL(t *testing.T) {
+	// GIVEN
+	// WHEN
+	got := writeTestMessages(t, JSONLFormat, resultColumns{values: true})
+
+	// THEN
+	lines := strings.Split(strings.TrimSuffix(got, "\n"), "\n")
+	require.Len(t, lines, 2)
+
+	var first map[string]any
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &first))
+	assert.Equal(t, "order-1", first["key"])
+	assert.InDelta(t, 10, first["offset"], 0)
+	assert.Equal(t, "{\n  \"id\": 1,\n  \"status\": \"FAILED\"\n}", first["value"])
+	assert.Equal(t, []any{map[string]any{"key": "tenant", "value": "acme"}}, first["headers"])
+
+	var second map[string]any
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &second))
+	assert.Nil(t, second["value"])
+}
+
+func TestSQLiteOutputDecodesValuesWithoutAddingAValueColumn(t *testing.T) {
+	// GIVEN
+	scanner := getTestScanner(1, nil)
+	scanner.behaviours.Format = CSVFormat
+	scanner.behaviours.SQLitePath = "orders.db"
+	scanner.behaviours.Decode = true
+
+	// WHEN
+	decode := scanner.needsDecoding()
+	columns := scanner.getResultColumns(decode)
+
+	// THEN
+	assert.True(t, decode)
+	assert.False(t, columns.values)
+	assert.Equal(t, []string{"partition", "offset", "timestamp", "key", "tombstone", "decode_error"}, columns.names())
+}
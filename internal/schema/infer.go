@@ -0,0 +1,322 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+const (
+	schemaDraft = "https://json-schema.org/draft/2020-12/schema"
+	// strings with at most this many distinct values are described via an enum
+	enumMaxValues = 10
+	// ... as long as each distinct value has been seen this many times on
+	// average; this prevents identifiers seen in a small sample from being
+	// treated as enums
+	enumMinOccurrencesPerValue = 3
+	maxExamples                = 3
+)
+
+var errValueIsNotJSON = errors.New("value is not valid JSON")
+
+const (
+	typeNull    = "null"
+	typeBoolean = "boolean"
+	typeInteger = "integer"
+	typeNumber  = "number"
+	typeString  = "string"
+	typeArray   = "array"
+	typeObject  = "object"
+)
+
+var typeOrder = []string{typeObject, typeArray, typeString, typeInteger, typeNumber, typeBoolean, typeNull}
+
+// Inferrer merges JSON values into a JSON Schema that describes all of them.
+type Inferrer struct {
+	root      *node
+	numValues uint
+}
+
+func New() *Inferrer {
+	return &Inferrer{root: newNode()}
+}
+
+// Add merges a JSON document into the inferred schema.
+func (i *Inferrer) Add(data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return fmt.Errorf("%w: %s", errValueIsNotJSON, err.Error())
+	}
+
+	if _, err := decoder.Token(); !errors.Is(err, io.EOF) {
+		return fmt.Errorf("%w: unexpected data after the top-level value", errValueIsNotJSON)
+	}
+
+	i.root.add(value)
+	i.numValues++
+
+	return nil
+}
+
+func (i *Inferrer) NumValues() uint {
+	return i.numValues
+}
+
+// Schema returns the inferred schema as an indented JSON document. Besides
+// types, the schema describes how often each property is present, enums for
+// strings with few distinct values, and example values.
+func (i *Inferrer) Schema(title string) ([]byte, error) {
+	doc := orderedObject{
+		{"$schema", schemaDraft},
+		{"title", title},
+		{"description", fmt.Sprintf("inferred from %d %s", i.numValues, pluralize(i.numValues, "message", "messages"))},
+	}
+	doc = append(doc, i.root.schema()...)
+
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+type node struct {
+	numValues       uint
+	types           map[string]uint
+	numObjects      uint
+	properties      map[string]*node
+	propertyOrder   []string
+	items           *node
+	numStrings      uint
+	strings         map[string]uint
+	stringsOverflow bool
+	examples        []any
+}
+
+func newNode() *node {
+	return &node{
+		types:      make(map[string]uint),
+		properties: make(map[string]*node),
+		strings:    make(map[string]uint),
+	}
+}
+
+func (n *node) add(value any) {
+	n.numValues++
+
+	switch v := value.(type) {
+	case nil:
+		n.types[typeNull]++
+	case bool:
+		n.types[typeBoolean]++
+		n.addExample(v)
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			n.types[typeInteger]++
+		} else {
+			n.types[typeNumber]++
+		}
+		n.addExample(v)
+	case string:
+		n.types[typeString]++
+		n.addString(v)
+		n.addExample(v)
+	case []any:
+		n.types[typeArray]++
+		if n.items == nil {
+			n.items = newNode()
+		}
+		for _, item := range v {
+			n.items.add(item)
+		}
+	case map[string]any:
+		n.types[typeObject]++
+		n.numObjects++
+		// encoding/json doesn't preserve the order of keys, so properties are
+		// sorted to keep the output stable
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+		for _, key := range keys {
+			property, ok := n.properties[key]
+			if !ok {
+				property = newNode()
+				n.properties[key] = property
+				n.propertyOrder = append(n.propertyOrder, key)
+			}
+			property.add(v[key])
+		}
+	}
+}
+
+func (n *node) addString(value string) {
+	n.numStrings++
+	if n.stringsOverflow {
+		return
+	}
+
+	n.strings[value]++
+	if len(n.strings) > enumMaxValues {
+		n.stringsOverflow = true
+		n.strings = nil
+	}
+}
+
+func (n *node) addExample(value any) {
+	if len(n.examples) >= maxExamples {
+		return
+	}
+
+	for _, example := range n.examples {
+		if example == value {
+			return
+		}
+	}
+
+	n.examples = append(n.examples, value)
+}
+
+func (n *node) schema() orderedObject {
+	var types []string
+	for _, typ := range typeOrder {
+		if n.types[typ] == 0 {
+			continue
+		}
+		// integers are numbers as well
+		if typ == typeInteger && n.types[typeNumber] > 0 {
+			continue
+		}
+		types = append(types, typ)
+	}
+
+	var schema orderedObject
+	switch len(types) {
+	case 0:
+	case 1:
+		schema = append(schema, keyValue{"type", types[0]})
+	default:
+		schema = append(schema, keyValue{"type", types})
+	}
+
+	if n.numObjects > 0 && len(n.propertyOrder) > 0 {
+		properties := make(orderedObject, 0, len(n.propertyOrder))
+		var required []string
+		for _, key := range n.propertyOrder {
+			property := n.properties[key]
+			presence := float64(property.numValues) * 100 / float64(n.numObjects)
+			propertySchema := orderedObject{
+				{"description", fmt.Sprintf("present in %s of objects", formatPercentage(presence))},
+			}
+			propertySchema = append(propertySchema, property.schema()...)
+			properties = append(properties, keyValue{key, propertySchema})
+
+			if property.numValues == n.numObjects {
+				required = append(required, key)
+			}
+		}
+
+		schema = append(schema, keyValue{"properties", properties})
+		if len(required) > 0 {
+			schema = append(schema, keyValue{"required", required})
+		}
+	}
+
+	if n.items != nil && n.items.numValues > 0 {
+		schema = append(schema, keyValue{"items", n.items.schema()})
+	}
+
+	if enum := n.enum(); len(enum) > 0 {
+		schema = append(schema, keyValue{"enum", enum})
+	} else if len(n.examples) > 0 {
+		schema = append(schema, keyValue{"examples", n.examples})
+	}
+
+	return schema
+}
+
+// enum returns the distinct values of a node that only holds strings (and
+// possibly nulls), when they're few enough to be considered an enum.
+func (n *node) enum() []any {
+	if n.stringsOverflow || len(n.strings) == 0 {
+		return nil
+	}
+
+	for typ, count := range n.types {
+		if count > 0 && typ != typeString && typ != typeNull {
+			return nil
+		}
+	}
+
+	if n.numStrings < uint(len(n.strings)*enumMinOccurrencesPerValue) {
+		return nil
+	}
+
+	values := make([]string, 0, len(n.strings))
+	for value := range n.strings {
+		values = append(values, value)
+	}
+	slices.Sort(values)
+
+	enum := make([]any, 0, len(values)+1)
+	for _, value := range values {
+		enum = append(enum, value)
+	}
+	if n.types[typeNull] > 0 {
+		enum = append(enum, nil)
+	}
+
+	return enum
+}
+
+type keyValue struct {
+	key   string
+	value any
+}
+
+// orderedObject is a JSON object whose keys are serialized in order.
+type orderedObject []keyValue
+
+func (o orderedObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, kv := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		keyBytes, err := json.Marshal(kv.key)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(keyBytes)
+		buf.WriteByte(':')
+
+		valueBytes, err := json.Marshal(kv.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(valueBytes)
+	}
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
+
+func formatPercentage(value float64) string {
+	formatted := strings.TrimSuffix(strings.TrimRight(fmt.Sprintf("%.2f", value), "0"), ".")
+
+	return formatted + "%"
+}
+
+func pluralize(count uint, singular, plural string) string {
+	if count == 1 {
+		return singular
+	}
+
+	return plural
+}
@@ -0,0 +1,169 @@
+package schema
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	s "github.com/dhth/kplay/internal/serde"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInferrerSchema(t *testing.T) {
+	// GIVEN
+	inferrer := New()
+	values := []string{
+		`{"id": 1, "status": "PAID", "amount": 10, "tags": ["a"], "customer": {"name": "a"}}`,
+		`{"id": 2, "status": "FAILED", "amount": 10.5, "tags": [], "customer": {"name": "b", "vip": true}}`,
+		`{"id": 3, "status": "PAID", "amount": 12, "tags": ["b", "c"], "customer": null}`,
+		`{"id": 4, "status": "PAID", "amount": 7, "note": "call back"}`,
+		`{"id": 5, "status": "FAILED", "amount": 3}`,
+		`{"id": 6, "status": "PAID", "amount": 1}`,
+	}
+
+	// WHEN
+	for _, value := range values {
+		require.NoError(t, inferrer.Add([]byte(value)))
+	}
+	got, err := inferrer.Schema("orders")
+
+	// THEN
+	require.NoError(t, err)
+	expected := `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "title": "orders",
+  "description": "inferred from 6 messages",
+  "type": "object",
+  "properties": {
+    "amount": {
+      "description": "present in 100% of objects",
+      "type": "number",
+      "examples": [
+        10,
+        10.5,
+        12
+      ]
+    },
+    "customer": {
+      "description": "present in 50% of objects",
+      "type": [
+        "object",
+        "null"
+      ],
+      "properties": {
+        "name": {
+          "description": "present in 100% of objects",
+          "type": "string",
+          "examples": [
+            "a",
+            "b"
+          ]
+        },
+        "vip": {
+          "description": "present in 50% of objects",
+          "type": "boolean",
+          "examples": [
+            true
+          ]
+        }
+      },
+      "required": [
+        "name"
+      ]
+    },
+    "id": {
+      "description": "present in 100% of objects",
+      "type": "integer",
+      "examples": [
+        1,
+        2,
+        3
+      ]
+    },
+    "status": {
+      "description": "present in 100% of objects",
+      "type": "string",
+      "enum": [
+        "FAILED",
+        "PAID"
+      ]
+    },
+    "tags": {
+      "description": "present in 50% of objects",
+      "type": "array",
+      "items": {
+        "type": "string",
+        "examples": [
+          "a",
+          "b",
+          "c"
+        ]
+      }
+    },
+    "note": {
+      "description": "present in 16.67% of objects",
+      "type": "string",
+      "examples": [
+        "call back"
+      ]
+    }
+  },
+  "required": [
+    "amount",
+    "id",
+    "status"
+  ]
+}`
+	assert.Equal(t, expected, string(got))
+}
+
+func TestInferredSchemaValidatesInputs(t *testing.T) {
+	// GIVEN
+	inferrer := New()
+	values := []string{
+		`{"id": 1, "items": [{"sku": "s-1", "qty": 1}], "meta": null}`,
+		`{"id": 2.5, "items": [{"sku": "s-2"}], "meta": {"source": "web"}}`,
+		`[1, "a"]`,
+		`"text"`,
+	}
+	for _, value := range values {
+		require.NoError(t, inferrer.Add([]byte(value)))
+	}
+	schemaBytes, err := inferrer.Schema("mixed")
+	require.NoError(t, err)
+
+	schemaPath := filepath.Join(t.TempDir(), "schema.json")
+	require.NoError(t, os.WriteFile(schemaPath, schemaBytes, 0o644))
+
+	// WHEN
+	schema, err := s.CompileJSONSchema(schemaPath)
+
+	// THEN
+	require.NoError(t, err)
+	for _, value := range values {
+		violations, err := s.ValidateJSON([]byte(value), schema)
+		require.NoError(t, err)
+		assert.Empty(t, violations, "value: %s", value)
+	}
+}
+
+func TestInferrerRejectsInvalidJSON(t *testing.T) {
+	testCases := []struct {
+		name  string
+		value string
+	}{
+		{name: "invalid json", value: `{"id": 1`},
+		{name: "trailing data", value: `{"id": 1} {"id": 2}`},
+		{name: "not json", value: `some text`},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			inferrer := New()
+			err := inferrer.Add([]byte(tt.value))
+			assert.ErrorIs(t, err, errValueIsNotJSON)
+			assert.Equal(t, uint(0), inferrer.NumValues())
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package serde
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/linkedin/goavro/v2"
+)
+
+var (
+	errCouldntDecodeAvroData        = errors.New("couldn't decode avro encoded data")
+	errCouldntConvertAvroDataToJSON = errors.New("couldn't convert avro data to JSON")
+)
+
+func TranscodeAvro(data []byte, codec *goavro.Codec) ([]byte, error) {
+	native, _, err := codec.NativeFromBinary(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errCouldntDecodeAvroData, err.Error())
+	}
+
+	jsonBytes, err := codec.TextualFromNative(nil, native)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errCouldntConvertAvroDataToJSON, err.Error())
+	}
+
+	return jsonBytes, nil
+}
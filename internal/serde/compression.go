@@ -0,0 +1,136 @@
+package serde
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+)
+
+// maxDecompressedSize guards against values that decompress to an unreasonable
+// size.
+const maxDecompressedSize = 64 * 1024 * 1024
+
+var (
+	errCouldntDecompress      = errors.New("couldn't decompress value")
+	errDecompressedTooLarge   = errors.New("decompressed value is too large")
+	errUnsupportedCompression = errors.New("unsupported compression codec")
+)
+
+var (
+	gzipMagic         = []byte{0x1f, 0x8b}
+	zstdMagic         = []byte{0x28, 0xb5, 0x2f, 0xfd}
+	lz4Magic          = []byte{0x04, 0x22, 0x4d, 0x18}
+	snappyFramedMagic = []byte{0xff, 0x06, 0x00, 0x00, 's', 'N', 'a', 'P', 'p', 'Y'}
+)
+
+type CompressionCodec uint
+
+const (
+	NoCompression CompressionCodec = iota
+	Gzip
+	Zstd
+	Snappy
+	LZ4
+)
+
+func (c CompressionCodec) String() string {
+	switch c {
+	case NoCompression:
+		return "none"
+	case Gzip:
+		return "gzip"
+	case Zstd:
+		return "zstd"
+	case Snappy:
+		return "snappy"
+	case LZ4:
+		return "lz4"
+	default:
+		return "unknown"
+	}
+}
+
+// DetectCompression guesses the codec data was compressed with via its magic
+// bytes. Snappy is only detected when it uses the framing format, since raw
+// snappy blocks don't start with a magic sequence.
+func DetectCompression(data []byte) CompressionCodec {
+	switch {
+	case bytes.HasPrefix(data, gzipMagic):
+		return Gzip
+	case bytes.HasPrefix(data, zstdMagic):
+		return Zstd
+	case bytes.HasPrefix(data, lz4Magic):
+		return LZ4
+	case bytes.HasPrefix(data, snappyFramedMagic):
+		return Snappy
+	default:
+		return NoCompression
+	}
+}
+
+func Decompress(data []byte, codec CompressionCodec) ([]byte, error) {
+	var reader io.Reader
+
+	switch codec {
+	case NoCompression:
+		return data, nil
+	case Gzip:
+		gzipReader, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("%w (%s): %s", errCouldntDecompress, codec, err.Error())
+		}
+		defer gzipReader.Close()
+		reader = gzipReader
+	case Zstd:
+		zstdReader, err := zstd.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("%w (%s): %s", errCouldntDecompress, codec, err.Error())
+		}
+		defer zstdReader.Close()
+		reader = zstdReader
+	case LZ4:
+		reader = lz4.NewReader(bytes.NewReader(data))
+	case Snappy:
+		if !bytes.HasPrefix(data, snappyFramedMagic) {
+			return decompressSnappyBlock(data)
+		}
+		reader = snappy.NewReader(bytes.NewReader(data))
+	default:
+		return nil, fmt.Errorf("%w: %d", errUnsupportedCompression, codec)
+	}
+
+	decompressed, err := io.ReadAll(io.LimitReader(reader, maxDecompressedSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w (%s): %s", errCouldntDecompress, codec, err.Error())
+	}
+
+	if len(decompressed) > maxDecompressedSize {
+		return nil, fmt.Errorf("%w: limit is %d bytes", errDecompressedTooLarge, maxDecompressedSize)
+	}
+
+	return decompressed, nil
+}
+
+func decompressSnappyBlock(data []byte) ([]byte, error) {
+	decodedLen, err := snappy.DecodedLen(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w (%s): %s", errCouldntDecompress, Snappy, err.Error())
+	}
+
+	if decodedLen > maxDecompressedSize {
+		return nil, fmt.Errorf("%w: limit is %d bytes", errDecompressedTooLarge, maxDecompressedSize)
+	}
+
+	decompressed, err := snappy.Decode(nil, data)
+	if err != nil {
+		return nil, fmt.Errorf("%w (%s): %s", errCouldntDecompress, Snappy, err.Error())
+	}
+
+	return decompressed, nil
+}
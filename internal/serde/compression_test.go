@@ -0,0 +1,199 @@
+package serde
+
+import (
+	"bytes"
+	"compress/gzip"
+	"testing"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var compressionTestValue = []byte(`{"id": 1, "description": "some compressed value"}`)
+
+func TestDecompress(t *testing.T) {
+	testCases := []struct {
+		name  string
+		codec CompressionCodec
+		data  []byte
+	}{
+		{
+			name:  "no compression",
+			codec: NoCompression,
+			data:  compressionTestValue,
+		},
+		{
+			name:  "gzip",
+			codec: Gzip,
+			data:  gzipCompress(t, compressionTestValue),
+		},
+		{
+			name:  "zstd",
+			codec: Zstd,
+			data:  zstdCompress(t, compressionTestValue),
+		},
+		{
+			name:  "lz4",
+			codec: LZ4,
+			data:  lz4Compress(t, compressionTestValue),
+		},
+		{
+			name:  "snappy framed",
+			codec: Snappy,
+			data:  snappyFramedCompress(t, compressionTestValue),
+		},
+		{
+			name:  "snappy block",
+			codec: Snappy,
+			data:  snappy.Encode(nil, compressionTestValue),
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Decompress(tt.data, tt.codec)
+
+			require.NoError(t, err)
+			assert.Equal(t, compressionTestValue, got)
+		})
+	}
+}
+
+func TestDecompressFailsForIncorrectData(t *testing.T) {
+	testCases := []struct {
+		name  string
+		codec CompressionCodec
+	}{
+		{
+			name:  "gzip",
+			codec: Gzip,
+		},
+		{
+			name:  "zstd",
+			codec: Zstd,
+		},
+		{
+			name:  "lz4",
+			codec: LZ4,
+		},
+		{
+			name:  "snappy",
+			codec: Snappy,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Decompress([]byte{0xff, 0xfe, 0xfd}, tt.codec)
+
+			assert.ErrorIs(t, err, errCouldntDecompress)
+		})
+	}
+}
+
+func TestDecompressFailsForTooLargeValues(t *testing.T) {
+	// GIVEN
+	data := gzipCompress(t, make([]byte, maxDecompressedSize+1))
+
+	// WHEN
+	_, err := Decompress(data, Gzip)
+
+	// THEN
+	assert.ErrorIs(t, err, errDecompressedTooLarge)
+}
+
+func TestDetectCompression(t *testing.T) {
+	testCases := []struct {
+		name     string
+		data     []byte
+		expected CompressionCodec
+	}{
+		{
+			name:     "gzip",
+			data:     gzipCompress(t, compressionTestValue),
+			expected: Gzip,
+		},
+		{
+			name:     "zstd",
+			data:     zstdCompress(t, compressionTestValue),
+			expected: Zstd,
+		},
+		{
+			name:     "lz4",
+			data:     lz4Compress(t, compressionTestValue),
+			expected: LZ4,
+		},
+		{
+			name:     "snappy framed",
+			data:     snappyFramedCompress(t, compressionTestValue),
+			expected: Snappy,
+		},
+		{
+			name:     "snappy block isn't detected",
+			data:     snappy.Encode(nil, compressionTestValue),
+			expected: NoCompression,
+		},
+		{
+			name:     "uncompressed",
+			data:     compressionTestValue,
+			expected: NoCompression,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DetectCompression(tt.data)
+
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func gzipCompress(t *testing.T, data []byte) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	_, err := writer.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	return buf.Bytes()
+}
+
+func zstdCompress(t *testing.T, data []byte) []byte {
+	t.Helper()
+
+	encoder, err := zstd.NewWriter(nil)
+	require.NoError(t, err)
+	defer encoder.Close()
+
+	return encoder.EncodeAll(data, nil)
+}
+
+func lz4Compress(t *testing.T, data []byte) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	writer := lz4.NewWriter(&buf)
+	_, err := writer.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	return buf.Bytes()
+}
+
+func snappyFramedCompress(t *testing.T, data []byte) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	writer := snappy.NewBufferedWriter(&buf)
+	_, err := writer.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	return buf.Bytes()
+}
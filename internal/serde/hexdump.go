@@ -0,0 +1,47 @@
+package serde
+
+import (
+	"fmt"
+	"strings"
+)
+
+const hexDumpBytesPerRow = 16
+
+// HexDump renders data as rows of 16 bytes, each of which starts with the
+// offset of its first byte and ends with the printable ASCII representation of
+// the row.
+//
+// 00000000  7b 22 69 64 22 3a 20 31  7d 0a                    |{"id": 1}.|
+func HexDump(data []byte) string {
+	var result strings.Builder
+
+	for rowStart := 0; rowStart < len(data); rowStart += hexDumpBytesPerRow {
+		row := data[rowStart:min(rowStart+hexDumpBytesPerRow, len(data))]
+
+		fmt.Fprintf(&result, "%08x  ", rowStart)
+
+		for i := range hexDumpBytesPerRow {
+			if i < len(row) {
+				fmt.Fprintf(&result, "%02x ", row[i])
+			} else {
+				result.WriteString("   ")
+			}
+
+			if i == hexDumpBytesPerRow/2-1 {
+				result.WriteByte(' ')
+			}
+		}
+
+		result.WriteString(" |")
+		for _, b := range row {
+			if b >= 32 && b <= 126 {
+				result.WriteByte(b)
+			} else {
+				result.WriteByte('.')
+			}
+		}
+		result.WriteString("|\n")
+	}
+
+	return result.String()
+}
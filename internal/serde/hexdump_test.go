@@ -0,0 +1,47 @@
+package serde
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHexDump(t *testing.T) {
+	testCases := []struct {
+		name     string
+		data     []byte
+		expected string
+	}{
+		{
+			name:     "empty input",
+			data:     []byte{},
+			expected: "",
+		},
+		{
+			name:     "partial row",
+			data:     []byte("{\"id\": 1}\n"),
+			expected: "00000000  7b 22 69 64 22 3a 20 31  7d 0a                    |{\"id\": 1}.|\n",
+		},
+		{
+			name:     "exactly one row",
+			data:     []byte{0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x41, 0x42, 0x43, 0x44, 0x45, 0x46, 0x47, 0x48},
+			expected: "00000000  00 01 02 03 04 05 06 07  41 42 43 44 45 46 47 48  |........ABCDEFGH|\n",
+		},
+		{
+			name: "multiple rows",
+			data: []byte("this spans more than a single row\xff"),
+			expected: `00000000  74 68 69 73 20 73 70 61  6e 73 20 6d 6f 72 65 20  |this spans more |
+00000010  74 68 61 6e 20 61 20 73  69 6e 67 6c 65 20 72 6f  |than a single ro|
+00000020  77 ff                                             |w.|
+`,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			got := HexDump(tt.data)
+
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
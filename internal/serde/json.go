@@ -26,3 +26,62 @@ func PrettifyJSON(data []byte) ([]byte, error) {
 
 	return out.Bytes(), nil
 }
+
+func CompactJSON(data []byte) ([]byte, error) {
+	var out bytes.Buffer
+
+	err := json.Compact(&out, data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errCouldntUnmarshalToJSON, err.Error())
+	}
+
+	return out.Bytes(), nil
+}
+
+// DecodeJSON decodes a JSON document into a generic value; numbers are
+// decoded as json.Number, so that they're not altered.
+func DecodeJSON(data []byte) (any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, fmt.Errorf("%w: %s", errCouldntUnmarshalToJSON, err.Error())
+	}
+
+	return value, nil
+}
+
+// LookupJSONField returns the value at a path of object keys in a decoded JSON
+// value.
+func LookupJSONField(value any, path []string) (any, bool) {
+	current := value
+	for _, segment := range path {
+		obj, ok := current.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+
+		current, ok = obj[segment]
+		if !ok {
+			return nil, false
+		}
+	}
+
+	return current, true
+}
+
+// JSONValueString returns a decoded JSON value as a string; strings are
+// returned as is, and other values are returned in their JSON representation.
+func JSONValueString(value any) string {
+	if str, ok := value.(string); ok {
+		return str
+	}
+
+	valueBytes, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+
+	return string(valueBytes)
+}
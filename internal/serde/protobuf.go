@@ -1,14 +1,18 @@
 package serde
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 
 	"google.golang.org/protobuf/encoding/protojson"
 	"google.golang.org/protobuf/encoding/protowire"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
 	"google.golang.org/protobuf/types/dynamicpb"
 )
 
@@ -26,29 +30,283 @@ var (
 type rawDecoder struct {
 	result      *strings.Builder
 	indentLevel int
+	annotate    bool
 }
 
-func TranscodeProto(bytes []byte, msgDescriptor protoreflect.MessageDescriptor) ([]byte, error) {
+// ProtoJSONOptions controls how protobuf messages are rendered as JSON.
+// AllowPartial lets messages with missing proto2 required fields through, which
+// is needed when those are reported by validation instead.
+type ProtoJSONOptions struct {
+	EmitUnpopulated   bool
+	UseProtoNames     bool
+	UseEnumNumbers    bool
+	ShowUnknownFields bool
+	AllowPartial      bool
+}
+
+// ProtoResolver resolves the message types referenced by google.protobuf.Any
+// fields, as well as extensions.
+type ProtoResolver interface {
+	protoregistry.MessageTypeResolver
+	protoregistry.ExtensionTypeResolver
+}
+
+// TranscodeProto decodes a protobuf encoded message and renders it as JSON. The
+// resolver is optional; when it's nil, google.protobuf.Any fields can only be
+// decoded if their types are linked into the binary.
+func TranscodeProto(bytes []byte, msgDescriptor protoreflect.MessageDescriptor, resolver ProtoResolver, options ProtoJSONOptions) ([]byte, error) {
 	msg := dynamicpb.NewMessage(msgDescriptor)
 
-	err := proto.Unmarshal(bytes, msg)
+	unmarshalOptions := proto.UnmarshalOptions{
+		DiscardUnknown: !options.ShowUnknownFields,
+		AllowPartial:   options.AllowPartial,
+	}
+	if resolver != nil {
+		unmarshalOptions.Resolver = resolver
+	}
+	err := unmarshalOptions.Unmarshal(bytes, msg)
 	if err != nil {
 		return nil, fmt.Errorf("%w: %s", errCouldntUnmarshalProtoMsg, err.Error())
 	}
 
 	marshallOptions := protojson.MarshalOptions{
-		Indent: "  ",
+		Indent:          "  ",
+		EmitUnpopulated: options.EmitUnpopulated,
+		UseProtoNames:   options.UseProtoNames,
+		UseEnumNumbers:  options.UseEnumNumbers,
+		AllowPartial:    options.AllowPartial,
+	}
+	if resolver != nil {
+		marshallOptions.Resolver = resolver
 	}
 	jsonBytes, err := marshallOptions.Marshal(msg)
 	if err != nil {
 		return nil, fmt.Errorf("%w: %s", errCouldntConvertProtoMsgToJSON, err.Error())
 	}
 
-	return jsonBytes, nil
+	if !options.ShowUnknownFields {
+		return jsonBytes, nil
+	}
+
+	unknown, err := getUnknownFields(msg, options.UseProtoNames)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errWireDataIsMalformed, err.Error())
+	}
+	if unknown == nil {
+		return jsonBytes, nil
+	}
+
+	return appendUnknownFields(jsonBytes, unknown)
+}
+
+// unknownFields holds the unknown fields of a message, as well as the ones of
+// the messages nested in it, keyed by the JSON member name (or array index)
+// they're rendered under.
+type unknownFields struct {
+	fields []rawField
+	nested map[string]*unknownFields
+}
+
+// getUnknownFields walks a message, and collects its unknown fields, as well as
+// the ones of every message nested in it. It returns nil if there aren't any.
+//
+// Well-known types (eg. google.protobuf.Timestamp) aren't walked, since
+// protojson doesn't render them as regular JSON objects.
+func getUnknownFields(msg protoreflect.Message, useProtoNames bool) (*unknownFields, error) {
+	result := &unknownFields{}
+	if unknown := msg.GetUnknown(); len(unknown) > 0 {
+		fields, err := getRawFields(unknown, maxRecursionDepth)
+		if err != nil {
+			return nil, err
+		}
+		result.fields = fields
+	}
+
+	var err error
+	msg.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		msgDescriptor := fd.Message()
+		if fd.IsMap() {
+			msgDescriptor = fd.MapValue().Message()
+		}
+		if msgDescriptor == nil || isWellKnownType(msgDescriptor) {
+			return true
+		}
+
+		var nested *unknownFields
+		switch {
+		case fd.IsList():
+			nested = &unknownFields{}
+			list := v.List()
+			for i := range list.Len() {
+				var element *unknownFields
+				if element, err = getUnknownFields(list.Get(i).Message(), useProtoNames); err != nil {
+					return false
+				}
+				nested.add(strconv.Itoa(i), element)
+			}
+		case fd.IsMap():
+			nested = &unknownFields{}
+			v.Map().Range(func(k protoreflect.MapKey, mv protoreflect.Value) bool {
+				var entry *unknownFields
+				if entry, err = getUnknownFields(mv.Message(), useProtoNames); err != nil {
+					return false
+				}
+				nested.add(k.String(), entry)
+				return true
+			})
+		default:
+			nested, err = getUnknownFields(v.Message(), useProtoNames)
+		}
+		if err != nil {
+			return false
+		}
+
+		result.add(getJSONFieldName(fd, useProtoNames), nested)
+
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if result.isEmpty() {
+		return nil, nil
+	}
+
+	return result, nil
+}
+
+func (u *unknownFields) add(key string, nested *unknownFields) {
+	if nested == nil || nested.isEmpty() {
+		return
+	}
+
+	if u.nested == nil {
+		u.nested = make(map[string]*unknownFields)
+	}
+	u.nested[key] = nested
+}
+
+func (u *unknownFields) isEmpty() bool {
+	return len(u.fields) == 0 && len(u.nested) == 0
+}
+
+// getJSONFieldName returns the name protojson renders a field under.
+func getJSONFieldName(fd protoreflect.FieldDescriptor, useProtoNames bool) string {
+	switch {
+	case fd.IsExtension():
+		return fmt.Sprintf("[%s]", fd.FullName())
+	case useProtoNames:
+		return fd.TextName()
+	default:
+		return fd.JSONName()
+	}
+}
+
+func isWellKnownType(msgDescriptor protoreflect.MessageDescriptor) bool {
+	return msgDescriptor.ParentFile().Package() == "google.protobuf"
+}
+
+// appendUnknownFields adds the unknown fields of a message, and of the messages
+// nested in it, to its JSON representation, under the key "@unknown" of the
+// corresponding JSON objects. protojson has no support for rendering unknown
+// fields, and the "@" prefix can't clash with a field name.
+func appendUnknownFields(jsonBytes []byte, unknown *unknownFields) ([]byte, error) {
+	var compact bytes.Buffer
+	if err := addUnknownFields(&compact, jsonBytes, unknown); err != nil {
+		return nil, fmt.Errorf("%w: %s", errCouldntConvertProtoMsgToJSON, err.Error())
+	}
+
+	var result bytes.Buffer
+	if err := json.Indent(&result, compact.Bytes(), "", "  "); err != nil {
+		return nil, fmt.Errorf("%w: %s", errCouldntConvertProtoMsgToJSON, err.Error())
+	}
+
+	return result.Bytes(), nil
+}
+
+// addUnknownFields writes a JSON value to buf, with the unknown fields added
+// to it (and to the values nested in it), while retaining the order of its
+// members. Values that aren't JSON objects or arrays are written as they are.
+func addUnknownFields(buf *bytes.Buffer, value []byte, unknown *unknownFields) error {
+	value = bytes.TrimSpace(value)
+	if unknown == nil || len(value) == 0 || (value[0] != '{' && value[0] != '[') {
+		return json.Compact(buf, value)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(value))
+	decoder.UseNumber()
+	delim, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+
+	isObject := delim == json.Delim('{')
+	buf.WriteByte(value[0])
+
+	for i := 0; decoder.More(); i++ {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		key := strconv.Itoa(i)
+		if isObject {
+			keyToken, err := decoder.Token()
+			if err != nil {
+				return err
+			}
+			key, _ = keyToken.(string)
+			if err := writeJSON(buf, key); err != nil {
+				return err
+			}
+			buf.WriteByte(':')
+		}
+
+		var member json.RawMessage
+		if err := decoder.Decode(&member); err != nil {
+			return err
+		}
+		if err := addUnknownFields(buf, member, unknown.nested[key]); err != nil {
+			return err
+		}
+	}
+
+	if isObject && len(unknown.fields) > 0 {
+		if !bytes.HasSuffix(buf.Bytes(), []byte("{")) {
+			buf.WriteByte(',')
+		}
+		buf.WriteString(`"@unknown":`)
+		if err := writeJSON(buf, unknown.fields); err != nil {
+			return err
+		}
+	}
+
+	if isObject {
+		buf.WriteByte('}')
+	} else {
+		buf.WriteByte(']')
+	}
+
+	return nil
+}
+
+// writeJSON writes a value to buf as compact JSON, without escaping HTML
+// characters, the same way protojson does.
+func writeJSON(buf *bytes.Buffer, value any) error {
+	encoder := json.NewEncoder(buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return err
+	}
+	buf.Truncate(buf.Len() - 1) // the trailing newline Encode adds
+
+	return nil
 }
 
+// DecodeRaw decodes protobuf wire data without a schema, the same way
+// "protoc --decode_raw" does.
 func DecodeRaw(data []byte) ([]byte, error) {
-	decoder := newRawDecoder()
+	decoder := newRawDecoder(false)
 	err := decoder.writeRawTagValuePairs(data, maxRecursionDepth)
 	if err != nil {
 		return nil, fmt.Errorf("%w: %s", errWireDataIsMalformed, err.Error())
@@ -57,6 +315,154 @@ func DecodeRaw(data []byte) ([]byte, error) {
 	return []byte(decoder.string()), nil
 }
 
+// DecodeRawAnnotated works like DecodeRaw, but annotates values with their
+// plausible interpretations (signed and zigzag encoded integers, floats,
+// timestamps, UTF-8 text, packed varints) as trailing comments.
+func DecodeRawAnnotated(data []byte) ([]byte, error) {
+	decoder := newRawDecoder(true)
+	err := decoder.writeRawTagValuePairs(data, maxRecursionDepth)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errWireDataIsMalformed, err.Error())
+	}
+
+	return []byte(decoder.string()), nil
+}
+
+// DecodeRawJSON decodes protobuf wire data without a schema, and renders it as
+// a JSON array of fields, each of which includes the same interpretations that
+// DecodeRawAnnotated adds as comments.
+func DecodeRawJSON(data []byte) ([]byte, error) {
+	fields, err := getRawFields(data, maxRecursionDepth)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errWireDataIsMalformed, err.Error())
+	}
+
+	jsonBytes, err := json.MarshalIndent(fields, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errCouldntConvertProtoMsgToJSON, err.Error())
+	}
+
+	return jsonBytes, nil
+}
+
+type rawField struct {
+	Field    protowire.Number `json:"field"`
+	WireType string           `json:"wire_type"`
+	Value    any              `json:"value"`
+	Guesses  map[string]any   `json:"guesses,omitempty"`
+}
+
+func getRawFields(data []byte, recursionBudget int) ([]rawField, error) {
+	fields := []rawField{}
+	remaining := data
+
+	for len(remaining) > 0 {
+		fieldNum, wireType, n := protowire.ConsumeTag(remaining)
+		if n < 0 {
+			return nil, fmt.Errorf("failed to consume tag: %w", protowire.ParseError(n))
+		}
+		remaining = remaining[n:]
+
+		field := rawField{Field: fieldNum}
+
+		switch wireType {
+		case protowire.VarintType:
+			value, n := protowire.ConsumeVarint(remaining)
+			if n < 0 {
+				return nil, fmt.Errorf("failed to consume varint: %w", protowire.ParseError(n))
+			}
+			field.WireType = "varint"
+			field.Value = value
+			field.Guesses = guessesMap(varintGuesses(value))
+			remaining = remaining[n:]
+
+		case protowire.Fixed32Type:
+			value, n := protowire.ConsumeFixed32(remaining)
+			if n < 0 {
+				return nil, fmt.Errorf("failed to consume fixed32: %w", protowire.ParseError(n))
+			}
+			field.WireType = "fixed32"
+			field.Value = fmt.Sprintf("0x%08x", value)
+			field.Guesses = guessesMap(fixed32Guesses(value))
+			remaining = remaining[n:]
+
+		case protowire.Fixed64Type:
+			value, n := protowire.ConsumeFixed64(remaining)
+			if n < 0 {
+				return nil, fmt.Errorf("failed to consume fixed64: %w", protowire.ParseError(n))
+			}
+			field.WireType = "fixed64"
+			field.Value = fmt.Sprintf("0x%016x", value)
+			field.Guesses = guessesMap(fixed64Guesses(value))
+			remaining = remaining[n:]
+
+		case protowire.BytesType:
+			value, n := protowire.ConsumeBytes(remaining)
+			if n < 0 {
+				return nil, fmt.Errorf("failed to consume bytes: %w", protowire.ParseError(n))
+			}
+			field.WireType = "bytes"
+
+			var nested []rawField
+			var nestedErr error
+			if len(value) > 0 && recursionBudget > 0 && canParseAsMessage(value) {
+				nested, nestedErr = getRawFields(value, recursionBudget-1)
+			}
+
+			// short strings often happen to be valid wire data as well, in
+			// which case the nested message is only offered as a guess
+			isMessage := nested != nil && nestedErr == nil
+			switch {
+			case isReadableUTF8(value):
+				field.Value = string(value)
+				if isMessage {
+					field.Guesses = map[string]any{"message": nested}
+				}
+			case isMessage:
+				field.Value = nested
+				field.Guesses = guessesMap(messageGuesses(value))
+			default:
+				field.Value = cEscape(value)
+				field.Guesses = guessesMap(bytesGuesses(value))
+			}
+			remaining = remaining[n:]
+
+		case protowire.StartGroupType:
+			groupData, n := protowire.ConsumeGroup(fieldNum, remaining)
+			if n < 0 {
+				return nil, fmt.Errorf("failed to consume group: %w", protowire.ParseError(n))
+			}
+			field.WireType = "group"
+
+			var nested []rawField
+			var nestedErr error
+			if recursionBudget > 0 {
+				nested, nestedErr = getRawFields(groupData, recursionBudget-1)
+			}
+
+			if nested != nil && nestedErr == nil {
+				field.Value = nested
+			} else {
+				field.Value = cEscape(groupData)
+			}
+			remaining = remaining[n:]
+
+		default:
+			n := protowire.ConsumeFieldValue(fieldNum, wireType, remaining)
+			if n < 0 {
+				return nil, fmt.Errorf("failed to consume field value: %w", protowire.ParseError(n))
+			}
+			field.WireType = fmt.Sprintf("unknown (%d)", wireType)
+			field.Value = cEscape(remaining[:n])
+			remaining = remaining[n:]
+		}
+
+		fields = append(fields, field)
+	}
+
+	return fields, nil
+}
+
 func (d *rawDecoder) writeRawTagValuePairs(data []byte, recursionBudget int) error {
 	remaining := data
 
@@ -78,6 +484,7 @@ func (d *rawDecoder) writeRawTagValuePairs(data []byte, recursionBudget int) err
 			d.printLiteral(fmt.Sprintf("%s%d", indent, fieldNum))
 			d.printLiteral(": ")
 			d.printLiteral(fmt.Sprintf("%d", value))
+			d.printAnnotation(varintGuesses(value))
 			d.printLineEnding()
 			remaining = remaining[n:]
 
@@ -89,6 +496,7 @@ func (d *rawDecoder) writeRawTagValuePairs(data []byte, recursionBudget int) err
 			d.printLiteral(fmt.Sprintf("%s%d", indent, fieldNum))
 			d.printLiteral(": 0x")
 			d.printLiteral(fmt.Sprintf("%08x", value))
+			d.printAnnotation(fixed32Guesses(value))
 			d.printLineEnding()
 			remaining = remaining[n:]
 
@@ -100,6 +508,7 @@ func (d *rawDecoder) writeRawTagValuePairs(data []byte, recursionBudget int) err
 			d.printLiteral(fmt.Sprintf("%s%d", indent, fieldNum))
 			d.printLiteral(": 0x")
 			d.printLiteral(fmt.Sprintf("%016x", value))
+			d.printAnnotation(fixed64Guesses(value))
 			d.printLineEnding()
 			remaining = remaining[n:]
 
@@ -112,7 +521,9 @@ func (d *rawDecoder) writeRawTagValuePairs(data []byte, recursionBudget int) err
 			d.printLiteral(fmt.Sprintf("%s%d", indent, fieldNum))
 
 			if len(value) > 0 && recursionBudget > 0 && canParseAsMessage(value) {
-				d.printLiteral(" {\n")
+				d.printLiteral(" {")
+				d.printAnnotation(messageGuesses(value))
+				d.printLineEnding()
 				d.indent()
 				err := d.writeRawTagValuePairs(value, recursionBudget-1)
 				if err != nil {
@@ -128,7 +539,9 @@ func (d *rawDecoder) writeRawTagValuePairs(data []byte, recursionBudget int) err
 			} else {
 				d.printLiteral(": \"")
 				d.printLiteral(cEscape(value))
-				d.printLiteral("\"\n")
+				d.printLiteral("\"")
+				d.printAnnotation(bytesGuesses(value))
+				d.printLineEnding()
 			}
 			remaining = remaining[n:]
 
@@ -240,9 +653,10 @@ func canParseAsMessage(data []byte) bool {
 	return true
 }
 
-func newRawDecoder() *rawDecoder {
+func newRawDecoder(annotate bool) *rawDecoder {
 	return &rawDecoder{
-		result: &strings.Builder{},
+		result:   &strings.Builder{},
+		annotate: annotate,
 	}
 }
 
@@ -268,6 +682,15 @@ func (d *rawDecoder) getIndent() string {
 	return strings.Repeat("  ", d.indentLevel)
 }
 
+func (d *rawDecoder) printAnnotation(guesses []rawGuess) {
+	if !d.annotate || len(guesses) == 0 {
+		return
+	}
+
+	d.result.WriteString("  # ")
+	d.result.WriteString(guessesDisplay(guesses))
+}
+
 func (d *rawDecoder) printLineEnding() {
 	d.result.WriteString("\n")
 }
@@ -0,0 +1,250 @@
+package serde
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Timestamps are only guessed for values that fall between 2000-01-01 and
+// 2100-01-01, which keeps the number of false positives low.
+const (
+	minPlausibleUnixSecs = 946684800
+	maxPlausibleUnixSecs = 4102444800
+	maxPlausibleNanos    = 999999999
+	minPlausibleFloat    = 1e-6
+	maxPlausibleFloat    = 1e12
+)
+
+// rawGuess is a plausible interpretation of a value decoded without a schema.
+type rawGuess struct {
+	name   string
+	value  any
+	quoted bool
+}
+
+func (g rawGuess) String() string {
+	if g.quoted {
+		return fmt.Sprintf("%s: %q", g.name, g.value)
+	}
+
+	return fmt.Sprintf("%s: %v", g.name, g.value)
+}
+
+func guessesMap(guesses []rawGuess) map[string]any {
+	if len(guesses) == 0 {
+		return nil
+	}
+
+	result := make(map[string]any, len(guesses))
+	for _, g := range guesses {
+		result[g.name] = g.value
+	}
+
+	return result
+}
+
+func guessesDisplay(guesses []rawGuess) string {
+	parts := make([]string, len(guesses))
+	for i, g := range guesses {
+		parts[i] = g.String()
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+func varintGuesses(v uint64) []rawGuess {
+	var guesses []rawGuess
+
+	if v > math.MaxInt64 {
+		guesses = append(guesses, rawGuess{name: "int64", value: int64(v)})
+	}
+
+	if v > 1 {
+		guesses = append(guesses, rawGuess{name: "sint", value: protowire.DecodeZigZag(v)})
+	}
+
+	if v <= math.MaxInt64 {
+		if ts, unit, ok := guessTimestamp(int64(v)); ok {
+			guesses = append(guesses, rawGuess{name: fmt.Sprintf("timestamp (%s)", unit), value: ts})
+		}
+	}
+
+	return guesses
+}
+
+func fixed32Guesses(v uint32) []rawGuess {
+	var guesses []rawGuess
+
+	if int32(v) < 0 {
+		guesses = append(guesses, rawGuess{name: "int32", value: int32(v)})
+	}
+
+	f := math.Float32frombits(v)
+	if isPlausibleFloat(float64(f)) {
+		guesses = append(guesses, rawGuess{name: "float", value: f})
+	}
+
+	if ts, unit, ok := guessTimestamp(int64(v)); ok {
+		guesses = append(guesses, rawGuess{name: fmt.Sprintf("timestamp (%s)", unit), value: ts})
+	}
+
+	return guesses
+}
+
+func fixed64Guesses(v uint64) []rawGuess {
+	var guesses []rawGuess
+
+	if int64(v) < 0 {
+		guesses = append(guesses, rawGuess{name: "int64", value: int64(v)})
+	}
+
+	f := math.Float64frombits(v)
+	if isPlausibleFloat(f) {
+		guesses = append(guesses, rawGuess{name: "double", value: f})
+	}
+
+	if ts, unit, ok := guessTimestamp(int64(v)); ok {
+		guesses = append(guesses, rawGuess{name: fmt.Sprintf("timestamp (%s)", unit), value: ts})
+	}
+
+	return guesses
+}
+
+// bytesGuesses returns interpretations of a length-delimited value that isn't
+// a valid message: text that the C-style escaping would obscure, and packed
+// repeated varints.
+func bytesGuesses(b []byte) []rawGuess {
+	var guesses []rawGuess
+
+	if isPrintableASCII(b) {
+		return nil
+	}
+
+	if isReadableUTF8(b) {
+		return []rawGuess{{name: "utf8", value: string(b), quoted: true}}
+	}
+
+	if varints, ok := consumePackedVarints(b); ok && len(varints) > 1 {
+		guesses = append(guesses, rawGuess{name: "packed varints", value: varints})
+	}
+
+	return guesses
+}
+
+// messageGuesses returns interpretations of a nested message as a whole; for
+// now, this only recognizes messages shaped like google.protobuf.Timestamp.
+func messageGuesses(b []byte) []rawGuess {
+	var seconds, nanos uint64
+	var seenSeconds bool
+
+	remaining := b
+	for len(remaining) > 0 {
+		fieldNum, wireType, n := protowire.ConsumeTag(remaining)
+		if n < 0 || wireType != protowire.VarintType {
+			return nil
+		}
+		remaining = remaining[n:]
+
+		v, n := protowire.ConsumeVarint(remaining)
+		if n < 0 {
+			return nil
+		}
+		remaining = remaining[n:]
+
+		switch fieldNum {
+		case 1:
+			seconds = v
+			seenSeconds = true
+		case 2:
+			nanos = v
+		default:
+			return nil
+		}
+	}
+
+	if !seenSeconds || seconds < minPlausibleUnixSecs || seconds > maxPlausibleUnixSecs || nanos > maxPlausibleNanos {
+		return nil
+	}
+
+	ts := time.Unix(int64(seconds), int64(nanos)).UTC().Format(time.RFC3339Nano)
+
+	return []rawGuess{{name: "timestamp", value: ts}}
+}
+
+func guessTimestamp(v int64) (string, string, bool) {
+	units := []struct {
+		name       string
+		multiplier int64
+	}{
+		{"s", 1},
+		{"ms", 1_000},
+		{"µs", 1_000_000},
+		{"ns", 1_000_000_000},
+	}
+
+	for _, u := range units {
+		if v >= minPlausibleUnixSecs*u.multiplier && v <= maxPlausibleUnixSecs*u.multiplier {
+			secs := v / u.multiplier
+			nanos := (v % u.multiplier) * (1_000_000_000 / u.multiplier)
+			return time.Unix(secs, nanos).UTC().Format(time.RFC3339Nano), u.name, true
+		}
+	}
+
+	return "", "", false
+}
+
+func isPlausibleFloat(f float64) bool {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return false
+	}
+
+	abs := math.Abs(f)
+
+	return abs >= minPlausibleFloat && abs <= maxPlausibleFloat
+}
+
+func isReadableUTF8(b []byte) bool {
+	if !utf8.Valid(b) {
+		return false
+	}
+
+	for _, r := range string(b) {
+		if !unicode.IsPrint(r) && !unicode.IsSpace(r) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isPrintableASCII(b []byte) bool {
+	for _, c := range b {
+		if c < 32 || c > 126 {
+			return false
+		}
+	}
+
+	return true
+}
+
+func consumePackedVarints(b []byte) ([]uint64, bool) {
+	var varints []uint64
+
+	remaining := b
+	for len(remaining) > 0 {
+		v, n := protowire.ConsumeVarint(remaining)
+		if n < 0 {
+			return nil, false
+		}
+		varints = append(varints, v)
+		remaining = remaining[n:]
+	}
+
+	return varints, true
+}
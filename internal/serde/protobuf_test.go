@@ -10,6 +10,14 @@ import (
 	"github.com/gkampitakis/go-snaps/snaps"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/anypb"
 )
 
 //go:embed testdata/values/a.bin
@@ -148,3 +156,323 @@ func TestDecodeRawFailsOnIncorrectInput(t *testing.T) {
 	// THEN
 	assert.ErrorIs(t, err, errWireDataIsMalformed)
 }
+
+func getTestFiles(t *testing.T) *protoregistry.Files {
+	t.Helper()
+
+	fileDescriptorProto := &descriptorpb.FileDescriptorProto{
+		Name:       proto.String("sample.proto"),
+		Package:    proto.String("sample"),
+		Syntax:     proto.String("proto3"),
+		Dependency: []string{"google/protobuf/any.proto"},
+		EnumType: []*descriptorpb.EnumDescriptorProto{
+			{
+				Name: proto.String("Status"),
+				Value: []*descriptorpb.EnumValueDescriptorProto{
+					{Name: proto.String("STATUS_UNKNOWN"), Number: proto.Int32(0)},
+					{Name: proto.String("STATUS_ACTIVE"), Number: proto.Int32(1)},
+				},
+			},
+		},
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name: proto.String("User"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					{
+						Name:     proto.String("user_id"),
+						JsonName: proto.String("userId"),
+						Number:   proto.Int32(1),
+						Type:     descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+						Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+					},
+					{
+						Name:     proto.String("status"),
+						JsonName: proto.String("status"),
+						Number:   proto.Int32(2),
+						Type:     descriptorpb.FieldDescriptorProto_TYPE_ENUM.Enum(),
+						TypeName: proto.String(".sample.Status"),
+						Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+					},
+					{
+						Name:     proto.String("login_count"),
+						JsonName: proto.String("loginCount"),
+						Number:   proto.Int32(3),
+						Type:     descriptorpb.FieldDescriptorProto_TYPE_INT32.Enum(),
+						Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+					},
+				},
+			},
+			{
+				Name: proto.String("Account"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					{
+						Name:     proto.String("owner"),
+						JsonName: proto.String("owner"),
+						Number:   proto.Int32(1),
+						Type:     descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum(),
+						TypeName: proto.String(".sample.User"),
+						Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+					},
+					{
+						Name:     proto.String("members"),
+						JsonName: proto.String("members"),
+						Number:   proto.Int32(2),
+						Type:     descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum(),
+						TypeName: proto.String(".sample.User"),
+						Label:    descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum(),
+					},
+				},
+			},
+			{
+				Name: proto.String("Envelope"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					{
+						Name:     proto.String("payload"),
+						JsonName: proto.String("payload"),
+						Number:   proto.Int32(1),
+						Type:     descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum(),
+						TypeName: proto.String(".google.protobuf.Any"),
+						Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+					},
+				},
+			},
+		},
+	}
+
+	fds := &descriptorpb.FileDescriptorSet{
+		File: []*descriptorpb.FileDescriptorProto{
+			protodesc.ToFileDescriptorProto(anypb.File_google_protobuf_any_proto),
+			fileDescriptorProto,
+		},
+	}
+
+	files, err := protodesc.NewFiles(fds)
+	require.NoError(t, err)
+
+	return files
+}
+
+func getTestMsgDescriptor(t *testing.T) protoreflect.MessageDescriptor {
+	t.Helper()
+
+	descriptor, err := getTestFiles(t).FindDescriptorByName("sample.User")
+	require.NoError(t, err)
+
+	return descriptor.(protoreflect.MessageDescriptor)
+}
+
+func TestTranscodeProto(t *testing.T) {
+	data := []byte{
+		0x0a, 0x03, 0x61, 0x62, 0x63, // field 1: string "abc"
+		0x10, 0x01, // field 2: enum STATUS_ACTIVE
+		0x28, 0x96, 0x01, // field 5 (unknown): varint 150
+	}
+
+	testCases := []struct {
+		name     string
+		options  ProtoJSONOptions
+		expected string
+	}{
+		{
+			name:     "default options",
+			options:  ProtoJSONOptions{},
+			expected: `{"userId":"abc","status":"STATUS_ACTIVE"}`,
+		},
+		{
+			name:     "emit unpopulated",
+			options:  ProtoJSONOptions{EmitUnpopulated: true},
+			expected: `{"userId":"abc","status":"STATUS_ACTIVE","loginCount":0}`,
+		},
+		{
+			name:     "use proto names",
+			options:  ProtoJSONOptions{UseProtoNames: true},
+			expected: `{"user_id":"abc","status":"STATUS_ACTIVE"}`,
+		},
+		{
+			name:     "use enum numbers",
+			options:  ProtoJSONOptions{UseEnumNumbers: true},
+			expected: `{"userId":"abc","status":1}`,
+		},
+		{
+			name:     "show unknown fields",
+			options:  ProtoJSONOptions{ShowUnknownFields: true},
+			expected: `{"userId":"abc","status":"STATUS_ACTIVE","@unknown":[{"field":5,"wire_type":"varint","value":150,"guesses":{"sint":75}}]}`,
+		},
+	}
+
+	msgDescriptor := getTestMsgDescriptor(t)
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := TranscodeProto(data, msgDescriptor, nil, tt.options)
+
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.expected, string(got))
+		})
+	}
+}
+
+func TestTranscodeProtoShowsUnknownFieldsForEmptyMessage(t *testing.T) {
+	// GIVEN
+	data := []byte{0x32, 0x03, 0x68, 0x69, 0x21} // field 6 (unknown): string "hi!"
+	msgDescriptor := getTestMsgDescriptor(t)
+
+	// WHEN
+	got, err := TranscodeProto(data, msgDescriptor, nil, ProtoJSONOptions{ShowUnknownFields: true})
+
+	// THEN
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"@unknown":[{"field":6,"wire_type":"bytes","value":"hi!"}]}`, string(got))
+}
+
+func TestTranscodeProtoShowsUnknownFieldsOfNestedMessages(t *testing.T) {
+	// GIVEN
+	descriptor, err := getTestFiles(t).FindDescriptorByName("sample.Account")
+	require.NoError(t, err)
+
+	// owner: {userId: "abc", 5: 150}, members: [{userId: "def"}, {6: "hi!"}]
+	data := []byte{
+		0x0a, 0x08, 0x0a, 0x03, 0x61, 0x62, 0x63, 0x28, 0x96, 0x01, // field 1: user with unknown field 5
+		0x12, 0x05, 0x0a, 0x03, 0x64, 0x65, 0x66, // field 2: user
+		0x12, 0x05, 0x32, 0x03, 0x68, 0x69, 0x21, // field 2: user with unknown field 6
+	}
+
+	testCases := []struct {
+		name     string
+		options  ProtoJSONOptions
+		expected string
+	}{
+		{
+			name:    "json names",
+			options: ProtoJSONOptions{ShowUnknownFields: true},
+			expected: `{
+  "owner": {"userId": "abc", "@unknown": [{"field": 5, "wire_type": "varint", "value": 150, "guesses": {"sint": 75}}]},
+  "members": [{"userId": "def"}, {"@unknown": [{"field": 6, "wire_type": "bytes", "value": "hi!"}]}]
+}`,
+		},
+		{
+			name:    "proto names",
+			options: ProtoJSONOptions{ShowUnknownFields: true, UseProtoNames: true},
+			expected: `{
+  "owner": {"user_id": "abc", "@unknown": [{"field": 5, "wire_type": "varint", "value": 150, "guesses": {"sint": 75}}]},
+  "members": [{"user_id": "def"}, {"@unknown": [{"field": 6, "wire_type": "bytes", "value": "hi!"}]}]
+}`,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			// WHEN
+			got, err := TranscodeProto(data, descriptor.(protoreflect.MessageDescriptor), nil, tt.options)
+
+			// THEN
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.expected, string(got))
+		})
+	}
+}
+
+func TestTranscodeProtoResolvesAnyFields(t *testing.T) {
+	// GIVEN
+	files := getTestFiles(t)
+	descriptor, err := files.FindDescriptorByName("sample.Envelope")
+	require.NoError(t, err)
+
+	user := []byte{0x0a, 0x03, 0x61, 0x62, 0x63} // field 1: string "abc"
+	var payload []byte
+	payload = protowire.AppendTag(payload, 1, protowire.BytesType)
+	payload = protowire.AppendString(payload, "type.googleapis.com/sample.User")
+	payload = protowire.AppendTag(payload, 2, protowire.BytesType)
+	payload = protowire.AppendBytes(payload, user)
+	var data []byte
+	data = protowire.AppendTag(data, 1, protowire.BytesType)
+	data = protowire.AppendBytes(data, payload)
+
+	// WHEN
+	got, err := TranscodeProto(data, descriptor.(protoreflect.MessageDescriptor), dynamicpb.NewTypes(files), ProtoJSONOptions{})
+
+	// THEN
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"payload":{"@type":"type.googleapis.com/sample.User","userId":"abc"}}`, string(got))
+}
+
+func TestDecodeRawAnnotatedOnSampleMsg(t *testing.T) {
+	// GIVEN
+	// WHEN
+	got, err := DecodeRawAnnotated(sampleMsg)
+
+	// THEN
+	require.NoError(t, err)
+	snaps.MatchStandaloneSnapshot(t, string(got))
+}
+
+func TestDecodeRawJSONOnSampleMsg(t *testing.T) {
+	// GIVEN
+	// WHEN
+	got, err := DecodeRawJSON(sampleMsg)
+
+	// THEN
+	require.NoError(t, err)
+	snaps.MatchStandaloneSnapshot(t, string(got))
+}
+
+func TestDecodeRawAnnotated(t *testing.T) {
+	testCases := []struct {
+		name     string
+		data     []byte
+		expected string
+	}{
+		{
+			name:     "zigzag encoded varint",
+			data:     []byte{0x08, 0x03}, // field 1: varint 3
+			expected: "1: 3  # sint: -2\n",
+		},
+		{
+			name:     "negative int64 varint",
+			data:     []byte{0x08, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01}, // field 1: varint -1
+			expected: "1: 18446744073709551615  # int64: -1, sint: -9223372036854775808\n",
+		},
+		{
+			name:     "timestamp in milliseconds",
+			data:     []byte{0x08, 0x80, 0xa4, 0x99, 0xd3, 0xe0, 0x32}, // field 1: varint 1743931200000
+			expected: "1: 1743931200000  # sint: 871965600000, timestamp (ms): 2025-04-06T09:20:00Z\n",
+		},
+		{
+			name:     "float",
+			data:     []byte{0x0d, 0x00, 0x00, 0x20, 0x41}, // field 1: fixed32 10.0
+			expected: "1: 0x41200000  # float: 10, timestamp (s): 2004-08-16T00:29:52Z\n",
+		},
+		{
+			name:     "double",
+			data:     []byte{0x09, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xf8, 0x3f}, // field 1: fixed64 1.5
+			expected: "1: 0x3ff8000000000000  # double: 1.5\n",
+		},
+		{
+			name:     "utf-8 string",
+			data:     []byte{0x12, 0x05, 0x63, 0x61, 0x66, 0xc3, 0xa9}, // field 2: string "café"
+			expected: "2: \"caf\\303\\251\"  # utf8: \"café\"\n",
+		},
+		{
+			name:     "packed varints",
+			data:     []byte{0x12, 0x04, 0x00, 0x96, 0x01, 0x07}, // field 2: packed [0, 150, 7]
+			expected: "2: \"\\000\\226\\001\\a\"  # packed varints: [0 150 7]\n",
+		},
+		{
+			name: "timestamp message",
+			data: []byte{
+				0x1a, 0x06, // field 3: length-delimited (6 bytes)
+				0x08, 0x80, 0x91, 0xc9, 0xbf, 0x06, // nested field 1: varint 1743931520
+			},
+			expected: "3 {  # timestamp: 2025-04-06T09:25:20Z\n  1: 1743931520  # sint: 871965760, timestamp (s): 2025-04-06T09:25:20Z\n}\n",
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeRawAnnotated(tt.data)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, string(got))
+		})
+	}
+}
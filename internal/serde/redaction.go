@@ -0,0 +1,352 @@
+package serde
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+const (
+	redactionWildcard = "*"
+	redactionMask     = "****"
+)
+
+var (
+	errRedactionPathEmpty          = errors.New("redaction path is empty")
+	errRedactionPathInvalid        = errors.New("redaction path is invalid")
+	errRedactionFieldNotFound      = errors.New("field not found in protobuf descriptor")
+	errRedactionFieldIsNotAMessage = errors.New("field is not a message")
+	errCouldntRedactValue          = errors.New("couldn't redact value")
+)
+
+type RedactionAction uint
+
+const (
+	RedactionMask RedactionAction = iota
+	RedactionHash
+	RedactionDrop
+)
+
+func (a RedactionAction) String() string {
+	switch a {
+	case RedactionMask:
+		return "mask"
+	case RedactionHash:
+		return "hash"
+	case RedactionDrop:
+		return "drop"
+	default:
+		return "unknown"
+	}
+}
+
+// RedactionRule redacts the values found at a path in a JSON document. Each
+// segment of the path holds the object keys it matches; a nil segment matches
+// any key. Arrays are traversed implicitly, ie, a rule applies to all elements
+// of the arrays it comes across.
+type RedactionRule struct {
+	Path     string
+	Segments [][]string
+	Action   RedactionAction
+	// if set, hashes are computed as HMAC-SHA256 digests keyed with it;
+	// plain SHA-256 hashes of low-entropy values (eg. emails, phone numbers)
+	// can be reversed by hashing candidate values
+	HashKey []byte
+}
+
+func (r RedactionRule) String() string {
+	if r.Action == RedactionHash && len(r.HashKey) > 0 {
+		return fmt.Sprintf("%s (%s, keyed)", r.Path, r.Action)
+	}
+
+	return fmt.Sprintf("%s (%s)", r.Path, r.Action)
+}
+
+// ParseRedactionPath splits a dot separated path (optionally prefixed with
+// "$.") into segments. When a message descriptor is provided, segments are
+// looked up as protobuf fields, and match both the field's name and its JSON
+// name; segments following map fields are treated as map keys.
+func ParseRedactionPath(path string, msgDescriptor protoreflect.MessageDescriptor) ([][]string, error) {
+	trimmed := strings.TrimPrefix(strings.TrimSpace(path), "$.")
+	if trimmed == "" {
+		return nil, errRedactionPathEmpty
+	}
+
+	parts := strings.Split(trimmed, ".")
+	segments := make([][]string, len(parts))
+
+	md := msgDescriptor
+	var mapValue protoreflect.FieldDescriptor
+	for i, part := range parts {
+		if part == "" {
+			return nil, fmt.Errorf("%w: %q has an empty segment", errRedactionPathInvalid, path)
+		}
+
+		if part != redactionWildcard {
+			segments[i] = []string{part}
+		}
+
+		if mapValue != nil {
+			md = getRedactableMessage(mapValue)
+			mapValue = nil
+			continue
+		}
+
+		if md == nil {
+			continue
+		}
+
+		if part == redactionWildcard {
+			md = nil
+			continue
+		}
+
+		fd := md.Fields().ByName(protoreflect.Name(part))
+		if fd == nil {
+			fd = md.Fields().ByJSONName(part)
+		}
+		if fd == nil {
+			return nil, fmt.Errorf("%w: %q (in %s)", errRedactionFieldNotFound, part, md.FullName())
+		}
+
+		segments[i] = []string{string(fd.Name())}
+		if fd.JSONName() != string(fd.Name()) {
+			segments[i] = append(segments[i], fd.JSONName())
+		}
+
+		isLast := i == len(parts)-1
+		switch {
+		case fd.IsMap():
+			mapValue = fd.MapValue()
+		case fd.Message() != nil:
+			md = getRedactableMessage(fd)
+		case !isLast:
+			return nil, fmt.Errorf("%w: %q", errRedactionFieldIsNotAMessage, part)
+		}
+	}
+
+	return segments, nil
+}
+
+// getRedactableMessage returns the message descriptor that the JSON value of
+// a field can be checked against. Well-known types have special JSON
+// representations, and aren't checked.
+func getRedactableMessage(fd protoreflect.FieldDescriptor) protoreflect.MessageDescriptor {
+	md := fd.Message()
+	if md == nil || md.FullName().Parent() == "google.protobuf" {
+		return nil
+	}
+
+	return md
+}
+
+// RedactJSON applies redaction rules to a JSON document, and returns the
+// redacted document (indented the same way as PrettifyJSON's output), along
+// with the rules that matched at least one value.
+func RedactJSON(data []byte, rules []RedactionRule) ([]byte, []RedactionRule, error) {
+	matchers := make([]redactionMatcher, len(rules))
+	for i := range rules {
+		matchers[i] = redactionMatcher{ruleIndex: i, segments: rules[i].Segments}
+	}
+
+	applied := make([]bool, len(rules))
+	redacted, err := redactJSONValue(json.RawMessage(data), rules, matchers, applied)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %s", errCouldntRedactValue, err.Error())
+	}
+
+	var out bytes.Buffer
+	err = json.Indent(&out, redacted, "", "  ")
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %s", errCouldntRedactValue, err.Error())
+	}
+
+	var appliedRules []RedactionRule
+	for i, ok := range applied {
+		if ok {
+			appliedRules = append(appliedRules, rules[i])
+		}
+	}
+
+	return out.Bytes(), appliedRules, nil
+}
+
+type redactionMatcher struct {
+	ruleIndex int
+	segments  [][]string
+}
+
+func (m redactionMatcher) matches(key string) bool {
+	names := m.segments[0]
+	if names == nil {
+		return true
+	}
+
+	for _, name := range names {
+		if name == key {
+			return true
+		}
+	}
+
+	return false
+}
+
+func redactJSONValue(data json.RawMessage, rules []RedactionRule, matchers []redactionMatcher, applied []bool) (json.RawMessage, error) {
+	trimmed := bytes.TrimSpace(data)
+	if len(matchers) == 0 || len(trimmed) == 0 {
+		return trimmed, nil
+	}
+
+	switch trimmed[0] {
+	case '{':
+		return redactJSONObject(trimmed, rules, matchers, applied)
+	case '[':
+		return redactJSONArray(trimmed, rules, matchers, applied)
+	default:
+		return trimmed, nil
+	}
+}
+
+func redactJSONObject(data json.RawMessage, rules []RedactionRule, matchers []redactionMatcher, applied []bool) (json.RawMessage, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if _, err := decoder.Token(); err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	out.WriteByte('{')
+	numMembers := 0
+
+	for decoder.More() {
+		keyToken, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		key, ok := keyToken.(string)
+		if !ok {
+			return nil, fmt.Errorf("unexpected object key: %v", keyToken)
+		}
+
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return nil, err
+		}
+
+		terminal := -1
+		var nested []redactionMatcher
+		for _, m := range matchers {
+			if !m.matches(key) {
+				continue
+			}
+
+			if len(m.segments) == 1 {
+				if terminal == -1 {
+					terminal = m.ruleIndex
+				}
+				continue
+			}
+
+			nested = append(nested, redactionMatcher{ruleIndex: m.ruleIndex, segments: m.segments[1:]})
+		}
+
+		if terminal != -1 {
+			applied[terminal] = true
+			if rules[terminal].Action == RedactionDrop {
+				continue
+			}
+			value = redactedJSONValue(value, rules[terminal])
+		} else if len(nested) > 0 {
+			value, err = redactJSONValue(value, rules, nested, applied)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if numMembers > 0 {
+			out.WriteByte(',')
+		}
+
+		// object keys are written without escaping HTML characters, which
+		// json.Marshal would do
+		keyEncoder := json.NewEncoder(&out)
+		keyEncoder.SetEscapeHTML(false)
+		if err := keyEncoder.Encode(key); err != nil {
+			return nil, err
+		}
+		out.Truncate(out.Len() - 1)
+		out.WriteByte(':')
+		out.Write(value)
+		numMembers++
+	}
+
+	if _, err := decoder.Token(); err != nil {
+		return nil, err
+	}
+
+	out.WriteByte('}')
+
+	return out.Bytes(), nil
+}
+
+func redactJSONArray(data json.RawMessage, rules []RedactionRule, matchers []redactionMatcher, applied []bool) (json.RawMessage, error) {
+	var elements []json.RawMessage
+	if err := json.Unmarshal(data, &elements); err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	out.WriteByte('[')
+	for i, element := range elements {
+		redacted, err := redactJSONValue(element, rules, matchers, applied)
+		if err != nil {
+			return nil, err
+		}
+
+		if i > 0 {
+			out.WriteByte(',')
+		}
+		out.Write(redacted)
+	}
+	out.WriteByte(']')
+
+	return out.Bytes(), nil
+}
+
+// redactedJSONValue returns the replacement for a value. Hashes are computed
+// over the contents of strings, and over the compact JSON representation of
+// every other value, so that equal values can still be correlated.
+func redactedJSONValue(value json.RawMessage, rule RedactionRule) json.RawMessage {
+	var replacement string
+	switch rule.Action {
+	case RedactionHash:
+		var str string
+		toHash := []byte(value)
+		if err := json.Unmarshal(value, &str); err == nil {
+			toHash = []byte(str)
+		} else if compacted, err := CompactJSON(value); err == nil {
+			toHash = compacted
+		}
+
+		if len(rule.HashKey) > 0 {
+			mac := hmac.New(sha256.New, rule.HashKey)
+			mac.Write(toHash)
+			replacement = "hmac-sha256:" + hex.EncodeToString(mac.Sum(nil))
+		} else {
+			sum := sha256.Sum256(toHash)
+			replacement = "sha256:" + hex.EncodeToString(sum[:])
+		}
+	default:
+		replacement = redactionMask
+	}
+
+	replacementBytes, _ := json.Marshal(replacement)
+
+	return replacementBytes
+}
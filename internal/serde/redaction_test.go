@@ -0,0 +1,345 @@
+package serde
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+const testRedactionValue = `{
+  "id": "o-1",
+  "customer": {
+    "email": "user@example.com",
+    "name": "Some User"
+  },
+  "payments": [
+    {
+      "cardNumber": "4111111111111111",
+      "amount": 10
+    },
+    {
+      "cardNumber": "5500000000000004",
+      "amount": 20
+    }
+  ]
+}`
+
+func getTestRedactionRule(t *testing.T, path string, action RedactionAction) RedactionRule {
+	t.Helper()
+
+	segments, err := ParseRedactionPath(path, nil)
+	require.NoError(t, err)
+
+	return RedactionRule{Path: path, Segments: segments, Action: action}
+}
+
+func TestRedactJSON(t *testing.T) {
+	testCases := []struct {
+		name     string
+		path     string
+		action   RedactionAction
+		expected string
+	}{
+		{
+			name:   "masking a nested field",
+			path:   "customer.email",
+			action: RedactionMask,
+			expected: `{
+  "id": "o-1",
+  "customer": {
+    "email": "****",
+    "name": "Some User"
+  },
+  "payments": [
+    {
+      "cardNumber": "4111111111111111",
+      "amount": 10
+    },
+    {
+      "cardNumber": "5500000000000004",
+      "amount": 20
+    }
+  ]
+}`,
+		},
+		{
+			name:   "hashing a field in arrays",
+			path:   "$.payments.cardNumber",
+			action: RedactionHash,
+			expected: `{
+  "id": "o-1",
+  "customer": {
+    "email": "user@example.com",
+    "name": "Some User"
+  },
+  "payments": [
+    {
+      "cardNumber": "sha256:9bbef19476623ca56c17da75fd57734dbf82530686043a6e491c6d71befe8f6e",
+      "amount": 10
+    },
+    {
+      "cardNumber": "sha256:dd1ed74bd626c6fa86bdffd25029a46c9d585e0429cc9f00a8c94e90f837d993",
+      "amount": 20
+    }
+  ]
+}`,
+		},
+		{
+			name:   "dropping fields via a wildcard",
+			path:   "customer.*",
+			action: RedactionDrop,
+			expected: `{
+  "id": "o-1",
+  "customer": {},
+  "payments": [
+    {
+      "cardNumber": "4111111111111111",
+      "amount": 10
+    },
+    {
+      "cardNumber": "5500000000000004",
+      "amount": 20
+    }
+  ]
+}`,
+		},
+		{
+			name:     "path that doesn't match",
+			path:     "customer.address",
+			action:   RedactionMask,
+			expected: testRedactionValue,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := getTestRedactionRule(t, tt.path, tt.action)
+
+			got, applied, err := RedactJSON([]byte(testRedactionValue), []RedactionRule{rule})
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, string(got))
+			if tt.expected == testRedactionValue {
+				assert.Empty(t, applied)
+			} else {
+				assert.Equal(t, []RedactionRule{rule}, applied)
+			}
+		})
+	}
+}
+
+func TestRedactJSONHashesWithAKeyIfProvided(t *testing.T) {
+	testCases := []struct {
+		name     string
+		key      string
+		expected string
+	}{
+		{
+			name:     "key",
+			key:      "s3cret",
+			expected: "hmac-sha256:f637bba887407a8f5d895f319977d0c00a0a69db672e40e5d980a900a842a6c1",
+		},
+		{
+			name:     "another key",
+			key:      "other",
+			expected: "hmac-sha256:f0b48ddfa9a08555247e980b30eb8ecaa81931c0ff15453e98b4c020aab62a56",
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := getTestRedactionRule(t, "customer.email", RedactionHash)
+			rule.HashKey = []byte(tt.key)
+
+			got, _, err := RedactJSON([]byte(testRedactionValue), []RedactionRule{rule})
+
+			require.NoError(t, err)
+			assert.Contains(t, string(got), fmt.Sprintf(`"email": %q`, tt.expected))
+		})
+	}
+}
+
+func TestRedactJSONUsesFirstMatchingRule(t *testing.T) {
+	// GIVEN
+	rules := []RedactionRule{
+		getTestRedactionRule(t, "customer.email", RedactionDrop),
+		getTestRedactionRule(t, "customer.*", RedactionMask),
+	}
+
+	// WHEN
+	got, applied, err := RedactJSON([]byte(testRedactionValue), rules)
+
+	// THEN
+	require.NoError(t, err)
+	assert.Contains(t, string(got), `"customer": {
+    "name": "****"
+  }`)
+	assert.Equal(t, rules, applied)
+}
+
+func TestRedactJSONFailsForInvalidJSON(t *testing.T) {
+	// GIVEN
+	rule := getTestRedactionRule(t, "id", RedactionMask)
+
+	// WHEN
+	_, _, err := RedactJSON([]byte(`{"id": `), []RedactionRule{rule})
+
+	// THEN
+	assert.ErrorIs(t, err, errCouldntRedactValue)
+}
+
+func getTestRedactionMsgDescriptor(t *testing.T) protoreflect.MessageDescriptor {
+	t.Helper()
+
+	fileDescriptorProto := &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("redaction.proto"),
+		Package: proto.String("sample"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name: proto.String("Payment"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					{
+						Name:     proto.String("card_number"),
+						JsonName: proto.String("cardNumber"),
+						Number:   proto.Int32(1),
+						Type:     descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+						Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+					},
+				},
+			},
+			{
+				Name: proto.String("Order"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					{
+						Name:   proto.String("id"),
+						Number: proto.Int32(1),
+						Type:   descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+						Label:  descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+					},
+					{
+						Name:     proto.String("payments"),
+						Number:   proto.Int32(2),
+						Type:     descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum(),
+						TypeName: proto.String(".sample.Payment"),
+						Label:    descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum(),
+					},
+					{
+						Name:     proto.String("payments_by_id"),
+						JsonName: proto.String("paymentsById"),
+						Number:   proto.Int32(3),
+						Type:     descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum(),
+						TypeName: proto.String(".sample.Order.PaymentsByIdEntry"),
+						Label:    descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum(),
+					},
+				},
+				NestedType: []*descriptorpb.DescriptorProto{
+					{
+						Name: proto.String("PaymentsByIdEntry"),
+						Field: []*descriptorpb.FieldDescriptorProto{
+							{
+								Name:   proto.String("key"),
+								Number: proto.Int32(1),
+								Type:   descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+								Label:  descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+							},
+							{
+								Name:     proto.String("value"),
+								Number:   proto.Int32(2),
+								Type:     descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum(),
+								TypeName: proto.String(".sample.Payment"),
+								Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+							},
+						},
+						Options: &descriptorpb.MessageOptions{MapEntry: proto.Bool(true)},
+					},
+				},
+			},
+		},
+	}
+
+	fileDescriptor, err := protodesc.NewFile(fileDescriptorProto, nil)
+	require.NoError(t, err)
+
+	return fileDescriptor.Messages().ByName("Order")
+}
+
+func TestParseRedactionPathWithProtoDescriptor(t *testing.T) {
+	md := getTestRedactionMsgDescriptor(t)
+
+	testCases := []struct {
+		name     string
+		path     string
+		expected [][]string
+	}{
+		{
+			name:     "proto field names",
+			path:     "payments.card_number",
+			expected: [][]string{{"payments"}, {"card_number", "cardNumber"}},
+		},
+		{
+			name:     "JSON field names",
+			path:     "payments.cardNumber",
+			expected: [][]string{{"payments"}, {"card_number", "cardNumber"}},
+		},
+		{
+			name:     "map keys",
+			path:     "paymentsById.*.card_number",
+			expected: [][]string{{"payments_by_id", "paymentsById"}, nil, {"card_number", "cardNumber"}},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRedactionPath(tt.path, md)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestParseRedactionPathFails(t *testing.T) {
+	md := getTestRedactionMsgDescriptor(t)
+
+	testCases := []struct {
+		name     string
+		path     string
+		expected error
+	}{
+		{
+			name:     "empty path",
+			path:     " ",
+			expected: errRedactionPathEmpty,
+		},
+		{
+			name:     "empty segment",
+			path:     "payments..card_number",
+			expected: errRedactionPathInvalid,
+		},
+		{
+			name:     "unknown field",
+			path:     "payments.cvv",
+			expected: errRedactionFieldNotFound,
+		},
+		{
+			name:     "segment after a scalar field",
+			path:     "id.value",
+			expected: errRedactionFieldIsNotAMessage,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseRedactionPath(tt.path, md)
+
+			assert.ErrorIs(t, err, tt.expected)
+		})
+	}
+}
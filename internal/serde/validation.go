@@ -0,0 +1,287 @@
+package serde
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// field numbers of the "(buf.validate.field).required" option; see
+// https://github.com/bufbuild/protovalidate/blob/main/proto/protovalidate/buf/validate/validate.proto
+const (
+	bufValidateFieldOptionNumber   protowire.Number = 1159
+	bufValidateRequiredFieldNumber protowire.Number = 25
+)
+
+var (
+	errCouldntReadJSONSchema    = errors.New("couldn't read JSON schema file")
+	errCouldntCompileJSONSchema = errors.New("couldn't compile JSON schema")
+	errCouldntParseJSON         = errors.New("couldn't parse JSON")
+)
+
+// ProtoValidationOptions controls which checks ValidateProto performs.
+// RequiredFieldOption is the number of a custom bool field option that marks
+// fields as required; 0 means there's none.
+type ProtoValidationOptions struct {
+	RejectUnknownFields bool
+	CheckRequiredFields bool
+	RequiredFieldOption protowire.Number
+}
+
+func CompileJSONSchema(schemaFilePath string) (*jsonschema.Schema, error) {
+	schemaBytes, err := os.ReadFile(schemaFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errCouldntReadJSONSchema, err.Error())
+	}
+
+	schemaDoc, err := jsonschema.UnmarshalJSON(bytes.NewReader(schemaBytes))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errCouldntCompileJSONSchema, err.Error())
+	}
+
+	compiler := jsonschema.NewCompiler()
+	err = compiler.AddResource(schemaFilePath, schemaDoc)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errCouldntCompileJSONSchema, err.Error())
+	}
+
+	schema, err := compiler.Compile(schemaFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errCouldntCompileJSONSchema, err.Error())
+	}
+
+	return schema, nil
+}
+
+// ValidateJSON returns the ways in which data violates schema, one per
+// offending location.
+func ValidateJSON(data []byte, schema *jsonschema.Schema) ([]string, error) {
+	value, err := jsonschema.UnmarshalJSON(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errCouldntParseJSON, err.Error())
+	}
+
+	err = schema.Validate(value)
+	if err == nil {
+		return nil, nil
+	}
+
+	var validationErr *jsonschema.ValidationError
+	if !errors.As(err, &validationErr) {
+		return nil, err
+	}
+
+	var violations []string
+	for _, unit := range validationErr.BasicOutput().Errors {
+		if unit.Error == nil || len(unit.Errors) > 0 {
+			continue
+		}
+
+		location := unit.InstanceLocation
+		if location == "" {
+			location = "/"
+		}
+
+		violations = append(violations, fmt.Sprintf("%s: %s", location, unit.Error.String()))
+	}
+
+	if len(violations) == 0 {
+		violations = append(violations, validationErr.Error())
+	}
+
+	return violations, nil
+}
+
+// ValidateProto returns the unknown fields and the missing required fields of
+// a protobuf encoded message, including the ones in nested messages.
+func ValidateProto(data []byte, msgDescriptor protoreflect.MessageDescriptor, resolver ProtoResolver, options ProtoValidationOptions) ([]string, error) {
+	msg := dynamicpb.NewMessage(msgDescriptor)
+
+	unmarshalOptions := proto.UnmarshalOptions{
+		AllowPartial: true,
+	}
+	if resolver != nil {
+		unmarshalOptions.Resolver = resolver
+	}
+
+	err := unmarshalOptions.Unmarshal(data, msg)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errCouldntUnmarshalProtoMsg, err.Error())
+	}
+
+	var violations []string
+	validateProtoMsg(msg, "", options, &violations)
+
+	return violations, nil
+}
+
+func validateProtoMsg(msg protoreflect.Message, path string, options ProtoValidationOptions, violations *[]string) {
+	if options.RejectUnknownFields {
+		if unknown := getUnknownFieldNumbers(msg.GetUnknown()); len(unknown) > 0 {
+			*violations = append(*violations, fmt.Sprintf("%s: unknown fields %v", displayPath(path), unknown))
+		}
+	}
+
+	fields := msg.Descriptor().Fields()
+	for i := range fields.Len() {
+		fd := fields.Get(i)
+		if options.CheckRequiredFields && !msg.Has(fd) && isRequiredField(fd, options.RequiredFieldOption) {
+			*violations = append(*violations, fmt.Sprintf("%s: missing required field", joinPath(path, string(fd.Name()))))
+		}
+	}
+
+	msg.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		if fd.Message() == nil {
+			return true
+		}
+
+		fieldPath := joinPath(path, string(fd.Name()))
+		switch {
+		case fd.IsList():
+			list := v.List()
+			for i := range list.Len() {
+				validateProtoMsg(list.Get(i).Message(), fmt.Sprintf("%s[%d]", fieldPath, i), options, violations)
+			}
+		case fd.IsMap():
+			if fd.MapValue().Message() == nil {
+				return true
+			}
+			v.Map().Range(func(k protoreflect.MapKey, mv protoreflect.Value) bool {
+				validateProtoMsg(mv.Message(), fmt.Sprintf("%s[%v]", fieldPath, k.Interface()), options, violations)
+				return true
+			})
+		default:
+			validateProtoMsg(v.Message(), fieldPath, options, violations)
+		}
+
+		return true
+	})
+}
+
+// isRequiredField reports whether a field is required, either via the proto2
+// "required" label, the "(buf.validate.field).required" option, or a custom bool
+// option. Options that the binary doesn't know about are retained as unknown
+// fields of the field's options, which is where they're looked up.
+func isRequiredField(fd protoreflect.FieldDescriptor, customOption protowire.Number) bool {
+	if fd.Cardinality() == protoreflect.Required {
+		return true
+	}
+
+	fieldOptions, ok := fd.Options().(*descriptorpb.FieldOptions)
+	if !ok || fieldOptions == nil {
+		return false
+	}
+
+	rawOptions := fieldOptions.ProtoReflect().GetUnknown()
+
+	if fieldRules, ok := findBytesField(rawOptions, bufValidateFieldOptionNumber); ok && isBoolFieldSet(fieldRules, bufValidateRequiredFieldNumber) {
+		return true
+	}
+
+	return customOption != 0 && isBoolFieldSet(rawOptions, customOption)
+}
+
+func findBytesField(data []byte, number protowire.Number) ([]byte, bool) {
+	remaining := data
+	for len(remaining) > 0 {
+		fieldNum, wireType, n := protowire.ConsumeTag(remaining)
+		if n < 0 {
+			return nil, false
+		}
+		remaining = remaining[n:]
+
+		if fieldNum == number && wireType == protowire.BytesType {
+			value, n := protowire.ConsumeBytes(remaining)
+			if n < 0 {
+				return nil, false
+			}
+			return value, true
+		}
+
+		n = protowire.ConsumeFieldValue(fieldNum, wireType, remaining)
+		if n < 0 {
+			return nil, false
+		}
+		remaining = remaining[n:]
+	}
+
+	return nil, false
+}
+
+func isBoolFieldSet(data []byte, number protowire.Number) bool {
+	remaining := data
+	for len(remaining) > 0 {
+		fieldNum, wireType, n := protowire.ConsumeTag(remaining)
+		if n < 0 {
+			return false
+		}
+		remaining = remaining[n:]
+
+		if fieldNum == number && wireType == protowire.VarintType {
+			value, n := protowire.ConsumeVarint(remaining)
+			if n < 0 {
+				return false
+			}
+			return value != 0
+		}
+
+		n = protowire.ConsumeFieldValue(fieldNum, wireType, remaining)
+		if n < 0 {
+			return false
+		}
+		remaining = remaining[n:]
+	}
+
+	return false
+}
+
+func getUnknownFieldNumbers(data []byte) []protowire.Number {
+	var numbers []protowire.Number
+
+	remaining := data
+	for len(remaining) > 0 {
+		fieldNum, wireType, n := protowire.ConsumeTag(remaining)
+		if n < 0 {
+			break
+		}
+		remaining = remaining[n:]
+
+		n = protowire.ConsumeFieldValue(fieldNum, wireType, remaining)
+		if n < 0 {
+			break
+		}
+		remaining = remaining[n:]
+
+		if !slices.Contains(numbers, fieldNum) {
+			numbers = append(numbers, fieldNum)
+		}
+	}
+
+	return numbers
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+
+	return strings.Join([]string{path, name}, ".")
+}
+
+func displayPath(path string) string {
+	if path == "" {
+		return "message"
+	}
+
+	return path
+}
@@ -0,0 +1,193 @@
+package serde
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+const testJSONSchema = `{
+  "type": "object",
+  "properties": {
+    "id": {"type": "string"},
+    "count": {"type": "integer", "minimum": 0}
+  },
+  "required": ["id"]
+}`
+
+func TestValidateJSON(t *testing.T) {
+	schemaPath := filepath.Join(t.TempDir(), "schema.json")
+	require.NoError(t, os.WriteFile(schemaPath, []byte(testJSONSchema), 0o644))
+	schema, err := CompileJSONSchema(schemaPath)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name     string
+		data     string
+		expected []string
+	}{
+		{
+			name: "valid value",
+			data: `{"id": "abc", "count": 1}`,
+		},
+		{
+			name:     "missing required property",
+			data:     `{"count": 1}`,
+			expected: []string{"/: missing property 'id'"},
+		},
+		{
+			name:     "multiple violations",
+			data:     `{"id": 1, "count": -1}`,
+			expected: []string{"/id: got number, want string", "/count: minimum: got -1, want 0"},
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ValidateJSON([]byte(tt.data), schema)
+
+			require.NoError(t, err)
+			assert.ElementsMatch(t, tt.expected, got)
+		})
+	}
+}
+
+func TestCompileJSONSchemaFailsForInvalidSchema(t *testing.T) {
+	// GIVEN
+	schemaPath := filepath.Join(t.TempDir(), "schema.json")
+	require.NoError(t, os.WriteFile(schemaPath, []byte(`{"type": 1}`), 0o644))
+
+	// WHEN
+	_, err := CompileJSONSchema(schemaPath)
+
+	// THEN
+	assert.ErrorIs(t, err, errCouldntCompileJSONSchema)
+}
+
+func getTestValidationMsgDescriptor(t *testing.T) protoreflect.MessageDescriptor {
+	t.Helper()
+
+	// (buf.validate.field).required = true
+	var fieldRules []byte
+	fieldRules = protowire.AppendTag(fieldRules, bufValidateRequiredFieldNumber, protowire.VarintType)
+	fieldRules = protowire.AppendVarint(fieldRules, 1)
+	var bufValidateOption []byte
+	bufValidateOption = protowire.AppendTag(bufValidateOption, bufValidateFieldOptionNumber, protowire.BytesType)
+	bufValidateOption = protowire.AppendBytes(bufValidateOption, fieldRules)
+	bufValidateOptions := &descriptorpb.FieldOptions{}
+	bufValidateOptions.ProtoReflect().SetUnknown(bufValidateOption)
+
+	// a custom option, (sample.required) = true
+	var customOption []byte
+	customOption = protowire.AppendTag(customOption, 50001, protowire.VarintType)
+	customOption = protowire.AppendVarint(customOption, 1)
+	customOptions := &descriptorpb.FieldOptions{}
+	customOptions.ProtoReflect().SetUnknown(customOption)
+
+	fileDescriptorProto := &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("validation.proto"),
+		Package: proto.String("sample"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name: proto.String("Item"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					{
+						Name:    proto.String("sku"),
+						Number:  proto.Int32(1),
+						Type:    descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+						Label:   descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+						Options: bufValidateOptions,
+					},
+				},
+			},
+			{
+				Name: proto.String("Order"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					{
+						Name:    proto.String("id"),
+						Number:  proto.Int32(1),
+						Type:    descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+						Label:   descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+						Options: bufValidateOptions,
+					},
+					{
+						Name:    proto.String("customer"),
+						Number:  proto.Int32(2),
+						Type:    descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+						Label:   descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+						Options: customOptions,
+					},
+					{
+						Name:     proto.String("items"),
+						Number:   proto.Int32(3),
+						Type:     descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum(),
+						TypeName: proto.String(".sample.Item"),
+						Label:    descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum(),
+					},
+				},
+			},
+		},
+	}
+
+	fileDescriptor, err := protodesc.NewFile(fileDescriptorProto, nil)
+	require.NoError(t, err)
+
+	return fileDescriptor.Messages().ByName("Order")
+}
+
+func TestValidateProto(t *testing.T) {
+	// id: "o-1", items: [{}, {sku: "s-1", 9: 1}], 7: 1
+	data := []byte{
+		0x0a, 0x03, 0x6f, 0x2d, 0x31, // field 1: "o-1"
+		0x1a, 0x00, // field 3: empty item
+		0x1a, 0x07, 0x0a, 0x03, 0x73, 0x2d, 0x31, 0x48, 0x01, // field 3: item with sku and unknown field 9
+		0x38, 0x01, // field 7 (unknown): varint 1
+	}
+
+	testCases := []struct {
+		name     string
+		options  ProtoValidationOptions
+		expected []string
+	}{
+		{
+			name:     "no checks",
+			options:  ProtoValidationOptions{},
+			expected: nil,
+		},
+		{
+			name:     "unknown fields",
+			options:  ProtoValidationOptions{RejectUnknownFields: true},
+			expected: []string{"message: unknown fields [7]", "items[1]: unknown fields [9]"},
+		},
+		{
+			name:     "required fields",
+			options:  ProtoValidationOptions{CheckRequiredFields: true},
+			expected: []string{"items[0].sku: missing required field"},
+		},
+		{
+			name:     "required fields with a custom option",
+			options:  ProtoValidationOptions{CheckRequiredFields: true, RequiredFieldOption: 50001},
+			expected: []string{"customer: missing required field", "items[0].sku: missing required field"},
+		},
+	}
+
+	msgDescriptor := getTestValidationMsgDescriptor(t)
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ValidateProto(data, msgDescriptor, nil, tt.options)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
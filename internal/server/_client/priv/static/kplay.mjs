@@ -4286,21 +4286,49 @@ var Config = class extends CustomType {
   }
 };
 var Behaviours = class extends CustomType {
-  constructor(select_on_hover) {
+  constructor(select_on_hover, hex_view) {
     super();
     this.select_on_hover = select_on_hover;
+    this.hex_view = hex_view;
+  }
+};
+var Header = class extends CustomType {
+  constructor(key2, value2, decode_error2) {
+    super();
+    this.key = key2;
+    this.value = value2;
+    this.decode_error = decode_error2;
+  }
+};
+var Metadata = class extends CustomType {
+  constructor(timestamp, timestamp_type, leader_epoch, producer_id, producer_epoch, value_size, compression, value_compression, decompressed_value_size, redactions) {
+    super();
+    this.timestamp = timestamp;
+    this.timestamp_type = timestamp_type;
+    this.leader_epoch = leader_epoch;
+    this.producer_id = producer_id;
+    this.producer_epoch = producer_epoch;
+    this.value_size = value_size;
+    this.compression = compression;
+    this.value_compression = value_compression;
+    this.decompressed_value_size = decompressed_value_size;
+    this.redactions = redactions;
   }
 };
 var MessageDetails = class extends CustomType {
-  constructor(key2, offset, partition, metadata, value2, decode_error2, decode_error_fallback) {
+  constructor(key2, key_decode_error, offset, partition, metadata, headers, value2, hex_dump, decode_error2, decode_error_fallback, redacted) {
     super();
     this.key = key2;
+    this.key_decode_error = key_decode_error;
     this.offset = offset;
     this.partition = partition;
     this.metadata = metadata;
+    this.headers = headers;
     this.value = value2;
+    this.hex_dump = hex_dump;
     this.decode_error = decode_error2;
     this.decode_error_fallback = decode_error_fallback;
+    this.redacted = redacted;
   }
 };
 var ConfigFetched = class extends CustomType {
@@ -4329,6 +4357,12 @@ var HoverSettingsChanged = class extends CustomType {
     this[0] = $0;
   }
 };
+var HexViewChanged = class extends CustomType {
+  constructor($0) {
+    super();
+    this[0] = $0;
+  }
+};
 var MessageChosen = class extends CustomType {
   constructor($0) {
     super();
@@ -4376,57 +4410,206 @@ function display_config(config) {
   return join(_pipe, "\n");
 }
 function default_behaviours() {
-  return new Behaviours(false);
+  return new Behaviours(false, false);
 }
 function behaviours_decoder() {
   return field2(
     "select_on_hover",
     bool2,
     (select_on_hover) => {
-      return success(new Behaviours(select_on_hover));
+      return optional_field(
+        "hex_view",
+        false,
+        bool2,
+        (hex_view) => {
+          return success(new Behaviours(select_on_hover, hex_view));
+        }
+      );
     }
   );
 }
-function message_details_decoder() {
+function header_decoder() {
   return field2(
     "key",
     string3,
     (key2) => {
       return field2(
-        "offset",
-        int2,
-        (offset) => {
+        "value",
+        string3,
+        (value2) => {
+          return optional_field(
+            "decode_error",
+            new None(),
+            optional(string3),
+            (decode_error2) => {
+              return success(new Header(key2, value2, decode_error2));
+            }
+          );
+        }
+      );
+    }
+  );
+}
+function display_header(header) {
+  let $ = header.decode_error;
+  if ($ instanceof Some) {
+    let e = $[0];
+    return header.key + ": " + header.value + " (decode error: " + e + ")";
+  } else {
+    return header.key + ": " + header.value;
+  }
+}
+function metadata_decoder() {
+  return field2(
+    "timestamp",
+    string3,
+    (timestamp) => {
+      return field2(
+        "timestamp_type",
+        string3,
+        (timestamp_type) => {
           return field2(
-            "partition",
+            "leader_epoch",
             int2,
-            (partition) => {
+            (leader_epoch) => {
               return field2(
-                "metadata",
-                string3,
-                (metadata) => {
+                "producer_id",
+                int2,
+                (producer_id) => {
                   return field2(
-                    "value",
-                    optional(string3),
-                    (value2) => {
+                    "producer_epoch",
+                    int2,
+                    (producer_epoch) => {
                       return field2(
-                        "decode_error",
-                        optional(string3),
-                        (decode_error2) => {
-                          return optional_field(
-                            "decode_error_fallback",
-                            new None(),
+                        "value_size",
+                        int2,
+                        (value_size) => {
+                          return field2(
+                            "compression",
+                            string3,
+                            (compression) => {
+                              return optional_field(
+                                "value_compression",
+                                new None(),
+                                optional(string3),
+                                (value_compression) => {
+                                  return optional_field(
+                                    "decompressed_value_size",
+                                    0,
+                                    int2,
+                                    (decompressed_value_size) => {
+                                      return optional_field(
+                                        "redactions",
+                                        toList([]),
+                                        list2(string3),
+                                        (redactions) => {
+                                          return success(
+                                            new Metadata(
+                                              timestamp,
+                                              timestamp_type,
+                                              leader_epoch,
+                                              producer_id,
+                                              producer_epoch,
+                                              value_size,
+                                              compression,
+                                              value_compression,
+                                              decompressed_value_size,
+                                              redactions
+                                            )
+                                          );
+                                        }
+                                      );
+                                    }
+                                  );
+                                }
+                              );
+                            }
+                          );
+                        }
+                      );
+                    }
+                  );
+                }
+              );
+            }
+          );
+        }
+      );
+    }
+  );
+}
+function message_details_decoder() {
+  return field2(
+    "key",
+    string3,
+    (key2) => {
+      return optional_field(
+        "key_decode_error",
+        new None(),
+        optional(string3),
+        (key_decode_error) => {
+          return field2(
+            "offset",
+            int2,
+            (offset) => {
+              return field2(
+                "partition",
+                int2,
+                (partition) => {
+                  return field2(
+                    "metadata",
+                    metadata_decoder(),
+                    (metadata) => {
+                      return optional_field(
+                        "headers",
+                        toList([]),
+                        list2(header_decoder()),
+                        (headers) => {
+                          return field2(
+                            "value",
                             optional(string3),
-                            (decode_error_fallback) => {
-                              return success(
-                                new MessageDetails(
-                                  key2,
-                                  offset,
-                                  partition,
-                                  metadata,
-                                  value2,
-                                  decode_error2,
-                                  decode_error_fallback
-                                )
+                            (value2) => {
+                              return optional_field(
+                                "hex_dump",
+                                new None(),
+                                optional(string3),
+                                (hex_dump) => {
+                                  return field2(
+                                    "decode_error",
+                                    optional(string3),
+                                    (decode_error2) => {
+                                      return optional_field(
+                                        "decode_error_fallback",
+                                        new None(),
+                                        optional(string3),
+                                        (decode_error_fallback) => {
+                                          return optional_field(
+                                            "redacted",
+                                            false,
+                                            bool2,
+                                            (redacted) => {
+                                              return success(
+                                                new MessageDetails(
+                                                  key2,
+                                                  key_decode_error,
+                                                  offset,
+                                                  partition,
+                                                  metadata,
+                                                  headers,
+                                                  value2,
+                                                  hex_dump,
+                                                  decode_error2,
+                                                  decode_error_fallback,
+                                                  redacted
+                                                )
+                                              );
+                                            }
+                                          );
+                                        }
+                                      );
+                                    }
+                                  );
+                                }
                               );
                             }
                           );
@@ -4443,6 +4626,54 @@ function message_details_decoder() {
     }
   );
 }
+function display_metadata(msg) {
+  let metadata = msg.metadata;
+  let _block;
+  let $ = msg.key_decode_error;
+  if ($ instanceof Some) {
+    let e = $[0];
+    _block = toList(["key: " + msg.key, "key decode error: " + e]);
+  } else {
+    _block = toList(["key: " + msg.key]);
+  }
+  let key_lines = _block;
+  let _pipe = toList(["offset: " + to_string(msg.offset)]);
+  let _pipe$1 = append(_pipe, key_lines);
+  let _pipe$2 = append(
+    _pipe$1,
+    toList([
+      "timestamp: " + metadata.timestamp + " (" + metadata.timestamp_type + ")",
+      "partition: " + to_string(msg.partition),
+      "leader epoch: " + to_string(metadata.leader_epoch),
+      "producer id: " + to_string(metadata.producer_id),
+      "producer epoch: " + to_string(metadata.producer_epoch),
+      "value size: " + to_string(metadata.value_size) + " bytes",
+      "compression: " + metadata.compression
+    ])
+  );
+  let _block$1;
+  let $1 = metadata.value_compression;
+  if ($1 instanceof Some) {
+    let c = $1[0];
+    _block$1 = toList([
+      "value compression: " + c,
+      "decompressed size: " + to_string(metadata.decompressed_value_size) + " bytes"
+    ]);
+  } else {
+    _block$1 = toList([]);
+  }
+  let _pipe$3 = append(_pipe$2, _block$1);
+  let _block$2;
+  let $2 = metadata.redactions;
+  if ($2 instanceof Empty) {
+    _block$2 = toList([]);
+  } else {
+    let redactions = $2;
+    _block$2 = toList(["redacted: " + join(redactions, ", ")]);
+  }
+  let _pipe$4 = append(_pipe$3, _block$2);
+  return join(_pipe$4, "\n");
+}
 
 // build/dev/javascript/kplay/effects.mjs
 var dev = false;
@@ -4631,7 +4862,22 @@ function update(model, msg) {
     return [
       new Model2(
         model.config,
-        new Behaviours(selected),
+        new Behaviours(selected, model.behaviours.hex_view),
+        model.messages,
+        model.messages_cache,
+        model.http_error,
+        model.current_message,
+        model.fetching,
+        model.debug
+      ),
+      none()
+    ];
+  } else if (msg instanceof HexViewChanged) {
+    let selected = msg[0];
+    return [
+      new Model2(
+        model.config,
+        new Behaviours(model.behaviours.select_on_hover, selected),
         model.messages,
         model.messages_cache,
         model.http_error,
@@ -4950,6 +5196,33 @@ function message_list_item(message, index5, current_index, select_on_hover) {
     ])
   );
 }
+function headers_section(headers) {
+  if (headers instanceof Empty) {
+    return none2();
+  } else {
+    return div(
+      toList([]),
+      toList([
+        p(
+          toList([class$("text-[#fabd2f] text-lg mb-4")]),
+          toList([text2("Headers")])
+        ),
+        pre(
+          toList([class$("text-[#d5c4a1] text-base mb-8 text-wrap")]),
+          toList([
+            text2(
+              (() => {
+                let _pipe = headers;
+                let _pipe$1 = map2(_pipe, display_header);
+                return join(_pipe$1, "\n");
+              })()
+            )
+          ])
+        )
+      ])
+    );
+  }
+}
 function message_details_pane(model) {
   let _block;
   let $ = model.current_message;
@@ -4965,18 +5238,50 @@ function message_details_pane(model) {
           ),
           pre(
             toList([class$("text-[#d5c4a1] text-base mb-8")]),
-            toList([text2(msg.metadata)])
+            toList([text2(display_metadata(msg))])
           ),
+          headers_section(msg.headers),
           p(
             toList([class$("text-[#fabd2f] text-lg mb-4")]),
-            toList([text2("Value")])
+            toList([
+              text2(
+                (() => {
+                  let $1 = model.behaviours.hex_view;
+                  if ($1) {
+                    return "Value (hex dump)";
+                  } else {
+                    return "Value";
+                  }
+                })()
+              )
+            ])
           )
         ]);
         return append(
           _pipe,
           (() => {
             let $1 = msg.decode_error;
-            if ($1 instanceof Some) {
+            if (model.behaviours.hex_view) {
+              return toList([
+                (() => {
+                  let $2 = msg.hex_dump;
+                  if ($2 instanceof Some) {
+                    let h = $2[0];
+                    return pre(
+                      toList([class$("text-[#d5c4a1] text-base mb-4")]),
+                      toList([text2(h)])
+                    );
+                  } else if (msg.redacted) {
+                    return p(toList([]), toList([text2("value redacted")]));
+                  } else {
+                    return p(
+                      toList([]),
+                      toList([text2("tombstone \u{1FAA6}")])
+                    );
+                  }
+                })()
+              ]);
+            } else if ($1 instanceof Some) {
               let e = $1[0];
               return toList([
                 div(
@@ -5294,6 +5599,33 @@ function controls_div_with_config(model, config) {
                 ])
               )
             ])
+          ),
+          div(
+            toList([class$("flex items-center space-x-2")]),
+            toList([
+              label(
+                toList([
+                  class$("cursor-pointer"),
+                  for$("hex-view-control-input")
+                ]),
+                toList([text("hex view")])
+              ),
+              input(
+                toList([
+                  class$(
+                    "w-4 h-4 text-[#fabd2f] bg-[#282828] focus:ring-[#fabd2f] cursor-pointer"
+                  ),
+                  id("hex-view-control-input"),
+                  type_("checkbox"),
+                  on_check(
+                    (var0) => {
+                      return new HexViewChanged(var0);
+                    }
+                  ),
+                  checked(model.behaviours.hex_view)
+                ])
+              )
+            ])
           )
         ])
       )
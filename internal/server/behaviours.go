@@ -1,14 +1,39 @@
 package server
 
-import "fmt"
+import (
+	"fmt"
+
+	"github.com/dhth/kplay/internal/filter"
+	k "github.com/dhth/kplay/internal/kafka"
+	t "github.com/dhth/kplay/internal/types"
+)
 
 type Behaviours struct {
-	SelectOnHover bool `json:"select_on_hover"`
+	SelectOnHover bool           `json:"select_on_hover"`
+	HexView       bool           `json:"hex_view"`
+	Filter        *filter.Filter `json:"-"`
+	Merge         *k.MergeMode   `json:"-"`
 }
 
 func (b Behaviours) Display() string {
+	filterExpr := t.NotProvided
+	if b.Filter != nil {
+		filterExpr = b.Filter.String()
+	}
+
+	merge := t.NotProvided
+	if b.Merge != nil {
+		merge = b.Merge.String()
+	}
+
 	return fmt.Sprintf(`Web Behaviours:
-  select on hover         %v`,
+  select on hover         %v
+  hex view                %v
+  filter                  %s
+  merge partitions        %s`,
 		b.SelectOnHover,
+		b.HexView,
+		filterExpr,
+		merge,
 	)
 }
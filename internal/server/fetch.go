@@ -7,8 +7,10 @@ import (
 	"log"
 	"net/http"
 	"strconv"
+	"sync"
 	"time"
 
+	"github.com/dhth/kplay/internal/filter"
 	k "github.com/dhth/kplay/internal/kafka"
 	t "github.com/dhth/kplay/internal/types"
 	"github.com/twmb/franz-go/pkg/kgo"
@@ -19,7 +21,11 @@ const (
 	applicationJSON = "application/json; charset=utf-8"
 )
 
-func getMessages(client *kgo.Client, config t.Config) func(w http.ResponseWriter, r *http.Request) {
+func getMessages(client *kgo.Client, config t.Config, msgFilter *filter.Filter, merger *k.Merger) func(w http.ResponseWriter, r *http.Request) {
+	// the merger's buffer is shared by all requests, so merged fetches are
+	// serialised
+	var mergeMu sync.Mutex
+
 	return func(w http.ResponseWriter, r *http.Request) {
 		queryParams := r.URL.Query()
 		numMessagesStr := queryParams.Get("num")
@@ -37,10 +43,21 @@ func getMessages(client *kgo.Client, config t.Config) func(w http.ResponseWriter
 			numMessages = 10
 		}
 
+		if merger != nil {
+			mergeMu.Lock()
+			defer mergeMu.Unlock()
+		}
+
 		fetchCtx, cancel := context.WithTimeout(context.TODO(), 5*time.Second)
 		defer cancel()
 
-		records, err := k.FetchRecords(fetchCtx, client, numMessages)
+		var records []*kgo.Record
+		var err error
+		if merger != nil {
+			records, err = k.FetchMergedRecords(fetchCtx, client, merger, numMessages)
+		} else {
+			records, err = k.FetchRecords(fetchCtx, client, numMessages)
+		}
 		if err != nil {
 			http.Error(w, fmt.Sprintf("failed to fetch messages: %s", err.Error()), http.StatusInternalServerError)
 			return
@@ -52,7 +69,12 @@ func getMessages(client *kgo.Client, config t.Config) func(w http.ResponseWriter
 				continue
 			}
 
-			messages = append(messages, t.GetMessageFromRecord(*record, config, true).ToSerializable())
+			msg := t.GetMessageFromRecord(*record, config, true)
+			if msgFilter != nil && !msgFilter.Matches(msg) {
+				continue
+			}
+
+			messages = append(messages, msg.ToSerializable())
 		}
 
 		jsonBytes, err := json.Marshal(messages)
@@ -10,11 +10,17 @@ import (
 	"syscall"
 	"time"
 
+	k "github.com/dhth/kplay/internal/kafka"
 	t "github.com/dhth/kplay/internal/types"
 	"github.com/twmb/franz-go/pkg/kgo"
 )
 
 func Serve(client *kgo.Client, config t.Config, initialBehaviours Behaviours, open bool) error {
+	var merger *k.Merger
+	if initialBehaviours.Merge != nil {
+		merger = k.NewMerger(*initialBehaviours.Merge)
+	}
+
 	mux := http.NewServeMux()
 
 	mux.HandleFunc("GET /", getIndex)
@@ -24,7 +30,7 @@ func Serve(client *kgo.Client, config t.Config, initialBehaviours Behaviours, op
 	mux.HandleFunc("GET /priv/static/kplay.mjs", getJS)
 	mux.HandleFunc("GET /api/config", getConfig(config))
 	mux.HandleFunc("GET /api/behaviours", getBehaviours(initialBehaviours))
-	mux.HandleFunc("GET /api/fetch", getMessages(client, config))
+	mux.HandleFunc("GET /api/fetch", getMessages(client, config, initialBehaviours.Filter, merger))
 	muxWithCors := corsMiddleware(mux)
 
 	port, ok := findOpenPort(startPort, endPort)
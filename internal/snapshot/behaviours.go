@@ -0,0 +1,66 @@
+package snapshot
+
+import (
+	"fmt"
+)
+
+const (
+	SnapshotMaxKeysInMemoryDefault = 100000
+)
+
+type OutputFormat uint
+
+const (
+	JSONLFormat OutputFormat = iota
+	SQLiteFormat
+)
+
+func ValidateOutputFormatValue(value string) (OutputFormat, error) {
+	switch value {
+	case "jsonl":
+		return JSONLFormat, nil
+	case "sqlite":
+		return SQLiteFormat, nil
+	default:
+		return JSONLFormat, fmt.Errorf("output format is incorrect; possible values: [jsonl, sqlite]")
+	}
+}
+
+func (f OutputFormat) String() string {
+	switch f {
+	case JSONLFormat:
+		return "jsonl"
+	case SQLiteFormat:
+		return "sqlite"
+	default:
+		return "unknown"
+	}
+}
+
+func (f OutputFormat) extension() string {
+	switch f {
+	case SQLiteFormat:
+		return "db"
+	default:
+		return f.String()
+	}
+}
+
+// Behaviours determines how a snapshot is built. Topics are always consumed
+// from the start of each partition, up to its end at the time of the snapshot.
+type Behaviours struct {
+	Format          OutputFormat
+	MaxKeysInMemory uint
+	BatchSize       uint
+}
+
+func (b Behaviours) Display() string {
+	return fmt.Sprintf(`Snapshot Behaviours:
+  output format           %s
+  max keys in memory      %d
+  batch size              %d`,
+		b.Format.String(),
+		b.MaxKeysInMemory,
+		b.BatchSize,
+	)
+}
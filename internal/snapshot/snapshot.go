@@ -0,0 +1,250 @@
+package snapshot
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	k "github.com/dhth/kplay/internal/kafka"
+	t "github.com/dhth/kplay/internal/types"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+const (
+	fetchTimeout = 5 * time.Second
+	// consuming stops if this many fetches in a row return no records; the
+	// snapshot isn't written if some partitions haven't reached their end offset
+	// by then
+	maxIdleFetches = 2
+)
+
+// Snapshotter materializes the "table" view of a (compacted) topic, ie, the
+// latest value for each key, leaving out keys whose latest record is a
+// tombstone.
+type Snapshotter struct {
+	client          *kgo.Client
+	config          t.Config
+	behaviours      Behaviours
+	outputDir       string
+	table           *table
+	numConsumed     uint
+	numWithoutKey   uint
+	numLiveKeys     uint
+	numDeletedKeys  uint
+	snapshotWritten bool
+}
+
+func New(client *kgo.Client, config t.Config, behaviours Behaviours, outputDir string) Snapshotter {
+	return Snapshotter{
+		client:     client,
+		config:     config,
+		behaviours: behaviours,
+		outputDir:  outputDir,
+	}
+}
+
+func (s *Snapshotter) Execute() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sigChan := make(chan os.Signal, 2)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigChan)
+
+	snapshotErrChan := make(chan error)
+
+	go func(errChan chan<- error) {
+		err := s.snapshot(ctx)
+		errChan <- err
+	}(snapshotErrChan)
+
+	select {
+	case <-sigChan:
+		cancel()
+		select {
+		case err := <-snapshotErrChan:
+			return err
+			// on a second signal
+		case <-sigChan:
+			return nil
+			// timeout after first signal
+		case <-time.After(5 * time.Second):
+			return t.ErrCouldntShutDownGracefully
+		}
+	case err := <-snapshotErrChan:
+		return err
+	}
+}
+
+func (s *Snapshotter) snapshot(ctx context.Context) error {
+	snapshotDir := filepath.Join(s.outputDir, "snapshots", s.config.Topic)
+	err := os.MkdirAll(snapshotDir, 0o755)
+	if err != nil {
+		return fmt.Errorf("%w: %s", t.ErrCouldntCreateDir, err.Error())
+	}
+
+	now := time.Now().Unix()
+	snapshotFilePath := filepath.Join(snapshotDir, fmt.Sprintf("snapshot-%d.%s", now, s.behaviours.Format.extension()))
+	spillDir := filepath.Join(snapshotDir, fmt.Sprintf(".spill-%d", now))
+
+	s.table = newTable(int(s.behaviours.MaxKeysInMemory), spillDir)
+	defer s.table.close()
+
+	defer func() {
+		s.reportResults(snapshotFilePath)
+	}()
+
+	err = s.consume(ctx)
+	if err != nil || ctx.Err() != nil {
+		return err
+	}
+
+	// the snapshot is written to a temporary file first, so that a snapshot
+	// that couldn't be written in full isn't left behind
+	tempFilePath := snapshotFilePath + ".tmp"
+	writer, err := newEntryWriter(tempFilePath, s.behaviours.Format)
+	if err != nil {
+		return err
+	}
+
+	err = s.table.finish(func(entry Entry) error {
+		if entry.Tombstone {
+			s.numDeletedKeys++
+			return nil
+		}
+
+		s.numLiveKeys++
+		return writer.write(entry)
+	})
+
+	closeErr := writer.close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(tempFilePath)
+		return err
+	}
+
+	err = os.Rename(tempFilePath, snapshotFilePath)
+	if err != nil {
+		_ = os.Remove(tempFilePath)
+		return fmt.Errorf("%w: %s", errCouldntWriteSnapshot, err.Error())
+	}
+	s.snapshotWritten = true
+
+	return nil
+}
+
+// consume consumes the topic from the start of each partition, until each
+// partition reaches the end offset it had when the snapshot started.
+func (s *Snapshotter) consume(ctx context.Context) error {
+	offsetRanges, err := k.GetPartitionOffsetRanges(ctx, s.client, s.config.Topic)
+	if err != nil {
+		return err
+	}
+
+	progress := k.NewPartitionProgress(offsetRanges, nil)
+
+	var numIdleFetches int
+	for !progress.Done() {
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		fetchCtx, cancel := context.WithTimeout(ctx, fetchTimeout)
+		records, err := k.FetchRecords(fetchCtx, s.client, s.behaviours.BatchSize)
+		cancel()
+
+		if err != nil {
+			return err
+		}
+
+		if len(records) == 0 {
+			numIdleFetches++
+			if numIdleFetches >= maxIdleFetches {
+				return progress.Err()
+			}
+			continue
+		}
+		numIdleFetches = 0
+
+		for _, record := range records {
+			// transaction markers aren't messages, but they take up offsets
+			if record.Attrs.IsControl() {
+				progress.Advance(record.Partition, record.Offset+1)
+				continue
+			}
+
+			if !progress.Accepts(record) {
+				continue
+			}
+
+			if err := s.add(record); err != nil {
+				return err
+			}
+
+			progress.Consumed(record)
+		}
+
+		if finished := progress.TakeFinished(); len(finished) > 0 {
+			s.client.PauseFetchPartitions(map[string][]int32{s.config.Topic: finished})
+		}
+
+		fmt.Fprintf(os.Stderr, "\r\033[Kconsumed %d messages", s.numConsumed)
+	}
+
+	return nil
+}
+
+func (s *Snapshotter) add(record *kgo.Record) error {
+	s.numConsumed++
+
+	// records without a key can't be compacted, and aren't part of the table
+	if record.Key == nil {
+		s.numWithoutKey++
+		return nil
+	}
+
+	// tombstones don't need to be decoded
+	decode := len(record.Value) > 0
+	msg := t.GetMessageFromRecord(*record, s.config, decode)
+
+	return s.table.add(record.Key, newEntry(msg))
+}
+
+func (s *Snapshotter) reportResults(snapshotFilePath string) {
+	fmt.Fprint(os.Stderr, "\r\033[K")
+
+	if !s.snapshotWritten {
+		if s.numConsumed > 0 {
+			fmt.Printf("The snapshot wasn't completed (after consuming %d messages); nothing was written\n", s.numConsumed)
+		}
+		return
+	}
+
+	fmt.Printf(`Summary:
+
+Snapshot File:                 %s
+Messages consumed:             %d
+Live keys:                     %d
+Deleted keys:                  %d
+`,
+		snapshotFilePath,
+		s.numConsumed,
+		s.numLiveKeys,
+		s.numDeletedKeys,
+	)
+
+	if s.numWithoutKey > 0 {
+		fmt.Printf("Messages without a key:        %d\n", s.numWithoutKey)
+	}
+
+	if s.table.numSpills > 0 {
+		fmt.Printf("Times keys were spilled:       %d\n", s.table.numSpills)
+	}
+}
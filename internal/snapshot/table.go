@@ -0,0 +1,262 @@
+package snapshot
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	s "github.com/dhth/kplay/internal/serde"
+	t "github.com/dhth/kplay/internal/types"
+)
+
+// keys are spread across this many spill files, so that each of them can be
+// read back into memory on its own
+const numSpillBuckets = 64
+
+var (
+	errCouldntSpillToDisk    = errors.New("couldn't spill keys to disk")
+	errCouldntReadSpilled    = errors.New("couldn't read spilled keys")
+	errSpillFileIsInvalid    = errors.New("spill file is invalid")
+	errCouldntCreateSpillDir = errors.New("couldn't create directory for spilled keys")
+)
+
+// Entry is the latest record for a key.
+type Entry struct {
+	Key       string                 `json:"key"`
+	Partition int32                  `json:"partition"`
+	Offset    int64                  `json:"offset"`
+	Timestamp time.Time              `json:"timestamp"`
+	Headers   []t.SerializableHeader `json:"headers"`
+	// decoded JSON values are kept as is; other values are kept as JSON strings
+	Value     json.RawMessage `json:"value,omitempty"`
+	DecodeErr *string         `json:"decode_error,omitempty"`
+	Tombstone bool            `json:"-"`
+}
+
+func newEntry(msg t.Message) Entry {
+	headers := make([]t.SerializableHeader, len(msg.Headers))
+	for i, h := range msg.Headers {
+		headers[i] = h.ToSerializable()
+	}
+
+	entry := Entry{
+		Key:       msg.Key,
+		Partition: msg.Partition,
+		Offset:    msg.Offset,
+		Timestamp: msg.Metadata.Timestamp,
+		Headers:   headers,
+		Tombstone: len(msg.Value) == 0,
+	}
+
+	if msg.DecodeErr != nil {
+		errStr := msg.DecodeErr.Error()
+		entry.DecodeErr = &errStr
+	}
+
+	if entry.Tombstone {
+		return entry
+	}
+
+	if msg.DecodeErr == nil {
+		if compacted, err := s.CompactJSON(msg.Value); err == nil {
+			entry.Value = compacted
+			return entry
+		}
+	}
+
+	entry.Value, _ = json.Marshal(msg.ValueDisplay())
+
+	return entry
+}
+
+// supersedes reports whether an entry is a later record for its key than
+// another one. Records in the same partition are ordered by their offsets;
+// records in different partitions (eg. if a topic was repartitioned, or keys
+// were produced with a different partitioner) are ordered by their timestamps,
+// with ties broken by their partitions (the higher one wins), since offsets
+// in different partitions can't be compared.
+func (e Entry) supersedes(other Entry) bool {
+	if e.Partition == other.Partition {
+		return e.Offset > other.Offset
+	}
+
+	if !e.Timestamp.Equal(other.Timestamp) {
+		return e.Timestamp.After(other.Timestamp)
+	}
+
+	return e.Partition > other.Partition
+}
+
+// spilledEntry is how entries are written to spill files; keys are identified
+// by their raw bytes, since decoded keys aren't guaranteed to be unique.
+type spilledEntry struct {
+	RawKey    []byte `json:"raw_key"`
+	Tombstone bool   `json:"tombstone"`
+	Entry     Entry  `json:"entry"`
+}
+
+// table holds the latest entry for each key (as per Entry.supersedes). Once it
+// holds more keys than it's allowed to keep in memory, its entries are
+// appended to spill files (with each key always going to the same file), and
+// it starts afresh; the latest entry for a key is picked again when its spill
+// file is read back.
+type table struct {
+	entries     map[string]Entry
+	maxInMemory int
+	spillDir    string
+	spillFiles  []*os.File
+	numSpills   uint
+}
+
+func newTable(maxInMemory int, spillDir string) *table {
+	return &table{
+		entries:     make(map[string]Entry),
+		maxInMemory: maxInMemory,
+		spillDir:    spillDir,
+	}
+}
+
+func (tb *table) add(rawKey []byte, entry Entry) error {
+	if existing, ok := tb.entries[string(rawKey)]; ok && !entry.supersedes(existing) {
+		return nil
+	}
+
+	tb.entries[string(rawKey)] = entry
+	if len(tb.entries) <= tb.maxInMemory {
+		return nil
+	}
+
+	return tb.spill()
+}
+
+func (tb *table) spill() error {
+	if tb.spillFiles == nil {
+		if err := os.MkdirAll(tb.spillDir, 0o755); err != nil {
+			return fmt.Errorf("%w: %s", errCouldntCreateSpillDir, err.Error())
+		}
+
+		tb.spillFiles = make([]*os.File, numSpillBuckets)
+		for i := range tb.spillFiles {
+			file, err := os.Create(filepath.Join(tb.spillDir, fmt.Sprintf("bucket-%d.jsonl", i)))
+			if err != nil {
+				return fmt.Errorf("%w: %s", errCouldntSpillToDisk, err.Error())
+			}
+			tb.spillFiles[i] = file
+		}
+	}
+
+	writers := make([]*bufio.Writer, numSpillBuckets)
+	encoders := make([]*json.Encoder, numSpillBuckets)
+	for i, file := range tb.spillFiles {
+		writers[i] = bufio.NewWriter(file)
+		encoders[i] = json.NewEncoder(writers[i])
+	}
+
+	for rawKey, entry := range tb.entries {
+		bucket := getBucket(rawKey)
+		spilled := spilledEntry{RawKey: []byte(rawKey), Tombstone: entry.Tombstone, Entry: entry}
+		if err := encoders[bucket].Encode(spilled); err != nil {
+			return fmt.Errorf("%w: %s", errCouldntSpillToDisk, err.Error())
+		}
+	}
+
+	for _, writer := range writers {
+		if err := writer.Flush(); err != nil {
+			return fmt.Errorf("%w: %s", errCouldntSpillToDisk, err.Error())
+		}
+	}
+
+	tb.entries = make(map[string]Entry)
+	tb.numSpills++
+
+	return nil
+}
+
+// finish calls emit with the latest entry for each key, in the order of raw
+// keys (per spill file, if entries were spilled).
+func (tb *table) finish(emit func(Entry) error) error {
+	if tb.spillFiles == nil {
+		return emitSorted(tb.entries, emit)
+	}
+
+	if len(tb.entries) > 0 {
+		if err := tb.spill(); err != nil {
+			return err
+		}
+	}
+
+	for _, file := range tb.spillFiles {
+		entries, err := readSpillFile(file)
+		if err != nil {
+			return err
+		}
+
+		if err := emitSorted(entries, emit); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (tb *table) close() {
+	for _, file := range tb.spillFiles {
+		_ = file.Close()
+	}
+
+	if tb.spillFiles != nil {
+		_ = os.RemoveAll(tb.spillDir)
+	}
+}
+
+func readSpillFile(file *os.File) (map[string]Entry, error) {
+	if _, err := file.Seek(0, 0); err != nil {
+		return nil, fmt.Errorf("%w: %s", errCouldntReadSpilled, err.Error())
+	}
+
+	entries := make(map[string]Entry)
+	decoder := json.NewDecoder(bufio.NewReader(file))
+	for decoder.More() {
+		var spilled spilledEntry
+		if err := decoder.Decode(&spilled); err != nil {
+			return nil, fmt.Errorf("%w: %s", errSpillFileIsInvalid, err.Error())
+		}
+
+		spilled.Entry.Tombstone = spilled.Tombstone
+		if existing, ok := entries[string(spilled.RawKey)]; ok && !spilled.Entry.supersedes(existing) {
+			continue
+		}
+		entries[string(spilled.RawKey)] = spilled.Entry
+	}
+
+	return entries, nil
+}
+
+func emitSorted(entries map[string]Entry, emit func(Entry) error) error {
+	keys := make([]string, 0, len(entries))
+	for key := range entries {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		if err := emit(entries[key]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func getBucket(rawKey string) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(rawKey))
+
+	return int(h.Sum32() % numSpillBuckets)
+}
@@ -0,0 +1,164 @@
+package snapshot
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	t "github.com/dhth/kplay/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func getTestMessage(key, value string, offset int64) t.Message {
+	var valueBytes []byte
+	if value != "" {
+		valueBytes = []byte(value)
+	}
+
+	return t.Message{
+		Key:      key,
+		RawKey:   []byte(key),
+		Value:    valueBytes,
+		Offset:   offset,
+		Metadata: t.Metadata{Timestamp: time.Date(2025, 4, 6, 11, 18, 3, 0, time.UTC)},
+	}
+}
+
+func addTestMessages(tb *table, msgs ...t.Message) error {
+	for _, msg := range msgs {
+		if err := tb.add(msg.RawKey, newEntry(msg)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func getTableContents(tb *table) (map[string]string, []string, error) {
+	live := make(map[string]string)
+	var deleted []string
+	err := tb.finish(func(entry Entry) error {
+		if entry.Tombstone {
+			deleted = append(deleted, entry.Key)
+			return nil
+		}
+
+		live[entry.Key] = string(entry.Value)
+		return nil
+	})
+
+	return live, deleted, err
+}
+
+// getSpillTestMessages returns 100 messages for 40 keys, followed by a
+// tombstone for a key that was spilled, and a key that's revived after being
+// deleted.
+func getSpillTestMessages() []t.Message {
+	var msgs []t.Message
+	for i := range 100 {
+		msgs = append(msgs, getTestMessage(fmt.Sprintf("key-%d", i%40), fmt.Sprintf(`{"version": %d}`, i), int64(i)))
+	}
+
+	return append(msgs,
+		getTestMessage("key-1", "", 100),
+		getTestMessage("key-2", "", 101),
+		getTestMessage("key-2", `{"version": 102}`, 102),
+	)
+}
+
+func TestTableKeepsTheLatestEntryPerKey(t *testing.T) {
+	// GIVEN
+	tb := newTable(100, filepath.Join(t.TempDir(), "spill"))
+	defer tb.close()
+
+	err := addTestMessages(tb,
+		getTestMessage("order-1", `{"status": "PAID"}`, 0),
+		getTestMessage("order-2", `{"status": "PAID"}`, 1),
+		getTestMessage("order-1", `{"status": "SHIPPED"}`, 2),
+		getTestMessage("order-3", `{"status": "PAID"}`, 3),
+		getTestMessage("order-2", "", 4),
+		getTestMessage("order-4", "plain text", 5),
+	)
+	require.NoError(t, err)
+
+	// WHEN
+	live, deleted, err := getTableContents(tb)
+
+	// THEN
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"order-1": `{"status":"SHIPPED"}`,
+		"order-3": `{"status":"PAID"}`,
+		"order-4": `"plain text"`,
+	}, live)
+	assert.Equal(t, []string{"order-2"}, deleted)
+	assert.Equal(t, uint(0), tb.numSpills)
+}
+
+func TestTableSpillsToDisk(t *testing.T) {
+	// GIVEN
+	spillDir := filepath.Join(t.TempDir(), "spill")
+	tb := newTable(10, spillDir)
+
+	require.NoError(t, addTestMessages(tb, getSpillTestMessages()...))
+
+	// WHEN
+	live, deleted, err := getTableContents(tb)
+	tb.close()
+
+	// THEN
+	require.NoError(t, err)
+	assert.Positive(t, tb.numSpills)
+	assert.Len(t, live, 39)
+	assert.Equal(t, `{"version":102}`, live["key-2"])
+	assert.Equal(t, `{"version":99}`, live["key-19"])
+	assert.Equal(t, `{"version":60}`, live["key-20"])
+	assert.Equal(t, []string{"key-1"}, deleted)
+	assert.NoDirExists(t, spillDir)
+}
+
+func getPartitionedTestMessage(key, value string, partition int32, offset int64, secondsSinceStart int) t.Message {
+	msg := getTestMessage(key, value, offset)
+	msg.Partition = partition
+	msg.Metadata.Timestamp = msg.Metadata.Timestamp.Add(time.Duration(secondsSinceStart) * time.Second)
+
+	return msg
+}
+
+func TestTablePicksTheLatestEntryByOffsetWithinAndTimestampAcrossPartitions(t *testing.T) {
+	for _, maxInMemory := range []int{100, 1} {
+		t.Run(fmt.Sprintf("max in memory: %d", maxInMemory), func(t *testing.T) {
+			// GIVEN
+			tb := newTable(maxInMemory, filepath.Join(t.TempDir(), "spill"))
+			defer tb.close()
+
+			// partitions are consumed independently, so later records can be
+			// consumed before earlier ones from other partitions
+			err := addTestMessages(tb,
+				getPartitionedTestMessage("order-1", `{"status": "SHIPPED"}`, 1, 7, 20),
+				getPartitionedTestMessage("order-1", `{"status": "PAID"}`, 0, 40, 10),
+				getPartitionedTestMessage("order-2", `{"status": "PAID"}`, 0, 41, 10),
+				getPartitionedTestMessage("order-2", `{"status": "SHIPPED"}`, 0, 42, 5),
+				getPartitionedTestMessage("order-3", `{"status": "PAID"}`, 0, 43, 30),
+				getPartitionedTestMessage("order-3", "", 1, 8, 30),
+			)
+			require.NoError(t, err)
+
+			// WHEN
+			live, deleted, err := getTableContents(tb)
+
+			// THEN
+			require.NoError(t, err)
+			assert.Equal(t, map[string]string{
+				// later timestamp, in another partition
+				"order-1": `{"status":"SHIPPED"}`,
+				// later offset in the same partition, despite an earlier timestamp
+				"order-2": `{"status":"SHIPPED"}`,
+			}, live)
+			// equal timestamps; the record in the higher partition wins
+			assert.Equal(t, []string{"order-3"}, deleted)
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package snapshot
+
+import (
+	"bufio"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	_ "modernc.org/sqlite" // registers the "sqlite" driver
+)
+
+var (
+	errCouldntCreateSnapshotFile = errors.New("couldn't create snapshot file")
+	errCouldntWriteSnapshot      = errors.New("couldn't write snapshot")
+)
+
+// sqliteTimestampLayout is understood by SQLite's date and time functions, and
+// sorts the same way as the timestamps it represents (when in UTC).
+const sqliteTimestampLayout = "2006-01-02T15:04:05.000Z"
+
+const sqliteSchema = `
+CREATE TABLE snapshot (
+    key TEXT NOT NULL,
+    partition INTEGER NOT NULL,
+    offset INTEGER NOT NULL,
+    timestamp TEXT NOT NULL,
+    headers TEXT NOT NULL,
+    value TEXT,
+    decode_error TEXT
+);
+
+CREATE INDEX idx_snapshot_key ON snapshot (key);
+`
+
+const sqliteInsertQuery = `
+INSERT INTO snapshot (key, partition, offset, timestamp, headers, value, decode_error)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+`
+
+// rows are inserted into SQLite in transactions of this size
+const sqliteBatchSize = 1000
+
+type entryWriter interface {
+	write(entry Entry) error
+	close() error
+}
+
+func newEntryWriter(filePath string, format OutputFormat) (entryWriter, error) {
+	switch format {
+	case SQLiteFormat:
+		return newSQLiteWriter(filePath)
+	default:
+		return newJSONLWriter(filePath)
+	}
+}
+
+type jsonlWriter struct {
+	file    *os.File
+	writer  *bufio.Writer
+	encoder *json.Encoder
+}
+
+func newJSONLWriter(filePath string) (*jsonlWriter, error) {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errCouldntCreateSnapshotFile, err.Error())
+	}
+
+	writer := bufio.NewWriter(file)
+	encoder := json.NewEncoder(writer)
+	encoder.SetEscapeHTML(false)
+
+	return &jsonlWriter{file: file, writer: writer, encoder: encoder}, nil
+}
+
+func (w *jsonlWriter) write(entry Entry) error {
+	if err := w.encoder.Encode(entry); err != nil {
+		return fmt.Errorf("%w: %s", errCouldntWriteSnapshot, err.Error())
+	}
+
+	return nil
+}
+
+func (w *jsonlWriter) close() error {
+	if err := w.writer.Flush(); err != nil {
+		_ = w.file.Close()
+		return fmt.Errorf("%w: %s", errCouldntWriteSnapshot, err.Error())
+	}
+
+	return w.file.Close()
+}
+
+type sqliteWriter struct {
+	db      *sql.DB
+	pending []Entry
+}
+
+func newSQLiteWriter(dbPath string) (*sqliteWriter, error) {
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errCouldntCreateSnapshotFile, err.Error())
+	}
+
+	_, err = db.Exec(sqliteSchema)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("%w: %s", errCouldntCreateSnapshotFile, err.Error())
+	}
+
+	return &sqliteWriter{db: db}, nil
+}
+
+func (w *sqliteWriter) write(entry Entry) error {
+	w.pending = append(w.pending, entry)
+	if len(w.pending) < sqliteBatchSize {
+		return nil
+	}
+
+	return w.insertPending()
+}
+
+func (w *sqliteWriter) insertPending() error {
+	if len(w.pending) == 0 {
+		return nil
+	}
+
+	tx, err := w.db.Begin()
+	if err != nil {
+		return fmt.Errorf("%w: %s", errCouldntWriteSnapshot, err.Error())
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	stmt, err := tx.Prepare(sqliteInsertQuery)
+	if err != nil {
+		return fmt.Errorf("%w: %s", errCouldntWriteSnapshot, err.Error())
+	}
+	defer stmt.Close()
+
+	for _, entry := range w.pending {
+		headersJSON, err := json.Marshal(entry.Headers)
+		if err != nil {
+			return fmt.Errorf("%w: %s", errCouldntWriteSnapshot, err.Error())
+		}
+
+		// values are stored as JSON, so that they can be queried via SQLite's
+		// JSON functions
+		var value *string
+		if len(entry.Value) > 0 {
+			valueStr := string(entry.Value)
+			value = &valueStr
+		}
+
+		_, err = stmt.Exec(
+			entry.Key,
+			entry.Partition,
+			entry.Offset,
+			entry.Timestamp.UTC().Format(sqliteTimestampLayout),
+			string(headersJSON),
+			value,
+			entry.DecodeErr,
+		)
+		if err != nil {
+			return fmt.Errorf("%w: %s", errCouldntWriteSnapshot, err.Error())
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%w: %s", errCouldntWriteSnapshot, err.Error())
+	}
+
+	w.pending = nil
+
+	return nil
+}
+
+func (w *sqliteWriter) close() error {
+	err := w.insertPending()
+	closeErr := w.db.Close()
+	if err != nil {
+		return err
+	}
+
+	return closeErr
+}
@@ -0,0 +1,66 @@
+package snapshot
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func getTestEntries() []Entry {
+	return []Entry{
+		newEntry(getTestMessage("order-1", `{"id": 1, "status": "PAID"}`, 10)),
+		newEntry(getTestMessage("order-2", "plain text", 20)),
+	}
+}
+
+func TestJSONLWriterWritesEntries(t *testing.T) {
+	// GIVEN
+	filePath := filepath.Join(t.TempDir(), "snapshot.jsonl")
+	w, err := newEntryWriter(filePath, JSONLFormat)
+	require.NoError(t, err)
+
+	// WHEN
+	for _, entry := range getTestEntries() {
+		require.NoError(t, w.write(entry))
+	}
+	require.NoError(t, w.close())
+
+	// THEN
+	contents, err := os.ReadFile(filePath)
+	require.NoError(t, err)
+	expected := `{"key":"order-1","partition":0,"offset":10,"timestamp":"2025-04-06T11:18:03Z","headers":[],"value":{"id":1,"status":"PAID"}}
+{"key":"order-2","partition":0,"offset":20,"timestamp":"2025-04-06T11:18:03Z","headers":[],"value":"plain text"}
+`
+	assert.Equal(t, expected, string(contents))
+}
+
+func TestSQLiteWriterWritesEntries(t *testing.T) {
+	// GIVEN
+	dbPath := filepath.Join(t.TempDir(), "snapshot.db")
+	w, err := newSQLiteWriter(dbPath)
+	require.NoError(t, err)
+
+	// WHEN
+	for _, entry := range getTestEntries() {
+		require.NoError(t, w.write(entry))
+	}
+	require.NoError(t, w.insertPending())
+
+	// THEN
+	defer w.close()
+
+	var numRows int
+	require.NoError(t, w.db.QueryRow(`SELECT count(*) FROM snapshot`).Scan(&numRows))
+	assert.Equal(t, 2, numRows)
+
+	var status string
+	require.NoError(t, w.db.QueryRow(`SELECT value ->> '$.status' FROM snapshot WHERE key = 'order-1'`).Scan(&status))
+	assert.Equal(t, "PAID", status)
+
+	var value string
+	require.NoError(t, w.db.QueryRow(`SELECT value ->> '$' FROM snapshot WHERE key = 'order-2'`).Scan(&value))
+	assert.Equal(t, "plain text", value)
+}
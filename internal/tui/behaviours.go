@@ -1,17 +1,50 @@
 package tui
 
-import "fmt"
+import (
+	"fmt"
+
+	"github.com/dhth/kplay/internal/filter"
+	"github.com/dhth/kplay/internal/fs"
+	k "github.com/dhth/kplay/internal/kafka"
+	t "github.com/dhth/kplay/internal/types"
+)
 
 type Behaviours struct {
 	PersistMessages bool
 	SkipMessages    bool
+	HexView         bool
+	Save            fs.SaveLayout
+	Filter          *filter.Filter
+	Merge           *k.MergeMode
 }
 
 func (b Behaviours) Display() string {
+	filterExpr := t.NotProvided
+	if b.Filter != nil {
+		filterExpr = b.Filter.String()
+	}
+
+	merge := t.NotProvided
+	if b.Merge != nil {
+		merge = b.Merge.String()
+	}
+
 	return fmt.Sprintf(`TUI Behaviours:
   persist messages        %v
-  skip messages           %v`,
+  skip messages           %v
+  hex view                %v
+  save path template      %s
+  save format             %s
+  save raw bytes          %v
+  filter                  %s
+  merge partitions        %s`,
 		b.PersistMessages,
 		b.SkipMessages,
+		b.HexView,
+		b.Save.PathTemplate(),
+		b.Save.Format.String(),
+		b.Save.Raw,
+		filterExpr,
+		merge,
 	)
 }
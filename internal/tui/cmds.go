@@ -2,53 +2,59 @@ package tui
 
 import (
 	"context"
-	"fmt"
 	"path/filepath"
 	"time"
 
 	"github.com/atotto/clipboard"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/dhth/kplay/internal/filter"
 	"github.com/dhth/kplay/internal/fs"
 	k "github.com/dhth/kplay/internal/kafka"
 	t "github.com/dhth/kplay/internal/types"
 	"github.com/twmb/franz-go/pkg/kgo"
 )
 
-func FetchMessages(cl *kgo.Client, config t.Config, numRecords uint) tea.Cmd {
+func FetchMessages(cl *kgo.Client, config t.Config, msgFilter *filter.Filter, merger *k.Merger, numRecords uint) tea.Cmd {
 	return func() tea.Msg {
 		fetchCtx, cancel := context.WithTimeout(context.TODO(), 5*time.Second)
 		defer cancel()
 
-		records, err := k.FetchRecords(fetchCtx, cl, numRecords)
+		var records []*kgo.Record
+		var err error
+		if merger != nil {
+			records, err = k.FetchMergedRecords(fetchCtx, cl, merger, numRecords)
+		} else {
+			records, err = k.FetchRecords(fetchCtx, cl, numRecords)
+		}
 		if err != nil {
 			return msgsFetchedMsg{
 				err: err,
 			}
 		}
 
-		messages := make([]t.Message, len(records))
-		for i, record := range records {
+		messages := make([]t.Message, 0, len(records))
+		var numFilteredOut int
+		for _, record := range records {
 			if record == nil {
 				continue
 			}
 
-			messages[i] = t.GetMessageFromRecord(*record, config, true)
+			msg := t.GetMessageFromRecord(*record, config, true)
+			if msgFilter != nil && !msgFilter.Matches(msg) {
+				numFilteredOut++
+				continue
+			}
+
+			messages = append(messages, msg)
 		}
 
-		return msgsFetchedMsg{messages: messages, err: nil}
+		return msgsFetchedMsg{messages: messages, numFilteredOut: numFilteredOut, err: nil}
 	}
 }
 
-func saveRecordDetailsToDisk(msg t.Message, outputDir, topic string, notifyUserOnSuccess bool) tea.Cmd {
+func saveRecordDetailsToDisk(msg t.Message, outputDir string, layout fs.SaveLayout, notifyUserOnSuccess bool) tea.Cmd {
 	return func() tea.Msg {
-		filePath := filepath.Join(
-			outputDir,
-			"messages",
-			topic,
-			fmt.Sprintf("partition-%d", msg.Partition),
-			fmt.Sprintf("offset-%d.txt", msg.Offset),
-		)
-		err := fs.SaveMessageToFileSystem(msg, filePath)
+		_, err := layout.Save(msg, filepath.Join(outputDir, "messages"))
 		if err != nil {
 			return msgSavedToDiskMsg{err: err}
 		}
@@ -1,11 +1,18 @@
 package tui
 
 import (
+	"fmt"
+	"io"
+	"strings"
+
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/lipgloss"
+	t "github.com/dhth/kplay/internal/types"
 )
 
+const partitionBadgeWidth = 1
+
 type delegateKeyMap struct {
 	choose key.Binding
 }
@@ -19,7 +26,33 @@ func newAppDelegateKeyMap() *delegateKeyMap {
 	}
 }
 
-func newAppItemDelegate(keys *delegateKeyMap) list.DefaultDelegate {
+// appItemDelegate renders messages with a badge coloured as per their
+// partition when partitions are merged, which makes messages from different
+// partitions easy to tell apart when they're interleaved.
+type appItemDelegate struct {
+	list.DefaultDelegate
+	showPartitionBadge bool
+}
+
+func (d appItemDelegate) Render(w io.Writer, m list.Model, index int, item list.Item) {
+	msg, ok := item.(t.Message)
+	if !ok || !d.showPartitionBadge {
+		d.DefaultDelegate.Render(w, m, index, item)
+		return
+	}
+
+	// the message is rendered in the width left over by the badge
+	m.SetWidth(m.Width() - partitionBadgeWidth)
+	var rendered strings.Builder
+	d.DefaultDelegate.Render(&rendered, m, index, item)
+
+	badge := getPartitionBadgeStyle(msg.Partition).
+		Render(strings.TrimSuffix(strings.Repeat("▌\n", d.Height()), "\n"))
+
+	fmt.Fprint(w, lipgloss.JoinHorizontal(lipgloss.Top, badge, rendered.String()))
+}
+
+func newAppItemDelegate(keys *delegateKeyMap, showPartitionBadge bool) appItemDelegate {
 	d := list.NewDefaultDelegate()
 
 	d.Styles.SelectedTitle = d.Styles.
@@ -38,5 +71,5 @@ func newAppItemDelegate(keys *delegateKeyMap) list.DefaultDelegate {
 	d.FullHelpFunc = func() [][]key.Binding {
 		return [][]key.Binding{help}
 	}
-	return d
+	return appItemDelegate{d, showPartitionBadge}
 }
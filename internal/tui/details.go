@@ -2,24 +2,42 @@ package tui
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/charmbracelet/lipgloss"
+	s "github.com/dhth/kplay/internal/serde"
 	t "github.com/dhth/kplay/internal/types"
 	"github.com/tidwall/pretty"
 )
 
-func getMsgDetailsStylized(m t.Message, encoding t.EncodingFormat, width int) string {
+func getMsgDetailsStylized(m t.Message, encoding t.EncodingFormat, width int, hexView bool) string {
 	var msgValue string
+	valueHeading := "Value"
 	wrappedStyle := lipgloss.NewStyle().Width(width)
 	if len(m.Value) == 0 {
 		msgValue = msgDetailsTombstoneStyle.Render("tombstone")
+	} else if hexView && m.Redacted {
+		valueHeading = "Value (hex dump)"
+		msgValue = msgDetailsTombstoneStyle.Render("value redacted")
+	} else if hexView && len(m.RawValue) > 0 {
+		// hex dump rows are not wrapped so that their columns stay aligned
+		valueHeading = "Value (hex dump)"
+		msgValue = s.HexDump(m.RawValue)
 	} else if m.DecodeErr != nil {
-		var decodeErrFallback string
+		msgValue = msgDetailsErrorStyle.Render(wrappedStyle.Render(fmt.Sprintf("Decode Error: %s", m.DecodeErr.Error())))
 		if len(m.DecodeErrFallback) > 0 {
-			decodeErrFallback = fmt.Sprintf("\n\n%s", m.DecodeErrFallback)
+			// fallbacks (hex dumps, raw decoded values) are preceded by a
+			// heading; only the heading is wrapped so that the columns of the
+			// fallback stay aligned
+			heading, fallback, hasFallback := strings.Cut(m.DecodeErrFallback, "\n\n")
+			msgValue += "\n\n" + msgDetailsErrorStyle.Render(wrappedStyle.Render(heading))
+			if hasFallback {
+				msgValue += "\n\n" + msgDetailsErrorStyle.Render(fallback)
+			}
 		}
-		errorText := fmt.Sprintf("Decode Error: %s%s", m.DecodeErr.Error(), decodeErrFallback)
-		msgValue = msgDetailsErrorStyle.Render(wrappedStyle.Render(errorText))
+	} else if m.IsBinary() {
+		valueHeading = "Value (hex dump)"
+		msgValue = m.ValueDisplay()
 	} else {
 		var rawValue string
 		switch encoding {
@@ -31,17 +49,35 @@ func getMsgDetailsStylized(m t.Message, encoding t.EncodingFormat, width int) st
 		msgValue = wrappedStyle.Render(rawValue)
 	}
 
+	var headersSection string
+	if len(m.Headers) > 0 {
+		headersSection = fmt.Sprintf("%s\n\n%s\n\n",
+			msgDetailsHeadingStyle.Render("Headers"),
+			wrappedStyle.Render(m.HeadersDisplay()),
+		)
+	}
+
+	var violationsSection string
+	if len(m.Violations) > 0 {
+		violationsSection = fmt.Sprintf("%s\n\n%s\n\n",
+			msgDetailsHeadingStyle.Render("Violations"),
+			msgDetailsErrorStyle.Render(wrappedStyle.Render(m.ViolationsDisplay())),
+		)
+	}
+
 	return fmt.Sprintf(`%s
 
 %s
 
-%s
+%s%s%s
 
 %s
 `,
 		msgDetailsHeadingStyle.Render("Metadata"),
-		wrappedStyle.Render(m.Metadata),
-		msgDetailsHeadingStyle.Render("Value"),
+		wrappedStyle.Render(m.MetadataDisplay()),
+		headersSection,
+		violationsSection,
+		msgDetailsHeadingStyle.Render(valueHeading),
 		msgValue,
 	)
 }
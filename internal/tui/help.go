@@ -1,8 +1,15 @@
 package tui
 
-import "fmt"
+import (
+	"fmt"
 
-var helpText = fmt.Sprintf(`%s
+	"github.com/dhth/kplay/internal/fs"
+)
+
+// getHelpText returns the contents of the help view; the location messages are
+// persisted at is rendered from the active save layout.
+func getHelpText(saveLayout fs.SaveLayout) string {
+	return fmt.Sprintf(`%s
 %s
 %s
 
@@ -11,23 +18,23 @@ var helpText = fmt.Sprintf(`%s
 %s
 %s
 `,
-	helpHeaderStyle.Render("kplay Reference Manual"),
-	helpSectionStyle.Render(`
+		helpHeaderStyle.Render("kplay Reference Manual"),
+		helpSectionStyle.Render(`
 (scroll with j/k/arrow/<c-d>/<c-u>)
 
 kplay has 2 views:
   - Message List and Details View
   - Help View (this one)
 `),
-	helpHeaderStyle.Render("Keyboard Shortcuts"),
-	helpHeaderStyle.Render("General"),
-	helpSectionStyle.Render(`
+		helpHeaderStyle.Render("Keyboard Shortcuts"),
+		helpHeaderStyle.Render("General"),
+		helpSectionStyle.Render(`
     ?                              Show help view
     q/<esc>                        Go back/quit
     <ctrl+c>                       Quit immediately
 `),
-	helpHeaderStyle.Render("Message List/Details View"),
-	helpSectionStyle.Render(`
+		helpHeaderStyle.Render("Message List/Details View"),
+		helpSectionStyle.Render(fmt.Sprintf(`
     <tab>/<shift-tab>              Switch focus between panes
     j/<Down>                       Select next message/scroll details down
     k/<Up>                         Select previous message/scroll details up
@@ -45,8 +52,11 @@ kplay has 2 views:
                                        skipping over them)
     p                              Toggle persist mode (if ON, kplay will start persisting
                                        messages at the location
-                                       messages/<topic>/partition-<partition>/offset-<offset>.txt)
+                                       messages/%s)
+    x                              Toggle hex view (if ON, kplay will show a hex dump of the
+                                       raw message value in the details pane)
     P                              Persist current message to local filesystem
     y                              Copy message details to clipboard
-`),
-)
+`, saveLayout.PathTemplate())),
+	)
+}
@@ -3,13 +3,14 @@ package tui
 import (
 	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/lipgloss"
+	k "github.com/dhth/kplay/internal/kafka"
 	t "github.com/dhth/kplay/internal/types"
 	"github.com/twmb/franz-go/pkg/kgo"
 )
 
 func InitialModel(kCl *kgo.Client, config t.Config, behaviours Behaviours, outputDir string) Model {
 	appDelegateKeys := newAppDelegateKeyMap()
-	appDelegate := newAppItemDelegate(appDelegateKeys)
+	appDelegate := newAppItemDelegate(appDelegateKeys, behaviours.Merge != nil)
 	jobItems := make([]list.Item, 0)
 
 	m := Model{
@@ -21,6 +22,10 @@ func InitialModel(kCl *kgo.Client, config t.Config, behaviours Behaviours, outpu
 		behaviours:        behaviours,
 		showHelpIndicator: true,
 	}
+	if behaviours.Merge != nil {
+		m.merger = k.NewMerger(*behaviours.Merge)
+	}
+
 	m.msgsList.Title = "Messages"
 	m.msgsList.SetStatusBarItemName("message", "messages")
 	m.msgsList.SetFilteringEnabled(false)
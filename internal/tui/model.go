@@ -6,6 +6,7 @@ import (
 	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
+	k "github.com/dhth/kplay/internal/kafka"
 	t "github.com/dhth/kplay/internal/types"
 	"github.com/twmb/franz-go/pkg/kgo"
 )
@@ -22,6 +23,7 @@ const (
 type Model struct {
 	config                         t.Config
 	client                         *kgo.Client
+	merger                         *k.Merger
 	activeView                     stateView
 	lastView                       stateView
 	lastViewBeforeInsufficientDims stateView
@@ -7,8 +7,9 @@ import (
 type hideHelpMsg struct{}
 
 type msgsFetchedMsg struct {
-	messages []t.Message
-	err      error
+	messages       []t.Message
+	numFilteredOut int
+	err            error
 }
 
 type msgSavedToDiskMsg struct {
@@ -22,6 +22,17 @@ const (
 	msgDetailsTombstoneColor = "#a89984"
 )
 
+var partitionColors = []string{
+	"#fb4934",
+	"#b8bb26",
+	"#fabd2f",
+	"#83a598",
+	"#d3869b",
+	"#8ec07c",
+	"#fe8019",
+	"#bdae93",
+}
+
 var (
 	baseStyle = lipgloss.NewStyle().
 			PaddingLeft(1).
@@ -102,3 +113,10 @@ var (
 					Foreground(lipgloss.Color(defaultBackgroundColor)).
 					Background(lipgloss.Color(msgDetailsTombstoneColor))
 )
+
+func getPartitionBadgeStyle(partition int32) lipgloss.Style {
+	color := partitionColors[int(partition)%len(partitionColors)]
+
+	return lipgloss.NewStyle().
+		Foreground(lipgloss.Color(color))
+}
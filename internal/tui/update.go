@@ -18,6 +18,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 	var cmds []tea.Cmd
 	terminalResized := false
+	refreshMsgDetails := false
 	m.msg = ""
 	m.errorMsg = ""
 
@@ -74,7 +75,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				break
 			}
 
-			cmds = append(cmds, FetchMessages(m.client, m.config, 1))
+			cmds = append(cmds, FetchMessages(m.client, m.config, m.behaviours.Filter, m.merger, 1))
 			m.fetchingInProgress = true
 		case "N":
 			if m.activeView == helpView {
@@ -86,7 +87,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				break
 			}
 
-			cmds = append(cmds, FetchMessages(m.client, m.config, 10))
+			cmds = append(cmds, FetchMessages(m.client, m.config, m.behaviours.Filter, m.merger, 10))
 			m.fetchingInProgress = true
 		case "}":
 			if m.activeView == helpView {
@@ -98,7 +99,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				break
 			}
 
-			cmds = append(cmds, FetchMessages(m.client, m.config, 100))
+			cmds = append(cmds, FetchMessages(m.client, m.config, m.behaviours.Filter, m.merger, 100))
 			m.fetchingInProgress = true
 		case "?":
 			if m.activeView != helpView {
@@ -119,6 +120,13 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 
 			m.behaviours.SkipMessages = !m.behaviours.SkipMessages
+		case "x":
+			if m.activeView == helpView {
+				break
+			}
+
+			m.behaviours.HexView = !m.behaviours.HexView
+			refreshMsgDetails = true
 		case "y":
 			if len(m.msgsList.Items()) == 0 {
 				break
@@ -243,7 +251,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				break
 			}
 
-			cmds = append(cmds, saveRecordDetailsToDisk(message, m.outputDir, m.config.Topic, true))
+			cmds = append(cmds, saveRecordDetailsToDisk(message, m.outputDir, m.behaviours.Save, true))
 		}
 	case tea.WindowSizeMsg:
 		w1, h1 := messageListStyle.GetFrameSize()
@@ -268,7 +276,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		helpVPWidth := msg.Width - w2 - 4
 		if !m.helpVPReady {
 			m.helpVP = viewport.New(helpVPWidth, fullScreenVPHeight)
-			m.helpVP.SetContent(helpText)
+			m.helpVP.SetContent(getHelpText(m.behaviours.Save))
 			m.helpVP.KeyMap.HalfPageDown.SetKeys("ctrl+d")
 			m.helpVP.KeyMap.Up.SetEnabled(false)
 			m.helpVP.KeyMap.Down.SetEnabled(false)
@@ -285,8 +293,13 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			break
 		}
 
+		var filterInfo string
+		if msg.numFilteredOut > 0 {
+			filterInfo = fmt.Sprintf(" (%d didn't match the filter)", msg.numFilteredOut)
+		}
+
 		if len(msg.messages) == 0 {
-			m.msg = "No new messages found"
+			m.msg = fmt.Sprintf("No new messages found%s", filterInfo)
 			break
 		}
 
@@ -295,12 +308,12 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			for _, message := range msg.messages {
 				m.msgsList.InsertItem(len(m.msgsList.Items()), message)
 				if m.behaviours.PersistMessages {
-					cmds = append(cmds, saveRecordDetailsToDisk(message, m.outputDir, m.config.Topic, false))
+					cmds = append(cmds, saveRecordDetailsToDisk(message, m.outputDir, m.behaviours.Save, false))
 				}
 			}
-			m.msg = fmt.Sprintf("%d message(s) fetched", len(msg.messages))
+			m.msg = fmt.Sprintf("%d message(s) fetched%s", len(msg.messages), filterInfo)
 		case true:
-			m.msg = fmt.Sprintf("skipped over %d message(s)", len(msg.messages))
+			m.msg = fmt.Sprintf("skipped over %d message(s)%s", len(msg.messages), filterInfo)
 		}
 
 	case msgSavedToDiskMsg:
@@ -331,19 +344,19 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		cmds = append(cmds, cmd)
 	}
 
-	m.updateMsgDetailsVP(terminalResized)
+	m.updateMsgDetailsVP(terminalResized, refreshMsgDetails)
 
 	return m, tea.Batch(cmds...)
 }
 
-func (m *Model) updateMsgDetailsVP(terminalResized bool) {
+func (m *Model) updateMsgDetailsVP(terminalResized, refresh bool) {
 	if m.activeView == msgListView || m.activeView == msgDetailsView {
-		if len(m.msgsList.Items()) > 0 && (terminalResized || m.msgsList.Index() != m.currentMsgIndex) {
+		if len(m.msgsList.Items()) > 0 && (terminalResized || refresh || m.msgsList.Index() != m.currentMsgIndex) {
 			m.currentMsgIndex = m.msgsList.Index()
 			message, ok := m.msgsList.SelectedItem().(t.Message)
 
 			if ok {
-				m.msgDetailsVP.SetContent(getMsgDetailsStylized(message, m.config.Encoding, m.msgDetailsVPWidth))
+				m.msgDetailsVP.SetContent(getMsgDetailsStylized(message, m.config.Encoding, m.msgDetailsVPWidth, m.behaviours.HexView))
 				if !terminalResized {
 					m.msgDetailsVP.GotoTop()
 				}
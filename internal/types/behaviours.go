@@ -9,6 +9,9 @@ type ConsumeBehaviours struct {
 	StartOffset      *int64
 	StartTimeStamp   *time.Time
 	PartitionOffsets map[int32]int64
+	// ResumeOffsets holds the offsets to resume consuming partitions from;
+	// partitions without one are consumed as per the other behaviours
+	ResumeOffsets map[int32]int64
 }
 
 func (b ConsumeBehaviours) Display() string {
@@ -27,12 +30,19 @@ func (b ConsumeBehaviours) Display() string {
 		partitionOffsets = fmt.Sprintf("%v", b.PartitionOffsets)
 	}
 
+	resumeOffsets := NotProvided
+	if len(b.ResumeOffsets) > 0 {
+		resumeOffsets = fmt.Sprintf("%v", b.ResumeOffsets)
+	}
+
 	return fmt.Sprintf(`Consume Behaviours:
   start offset            %s
   start timestamp         %s
-  partition offsets       %s`,
+  partition offsets       %s
+  resume offsets          %s`,
 		startOffset,
 		startTimeStamp,
 		partitionOffsets,
+		resumeOffsets,
 	)
 }
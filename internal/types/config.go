@@ -2,16 +2,26 @@ package types
 
 import (
 	"fmt"
+	"slices"
 	"strings"
+
+	s "github.com/dhth/kplay/internal/serde"
 )
 
 type Config struct {
-	Name           string         `json:"profile_name"`
-	Authentication AuthType       `json:"-"`
-	Encoding       EncodingFormat `json:"-"`
-	Brokers        []string       `json:"brokers"`
-	Topic          string         `json:"topic"`
-	Proto          *ProtoConfig   `json:"-"`
+	Name           string                `json:"profile_name"`
+	Authentication AuthType              `json:"-"`
+	Encoding       EncodingFormat        `json:"-"`
+	Compression    ValueCompression      `json:"-"`
+	KeyEncoding    KeyEncodingFormat     `json:"-"`
+	Brokers        []string              `json:"brokers"`
+	Topic          string                `json:"topic"`
+	Proto          *ProtoConfig          `json:"-"`
+	KeyProto       *ProtoConfig          `json:"-"`
+	KeyAvro        *AvroConfig           `json:"-"`
+	HeaderRules    map[string]HeaderRule `json:"-"`
+	Validation     *ValidationConfig     `json:"-"`
+	Redaction      []s.RedactionRule     `json:"-"`
 }
 
 func (c Config) AuthenticationDisplay() string {
@@ -30,7 +40,7 @@ func (c Config) EncodingDisplay() string {
 	case JSON:
 		return "json"
 	case Protobuf:
-		return fmt.Sprintf("protobuf (descriptor set: %s, descriptor name: %s)", c.Proto.DescriptorSetFile, c.Proto.DescriptorName)
+		return fmt.Sprintf("protobuf (%s)", protoConfigDisplay(c.Proto))
 	case Raw:
 		return "raw"
 	default:
@@ -38,16 +48,100 @@ func (c Config) EncodingDisplay() string {
 	}
 }
 
+func (c Config) KeyEncodingDisplay() string {
+	switch c.KeyEncoding {
+	case KeyString:
+		return "string"
+	case KeyInt64:
+		return "int64"
+	case KeyUUID:
+		return "uuid"
+	case KeyProtobuf:
+		return fmt.Sprintf("protobuf (%s)", protoConfigDisplay(c.KeyProto))
+	case KeyAvro:
+		return fmt.Sprintf("avro (schema file: %s)", c.KeyAvro.SchemaFile)
+	default:
+		return "unknown"
+	}
+}
+
+func protoConfigDisplay(pc *ProtoConfig) string {
+	display := fmt.Sprintf("descriptor set: %s, descriptor name: %s", pc.DescriptorSetFile, pc.DescriptorName)
+	if jsonOptions := pc.JSONOptionsDisplay(); jsonOptions != "" {
+		display = fmt.Sprintf("%s, json options: %s", display, jsonOptions)
+	}
+	if pc.RawDecodeFormat != RawDecodeText {
+		display = fmt.Sprintf("%s, raw decode format: %s", display, pc.RawDecodeFormat)
+	}
+
+	return display
+}
+
+func (c Config) HeaderRulesDisplay() string {
+	if len(c.HeaderRules) == 0 {
+		return NotProvided
+	}
+
+	keys := make([]string, 0, len(c.HeaderRules))
+	for key := range c.HeaderRules {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	rules := make([]string, len(keys))
+	for i, key := range keys {
+		rule := c.HeaderRules[key]
+		if rule.Encoding == HeaderProtobuf && rule.Proto != nil {
+			rules[i] = fmt.Sprintf("%s: protobuf (descriptor name: %s)", key, rule.Proto.DescriptorName)
+		} else {
+			rules[i] = fmt.Sprintf("%s: %s", key, rule.Encoding)
+		}
+	}
+
+	return strings.Join(rules, "\n                          ")
+}
+
+func (c Config) ValidationDisplay() string {
+	if c.Validation == nil {
+		return NotProvided
+	}
+
+	return c.Validation.Display()
+}
+
+func (c Config) RedactionDisplay() string {
+	if len(c.Redaction) == 0 {
+		return NotProvided
+	}
+
+	rules := make([]string, len(c.Redaction))
+	for i, rule := range c.Redaction {
+		rules[i] = rule.String()
+	}
+
+	return strings.Join(rules, "\n                          ")
+}
+
 func (c Config) Display() string {
 	return fmt.Sprintf(`Profile:
   name                    %s
   topic                   %s
   authentication          %s
   encoding                %s
+  value compression       %s
+  key encoding            %s
+  header encodings        %s
+  validation              %s
+  redaction               %s
   brokers                 %s`,
 		c.Name,
 		c.Topic,
 		c.AuthenticationDisplay(),
 		c.EncodingDisplay(),
+		c.Compression,
+		c.KeyEncodingDisplay(),
+		c.HeaderRulesDisplay(),
+		c.ValidationDisplay(),
+		c.RedactionDisplay(),
 		strings.Join(c.Brokers, "\n                          "))
 }
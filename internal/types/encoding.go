@@ -2,7 +2,10 @@ package types
 
 import (
 	"fmt"
+	"strings"
 
+	s "github.com/dhth/kplay/internal/serde"
+	"github.com/linkedin/goavro/v2"
 	"google.golang.org/protobuf/reflect/protoreflect"
 )
 
@@ -27,8 +30,214 @@ func ValidateEncodingFmtValue(value string) (EncodingFormat, error) {
 	}
 }
 
+type KeyEncodingFormat uint
+
+const (
+	KeyString KeyEncodingFormat = iota
+	KeyInt64
+	KeyUUID
+	KeyProtobuf
+	KeyAvro
+)
+
+func ValidateKeyEncodingFmtValue(value string) (KeyEncodingFormat, error) {
+	switch value {
+	case "", "string":
+		return KeyString, nil
+	case "int64":
+		return KeyInt64, nil
+	case "uuid":
+		return KeyUUID, nil
+	case "protobuf":
+		return KeyProtobuf, nil
+	case "avro":
+		return KeyAvro, nil
+	default:
+		return KeyString, fmt.Errorf("key encoding format is incorrect; possible values: [string, int64, uuid, protobuf, avro]")
+	}
+}
+
+// ValueCompression is the compression that producers apply to message values
+// themselves, as opposed to the compression of record batches done by Kafka.
+type ValueCompression uint
+
+const (
+	NoValueCompression ValueCompression = iota
+	GzipValueCompression
+	ZstdValueCompression
+	SnappyValueCompression
+	LZ4ValueCompression
+	AutoValueCompression
+)
+
+func ValidateValueCompressionValue(value string) (ValueCompression, error) {
+	switch value {
+	case "", "none":
+		return NoValueCompression, nil
+	case "gzip":
+		return GzipValueCompression, nil
+	case "zstd":
+		return ZstdValueCompression, nil
+	case "snappy":
+		return SnappyValueCompression, nil
+	case "lz4":
+		return LZ4ValueCompression, nil
+	case "auto":
+		return AutoValueCompression, nil
+	default:
+		return NoValueCompression, fmt.Errorf("value compression is incorrect; possible values: [none, gzip, zstd, snappy, lz4, auto]")
+	}
+}
+
+func (c ValueCompression) String() string {
+	switch c {
+	case NoValueCompression:
+		return "none"
+	case GzipValueCompression:
+		return "gzip"
+	case ZstdValueCompression:
+		return "zstd"
+	case SnappyValueCompression:
+		return "snappy"
+	case LZ4ValueCompression:
+		return "lz4"
+	case AutoValueCompression:
+		return "auto"
+	default:
+		return "unknown"
+	}
+}
+
+// codec returns the codec to decompress a value with; for auto-detection, this
+// depends on the value's magic bytes.
+func (c ValueCompression) codec(value []byte) s.CompressionCodec {
+	switch c {
+	case GzipValueCompression:
+		return s.Gzip
+	case ZstdValueCompression:
+		return s.Zstd
+	case SnappyValueCompression:
+		return s.Snappy
+	case LZ4ValueCompression:
+		return s.LZ4
+	case AutoValueCompression:
+		return s.DetectCompression(value)
+	default:
+		return s.NoCompression
+	}
+}
+
+// RawDecodeFormat determines how protobuf messages that can't be decoded using
+// their descriptor are shown after being decoded without a schema.
+type RawDecodeFormat uint
+
+const (
+	RawDecodeText RawDecodeFormat = iota
+	RawDecodeJSON
+)
+
+func ValidateRawDecodeFmtValue(value string) (RawDecodeFormat, error) {
+	switch value {
+	case "", "text":
+		return RawDecodeText, nil
+	case "json":
+		return RawDecodeJSON, nil
+	default:
+		return RawDecodeText, fmt.Errorf("raw decode format is incorrect; possible values: [text, json]")
+	}
+}
+
+func (f RawDecodeFormat) String() string {
+	switch f {
+	case RawDecodeText:
+		return "text"
+	case RawDecodeJSON:
+		return "json"
+	default:
+		return "unknown"
+	}
+}
+
 type ProtoConfig struct {
 	DescriptorSetFile string
 	DescriptorName    string
 	MsgDescriptor     protoreflect.MessageDescriptor
+	Resolver          s.ProtoResolver
+	JSONOptions       s.ProtoJSONOptions
+	RawDecodeFormat   RawDecodeFormat
+}
+
+// JSONOptionsDisplay returns the JSON rendering options that deviate from the
+// defaults, or an empty string if there are none.
+func (pc ProtoConfig) JSONOptionsDisplay() string {
+	var options []string
+	if pc.JSONOptions.EmitUnpopulated {
+		options = append(options, "emit unpopulated")
+	}
+	if pc.JSONOptions.UseProtoNames {
+		options = append(options, "proto names")
+	}
+	if pc.JSONOptions.UseEnumNumbers {
+		options = append(options, "enum numbers")
+	}
+	if pc.JSONOptions.ShowUnknownFields {
+		options = append(options, "show unknown fields")
+	}
+
+	return strings.Join(options, ", ")
+}
+
+type AvroConfig struct {
+	SchemaFile string
+	Codec      *goavro.Codec
+}
+
+type HeaderEncodingFormat uint
+
+const (
+	HeaderUTF8 HeaderEncodingFormat = iota
+	HeaderHex
+	HeaderInt
+	HeaderBase64
+	HeaderProtobuf
+)
+
+func ValidateHeaderEncodingFmtValue(value string) (HeaderEncodingFormat, error) {
+	switch value {
+	case "utf8":
+		return HeaderUTF8, nil
+	case "hex":
+		return HeaderHex, nil
+	case "int":
+		return HeaderInt, nil
+	case "base64":
+		return HeaderBase64, nil
+	case "protobuf":
+		return HeaderProtobuf, nil
+	default:
+		return HeaderUTF8, fmt.Errorf("header encoding format is missing/incorrect; possible values: [utf8, hex, int, base64, protobuf]")
+	}
+}
+
+func (f HeaderEncodingFormat) String() string {
+	switch f {
+	case HeaderUTF8:
+		return "utf8"
+	case HeaderHex:
+		return "hex"
+	case HeaderInt:
+		return "int"
+	case HeaderBase64:
+		return "base64"
+	case HeaderProtobuf:
+		return "protobuf"
+	default:
+		return "unknown"
+	}
+}
+
+type HeaderRule struct {
+	Key      string
+	Encoding HeaderEncodingFormat
+	Proto    *ProtoConfig
 }
@@ -0,0 +1,137 @@
+package types
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	s "github.com/dhth/kplay/internal/serde"
+	"github.com/dhth/kplay/internal/utils"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+const headerKeyPadding = 20
+
+var (
+	errHeaderValueNotUTF8       = errors.New("header value is not valid UTF-8")
+	errHeaderValueLengthInvalid = errors.New("header value length is invalid for an integer")
+	errHeaderRuleConfigNil      = errors.New("header rule config is nil when it shouldn't be")
+)
+
+type Header struct {
+	Key       string
+	Value     string
+	RawValue  []byte
+	DecodeErr error
+}
+
+type SerializableHeader struct {
+	Key       string  `json:"key"`
+	Value     string  `json:"value"`
+	DecodeErr *string `json:"decode_error,omitempty"`
+}
+
+func (h Header) ToSerializable() SerializableHeader {
+	var decodeErr *string
+	if h.DecodeErr != nil {
+		errStr := h.DecodeErr.Error()
+		decodeErr = &errStr
+	}
+
+	return SerializableHeader{
+		Key:       h.Key,
+		Value:     h.Value,
+		DecodeErr: decodeErr,
+	}
+}
+
+func getHeadersFromRecord(recordHeaders []kgo.RecordHeader, rules map[string]HeaderRule) []Header {
+	if len(recordHeaders) == 0 {
+		return nil
+	}
+
+	headers := make([]Header, len(recordHeaders))
+	for i, rh := range recordHeaders {
+		header := Header{
+			Key:      rh.Key,
+			RawValue: rh.Value,
+		}
+
+		rule, ok := rules[rh.Key]
+		if !ok {
+			header.Value = textOrHex(rh.Value)
+		} else {
+			value, err := decodeHeaderValue(rh.Value, rule)
+			if err != nil {
+				header.Value = textOrHex(rh.Value)
+				header.DecodeErr = err
+			} else {
+				header.Value = value
+			}
+		}
+
+		headers[i] = header
+	}
+
+	return headers
+}
+
+func decodeHeaderValue(value []byte, rule HeaderRule) (string, error) {
+	switch rule.Encoding {
+	case HeaderUTF8:
+		if !utf8.Valid(value) {
+			return "", errHeaderValueNotUTF8
+		}
+		return string(value), nil
+	case HeaderHex:
+		return hex.EncodeToString(value), nil
+	case HeaderInt:
+		switch len(value) {
+		case 1:
+			return strconv.FormatInt(int64(int8(value[0])), 10), nil
+		case 2:
+			return strconv.FormatInt(int64(int16(binary.BigEndian.Uint16(value))), 10), nil
+		case 4:
+			return strconv.FormatInt(int64(int32(binary.BigEndian.Uint32(value))), 10), nil
+		case 8:
+			return strconv.FormatInt(int64(binary.BigEndian.Uint64(value)), 10), nil
+		default:
+			return "", fmt.Errorf("%w: expected 1, 2, 4, or 8 bytes, got %d", errHeaderValueLengthInvalid, len(value))
+		}
+	case HeaderBase64:
+		return base64.StdEncoding.EncodeToString(value), nil
+	case HeaderProtobuf:
+		if rule.Proto == nil {
+			return "", fmt.Errorf("%w: %s", errHeaderRuleConfigNil, unexpectedErrorMessage)
+		}
+		decoded, err := s.TranscodeProto(value, rule.Proto.MsgDescriptor, rule.Proto.Resolver, rule.Proto.JSONOptions)
+		if err != nil {
+			return "", err
+		}
+		compacted, err := s.CompactJSON(decoded)
+		if err != nil {
+			return "", err
+		}
+		return string(compacted), nil
+	default:
+		return textOrHex(value), nil
+	}
+}
+
+func getHeadersDisplay(headers []Header) string {
+	lines := make([]string, len(headers))
+	for i, h := range headers {
+		if h.DecodeErr != nil {
+			lines[i] = fmt.Sprintf("- %s %s (decode error: %s)", utils.RightPadTrim(h.Key, headerKeyPadding), h.Value, h.DecodeErr.Error())
+		} else {
+			lines[i] = fmt.Sprintf("- %s %s", utils.RightPadTrim(h.Key, headerKeyPadding), h.Value)
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,127 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+func TestDecodeHeaderValue(t *testing.T) {
+	testCases := []struct {
+		name     string
+		value    []byte
+		encoding HeaderEncodingFormat
+		expected string
+	}{
+		{
+			name:     "utf-8 value",
+			value:    []byte("application/json"),
+			encoding: HeaderUTF8,
+			expected: "application/json",
+		},
+		{
+			name:     "hex value",
+			value:    []byte{0xca, 0xfe},
+			encoding: HeaderHex,
+			expected: "cafe",
+		},
+		{
+			name:     "single byte int value",
+			value:    []byte{0xff},
+			encoding: HeaderInt,
+			expected: "-1",
+		},
+		{
+			name:     "two byte int value",
+			value:    []byte{0x01, 0x00},
+			encoding: HeaderInt,
+			expected: "256",
+		},
+		{
+			name:     "four byte int value",
+			value:    []byte{0x00, 0x00, 0x30, 0x39},
+			encoding: HeaderInt,
+			expected: "12345",
+		},
+		{
+			name:     "eight byte int value",
+			value:    []byte{0x00, 0x00, 0x01, 0x8f, 0x4a, 0x3b, 0x2c, 0x1d},
+			encoding: HeaderInt,
+			expected: "1714937343005",
+		},
+		{
+			name:     "base64 value",
+			value:    []byte("kplay"),
+			encoding: HeaderBase64,
+			expected: "a3BsYXk=",
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeHeaderValue(tt.value, HeaderRule{Key: "key", Encoding: tt.encoding})
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestDecodeHeaderValueFails(t *testing.T) {
+	testCases := []struct {
+		name     string
+		value    []byte
+		encoding HeaderEncodingFormat
+		expected error
+	}{
+		{
+			name:     "invalid utf-8",
+			value:    []byte{0xff, 0xfe},
+			encoding: HeaderUTF8,
+			expected: errHeaderValueNotUTF8,
+		},
+		{
+			name:     "int with invalid length",
+			value:    []byte{0x00, 0x00, 0x01},
+			encoding: HeaderInt,
+			expected: errHeaderValueLengthInvalid,
+		},
+		{
+			name:     "protobuf without config",
+			value:    []byte{0x08, 0x01},
+			encoding: HeaderProtobuf,
+			expected: errHeaderRuleConfigNil,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := decodeHeaderValue(tt.value, HeaderRule{Key: "key", Encoding: tt.encoding})
+
+			assert.ErrorIs(t, err, tt.expected)
+		})
+	}
+}
+
+func TestGetHeadersFromRecordFallsBackOnDecodeError(t *testing.T) {
+	// GIVEN
+	recordHeaders := []kgo.RecordHeader{
+		{Key: "content-type", Value: []byte("application/json")},
+		{Key: "retries", Value: []byte{0xff, 0xfe, 0x01}},
+	}
+	rules := map[string]HeaderRule{
+		"retries": {Key: "retries", Encoding: HeaderInt},
+	}
+
+	// WHEN
+	got := getHeadersFromRecord(recordHeaders, rules)
+
+	// THEN
+	require.Len(t, got, 2)
+	assert.Equal(t, "application/json", got[0].Value)
+	require.NoError(t, got[0].DecodeErr)
+	assert.Equal(t, "0xfffe01", got[1].Value)
+	assert.ErrorIs(t, got[1].DecodeErr, errHeaderValueLengthInvalid)
+}
@@ -0,0 +1,77 @@
+package types
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"unicode/utf8"
+
+	s "github.com/dhth/kplay/internal/serde"
+)
+
+const (
+	int64KeyLength = 8
+	uuidKeyLength  = 16
+)
+
+var (
+	errKeyLengthIncorrect = errors.New("key length is incorrect")
+	errKeyConfigNil       = errors.New("key config is nil when it shouldn't be")
+)
+
+func decodeKey(key []byte, config Config) (string, error) {
+	if len(key) == 0 {
+		return "", nil
+	}
+
+	switch config.KeyEncoding {
+	case KeyInt64:
+		if len(key) != int64KeyLength {
+			return "", fmt.Errorf("%w: expected %d bytes for an int64 key, got %d", errKeyLengthIncorrect, int64KeyLength, len(key))
+		}
+		return strconv.FormatInt(int64(binary.BigEndian.Uint64(key)), 10), nil
+	case KeyUUID:
+		if len(key) != uuidKeyLength {
+			return "", fmt.Errorf("%w: expected %d bytes for a uuid key, got %d", errKeyLengthIncorrect, uuidKeyLength, len(key))
+		}
+		h := hex.EncodeToString(key)
+		return fmt.Sprintf("%s-%s-%s-%s-%s", h[0:8], h[8:12], h[12:16], h[16:20], h[20:32]), nil
+	case KeyProtobuf:
+		if config.KeyProto == nil {
+			return "", fmt.Errorf("%w: %s", errKeyConfigNil, unexpectedErrorMessage)
+		}
+		decoded, err := s.TranscodeProto(key, config.KeyProto.MsgDescriptor, config.KeyProto.Resolver, config.KeyProto.JSONOptions)
+		if err != nil {
+			return "", err
+		}
+		compacted, err := s.CompactJSON(decoded)
+		if err != nil {
+			return "", err
+		}
+		return string(compacted), nil
+	case KeyAvro:
+		if config.KeyAvro == nil {
+			return "", fmt.Errorf("%w: %s", errKeyConfigNil, unexpectedErrorMessage)
+		}
+		decoded, err := s.TranscodeAvro(key, config.KeyAvro.Codec)
+		if err != nil {
+			return "", err
+		}
+		return string(decoded), nil
+	default:
+		return string(key), nil
+	}
+}
+
+// textOrHex is used to display bytes that couldn't be decoded; bytes that
+// aren't valid UTF-8 are shown hex encoded so that they don't garble list
+// titles, CSV rows, or the terminal.
+func textOrHex(data []byte) string {
+	if utf8.Valid(data) {
+		return string(data)
+	}
+
+	return fmt.Sprintf("0x%s", hex.EncodeToString(data))
+}
@@ -0,0 +1,86 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeKey(t *testing.T) {
+	testCases := []struct {
+		name     string
+		key      []byte
+		encoding KeyEncodingFormat
+		expected string
+	}{
+		{
+			name:     "string key",
+			key:      []byte("some-key"),
+			encoding: KeyString,
+			expected: "some-key",
+		},
+		{
+			name:     "empty key",
+			key:      nil,
+			encoding: KeyInt64,
+			expected: "",
+		},
+		{
+			name:     "int64 key",
+			key:      []byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x30, 0x39},
+			encoding: KeyInt64,
+			expected: "12345",
+		},
+		{
+			name:     "negative int64 key",
+			key:      []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+			encoding: KeyInt64,
+			expected: "-1",
+		},
+		{
+			name:     "uuid key",
+			key:      []byte{0x41, 0xbb, 0x43, 0xd3, 0x85, 0x89, 0x48, 0x19, 0x87, 0x85, 0x04, 0x8d, 0xc2, 0xdd, 0x4c, 0x8a},
+			encoding: KeyUUID,
+			expected: "41bb43d3-8589-4819-8785-048dc2dd4c8a",
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeKey(tt.key, Config{KeyEncoding: tt.encoding})
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestDecodeKeyFailsForIncorrectLength(t *testing.T) {
+	testCases := []struct {
+		name     string
+		encoding KeyEncodingFormat
+	}{
+		{
+			name:     "int64",
+			encoding: KeyInt64,
+		},
+		{
+			name:     "uuid",
+			encoding: KeyUUID,
+		},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := decodeKey([]byte{0x01, 0x02, 0x03}, Config{KeyEncoding: tt.encoding})
+
+			assert.ErrorIs(t, err, errKeyLengthIncorrect)
+		})
+	}
+}
+
+func TestTextOrHex(t *testing.T) {
+	assert.Equal(t, "some-key", textOrHex([]byte("some-key")))
+	assert.Equal(t, "0x00ff10", textOrHex([]byte{0x00, 0xff, 0x10}))
+}
@@ -3,10 +3,9 @@ package types
 import (
 	"errors"
 	"fmt"
-	"time"
+	"unicode/utf8"
 
 	s "github.com/dhth/kplay/internal/serde"
-	"github.com/dhth/kplay/internal/utils"
 	"github.com/twmb/franz-go/pkg/kgo"
 )
 
@@ -15,43 +14,89 @@ var errProtoDescriptorNil = errors.New("protobuf descriptor is nil when it shoul
 var unexpectedErrorMessage = "this is not expected; let @dhth know via https://github.com/dhth/kplay/issues"
 
 type Message struct {
-	Metadata          string    `json:"metadata"`
-	Topic             string    `json:"-"`
-	Offset            int64     `json:"offset"`
-	Partition         int32     `json:"partition"`
-	Timestamp         time.Time `json:"-"`
-	Value             []byte    `json:"-"`
-	Key               string    `json:"key"`
-	DecodeErr         error     `json:"-"`
-	DecodeErrFallback string    `json:"decode_error_fallback,omitempty"`
+	Metadata          Metadata `json:"metadata"`
+	Topic             string   `json:"-"`
+	Offset            int64    `json:"offset"`
+	Partition         int32    `json:"partition"`
+	Value             []byte   `json:"-"`
+	RawValue          []byte   `json:"-"`
+	Key               string   `json:"key"`
+	RawKey            []byte   `json:"-"`
+	KeyDecodeErr      error    `json:"-"`
+	Headers           []Header `json:"-"`
+	DecodeErr         error    `json:"-"`
+	DecodeErrFallback string   `json:"decode_error_fallback,omitempty"`
+	Violations        []string `json:"violations,omitempty"`
+	// whether the value was redacted (or withheld) as per the profile's
+	// redaction rules, in which case its raw bytes aren't available
+	Redacted bool `json:"redacted,omitempty"`
 }
 
 type SerializableMessage struct {
 	Message
-	Value     *string `json:"value"`
-	DecodeErr *string `json:"decode_error"`
+	Value        *string              `json:"value"`
+	HexDump      *string              `json:"hex_dump"`
+	DecodeErr    *string              `json:"decode_error"`
+	KeyDecodeErr *string              `json:"key_decode_error,omitempty"`
+	Headers      []SerializableHeader `json:"headers"`
 }
 
 func (m Message) ToSerializable() SerializableMessage {
 	var decodeErr *string
+	var keyDecodeErr *string
 	var value *string
+	var hexDump *string
 	if len(m.Value) > 0 {
-		valueStr := string(m.Value)
+		valueStr := m.ValueDisplay()
 		value = &valueStr
 	}
 
+	if len(m.RawValue) > 0 {
+		hexDumpStr := s.HexDump(m.RawValue)
+		hexDump = &hexDumpStr
+	}
+
 	if m.DecodeErr != nil {
 		errStr := m.DecodeErr.Error()
 		decodeErr = &errStr
 	}
 
+	if m.KeyDecodeErr != nil {
+		errStr := m.KeyDecodeErr.Error()
+		keyDecodeErr = &errStr
+	}
+
+	headers := make([]SerializableHeader, len(m.Headers))
+	for i, h := range m.Headers {
+		headers[i] = h.ToSerializable()
+	}
+
 	return SerializableMessage{
-		Message:   m,
-		Value:     value,
-		DecodeErr: decodeErr,
+		Message:      m,
+		Headers:      headers,
+		Value:        value,
+		HexDump:      hexDump,
+		DecodeErr:    decodeErr,
+		KeyDecodeErr: keyDecodeErr,
 	}
 }
 
+// IsBinary reports whether the message's value can't be shown as text, which
+// is the case for raw or undecoded values that aren't valid UTF-8.
+func (m Message) IsBinary() bool {
+	return len(m.Value) > 0 && !utf8.Valid(m.Value)
+}
+
+// ValueDisplay returns the message's value as text, falling back to a hex dump
+// for binary values.
+func (m Message) ValueDisplay() string {
+	if m.IsBinary() {
+		return s.HexDump(m.Value)
+	}
+
+	return string(m.Value)
+}
+
 func (m Message) GetDetails() string {
 	var msgValue string
 	if len(m.Value) == 0 {
@@ -63,35 +108,89 @@ func (m Message) GetDetails() string {
 		}
 		msgValue = fmt.Sprintf("Decode Error: %s%s", m.DecodeErr.Error(), decodeErrFallback)
 	} else {
-		msgValue = string(m.Value)
+		msgValue = m.ValueDisplay()
+	}
+
+	var headersSection string
+	if len(m.Headers) > 0 {
+		headersSection = fmt.Sprintf("Headers\n\n%s\n\n", getHeadersDisplay(m.Headers))
+	}
+
+	var violationsSection string
+	if len(m.Violations) > 0 {
+		violationsSection = fmt.Sprintf("Violations\n\n%s\n\n", m.ViolationsDisplay())
 	}
 
 	return fmt.Sprintf(`%s
 
 %s
 
-%s
+%s%s%s
 
 %s`,
 		"Metadata",
-		m.Metadata,
+		m.MetadataDisplay(),
+		headersSection,
+		violationsSection,
 		"Value",
 		msgValue,
 	)
 }
 
+// GetMessageFromRecord converts a kafka record into a Message. The record's key
+// is always decoded as per the config's key encoding; decode only controls
+// whether the value is decoded. Values are always decoded for configs with
+// redaction rules, since these rules apply to decoded values.
 func GetMessageFromRecord(record kgo.Record, config Config, decode bool) Message {
+	redact := len(config.Redaction) > 0
+
+	msg := decodeRecord(record, config, decode || redact)
+	if redact {
+		redactMessage(&msg, config.Redaction)
+	}
+
+	return msg
+}
+
+func decodeRecord(record kgo.Record, config Config, decode bool) Message {
+	key, keyDecodeErr := decodeKey(record.Key, config)
+	if keyDecodeErr != nil {
+		key = textOrHex(record.Key)
+	}
+
 	msg := Message{
-		Metadata:  utils.GetRecordMetadata(record),
-		Topic:     record.Topic,
-		Offset:    record.Offset,
-		Partition: record.Partition,
-		Timestamp: record.Timestamp,
-		Key:       string(record.Key),
-		Value:     record.Value,
+		Metadata:     getMetadataFromRecord(record),
+		Topic:        record.Topic,
+		Offset:       record.Offset,
+		Partition:    record.Partition,
+		Key:          key,
+		RawKey:       record.Key,
+		KeyDecodeErr: keyDecodeErr,
+		Headers:      getHeadersFromRecord(record.Headers, config.HeaderRules),
+		Value:        record.Value,
+		RawValue:     record.Value,
 	}
 
-	if len(record.Value) == 0 || !decode || config.Encoding == Raw {
+	if len(record.Value) == 0 || !decode {
+		return msg
+	}
+
+	value := record.Value
+	if codec := config.Compression.codec(record.Value); codec != s.NoCompression {
+		decompressed, err := s.Decompress(record.Value, codec)
+		if err != nil {
+			msg.DecodeErr = err
+			msg.DecodeErrFallback = fmt.Sprintf("Hex dump:\n\n%s", s.HexDump(record.Value))
+			return msg
+		}
+
+		value = decompressed
+		msg.Value = decompressed
+		msg.Metadata.ValueCompression = codec.String()
+		msg.Metadata.DecompressedValueSize = len(decompressed)
+	}
+
+	if config.Encoding == Raw {
 		return msg
 	}
 
@@ -101,16 +200,21 @@ func GetMessageFromRecord(record kgo.Record, config Config, decode bool) Message
 
 	switch config.Encoding {
 	case JSON:
-		decodedValueBytes, decodeErr = s.PrettifyJSON(record.Value)
+		decodedValueBytes, decodeErr = s.PrettifyJSON(value)
+		if decodeErr != nil {
+			decodeErrFallback = fmt.Sprintf("Hex dump:\n\n%s", s.HexDump(value))
+		}
 	case Protobuf:
 		if config.Proto == nil {
 			decodeErr = fmt.Errorf("%w: %s", errProtoDescriptorNil, unexpectedErrorMessage)
 		} else {
-			decodedValueBytes, decodeErr = s.TranscodeProto(record.Value, config.Proto.MsgDescriptor)
+			decodedValueBytes, decodeErr = s.TranscodeProto(value, config.Proto.MsgDescriptor, config.Proto.Resolver, config.Proto.JSONOptions)
 			if decodeErr != nil {
-				rawDecodedBytes, rawDecodeErr := s.DecodeRaw(record.Value)
+				rawDecodedBytes, rawDecodeErr := decodeRawWithGuesses(value, config.Proto.RawDecodeFormat)
 				if rawDecodeErr == nil {
-					decodeErrFallback = fmt.Sprintf("Raw decoded value: \n\n%s", rawDecodedBytes)
+					decodeErrFallback = fmt.Sprintf("Raw decoded value (annotated with plausible interpretations):\n\n%s", rawDecodedBytes)
+				} else {
+					decodeErrFallback = fmt.Sprintf("Hex dump:\n\n%s", s.HexDump(value))
 				}
 			}
 		}
@@ -121,11 +225,31 @@ func GetMessageFromRecord(record kgo.Record, config Config, decode bool) Message
 		msg.DecodeErrFallback = decodeErrFallback
 	} else {
 		msg.Value = decodedValueBytes
+		msg.Violations = getViolations(value, config)
 	}
 
 	return msg
 }
 
+func decodeRawWithGuesses(data []byte, format RawDecodeFormat) ([]byte, error) {
+	if format == RawDecodeJSON {
+		return s.DecodeRawJSON(data)
+	}
+
+	return s.DecodeRawAnnotated(data)
+}
+
+// HeadersDisplay returns a human-readable rendering of the message's headers.
+func (m Message) HeadersDisplay() string {
+	return getHeadersDisplay(m.Headers)
+}
+
+// ViolationsDisplay returns a human-readable rendering of the ways in which the
+// message's value violates the profile's validation rules.
+func (m Message) ViolationsDisplay() string {
+	return getViolationsDisplay(m.Violations)
+}
+
 func (m Message) Title() string {
 	return m.Key
 }
@@ -141,7 +265,17 @@ func (m Message) Description() string {
 		decodeErrorMarker = " (e)"
 	}
 
-	return fmt.Sprintf("offset: %d, partition: %d%s%s", m.Offset, m.Partition, decodeErrorMarker, tombstoneMarker)
+	var keyDecodeErrorMarker string
+	if m.KeyDecodeErr != nil {
+		keyDecodeErrorMarker = " (ke)"
+	}
+
+	var violationsMarker string
+	if len(m.Violations) > 0 {
+		violationsMarker = " (v)"
+	}
+
+	return fmt.Sprintf("offset: %d, partition: %d%s%s%s%s", m.Offset, m.Partition, keyDecodeErrorMarker, decodeErrorMarker, violationsMarker, tombstoneMarker)
 }
 
 func (m Message) FilterValue() string {
@@ -0,0 +1,109 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dhth/kplay/internal/utils"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+const metadataKeyPadding = 20
+
+type TimestampType string
+
+const (
+	CreateTime    TimestampType = "CreateTime"
+	LogAppendTime TimestampType = "LogAppendTime"
+	NoTimestamp   TimestampType = "NoTimestampType"
+)
+
+type Metadata struct {
+	Timestamp     time.Time     `json:"timestamp"`
+	TimestampType TimestampType `json:"timestamp_type"`
+	LeaderEpoch   int32         `json:"leader_epoch"`
+	ProducerID    int64         `json:"producer_id"`
+	ProducerEpoch int16         `json:"producer_epoch"`
+	ValueSize     int           `json:"value_size"`
+	Compression   string        `json:"compression"`
+	// ValueCompression and DecompressedValueSize are only set when the value
+	// was compressed by its producer, and was decompressed before decoding.
+	ValueCompression      string `json:"value_compression,omitempty"`
+	DecompressedValueSize int    `json:"decompressed_value_size,omitempty"`
+	// Redactions holds the redaction rules that were applied to the value.
+	Redactions []string `json:"redactions,omitempty"`
+}
+
+func getMetadataFromRecord(record kgo.Record) Metadata {
+	return Metadata{
+		Timestamp:     record.Timestamp,
+		TimestampType: getTimestampType(record.Attrs),
+		LeaderEpoch:   record.LeaderEpoch,
+		ProducerID:    record.ProducerID,
+		ProducerEpoch: record.ProducerEpoch,
+		ValueSize:     len(record.Value),
+		Compression:   getCompression(record.Attrs),
+	}
+}
+
+func getTimestampType(attrs kgo.RecordAttrs) TimestampType {
+	switch attrs.TimestampType() {
+	case 0:
+		return CreateTime
+	case 1:
+		return LogAppendTime
+	default:
+		return NoTimestamp
+	}
+}
+
+func getCompression(attrs kgo.RecordAttrs) string {
+	switch attrs.CompressionType() {
+	case 0:
+		return "none"
+	case 1:
+		return "gzip"
+	case 2:
+		return "snappy"
+	case 3:
+		return "lz4"
+	case 4:
+		return "zstd"
+	default:
+		return "unknown"
+	}
+}
+
+// MetadataDisplay returns a human-readable rendering of the message's
+// metadata.
+func (m Message) MetadataDisplay() string {
+	var lines []string // nolint:prealloc
+	lines = append(lines, metadataLine("offset", fmt.Sprintf("%d", m.Offset)))
+	if len(m.Key) > 0 {
+		lines = append(lines, metadataLine("key", m.Key))
+	}
+	if m.KeyDecodeErr != nil {
+		lines = append(lines, metadataLine("key decode error", m.KeyDecodeErr.Error()))
+	}
+	lines = append(lines, metadataLine("timestamp", fmt.Sprintf("%s (%s)", m.Metadata.Timestamp, m.Metadata.TimestampType)))
+	lines = append(lines, metadataLine("partition", fmt.Sprintf("%d", m.Partition)))
+	lines = append(lines, metadataLine("leader epoch", fmt.Sprintf("%d", m.Metadata.LeaderEpoch)))
+	lines = append(lines, metadataLine("producer id", fmt.Sprintf("%d", m.Metadata.ProducerID)))
+	lines = append(lines, metadataLine("producer epoch", fmt.Sprintf("%d", m.Metadata.ProducerEpoch)))
+	lines = append(lines, metadataLine("value size", utils.HumanReadableBytes(uint64(m.Metadata.ValueSize))))
+	lines = append(lines, metadataLine("compression", m.Metadata.Compression))
+	if m.Metadata.ValueCompression != "" {
+		lines = append(lines, metadataLine("value compression", m.Metadata.ValueCompression))
+		lines = append(lines, metadataLine("decompressed size", utils.HumanReadableBytes(uint64(m.Metadata.DecompressedValueSize))))
+	}
+	if len(m.Metadata.Redactions) > 0 {
+		lines = append(lines, metadataLine("redacted", strings.Join(m.Metadata.Redactions, ", ")))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func metadataLine(name, value string) string {
+	return fmt.Sprintf("- %s %s", utils.RightPadTrim(name, metadataKeyPadding), value)
+}
@@ -0,0 +1,108 @@
+package types
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+func TestGetMetadataFromRecord(t *testing.T) {
+	// GIVEN
+	timestamp := time.Date(2025, 4, 6, 11, 18, 3, 0, time.UTC)
+	record := kgo.Record{
+		Value:         []byte(`{"id": 1}`),
+		Timestamp:     timestamp,
+		LeaderEpoch:   3,
+		ProducerID:    1001,
+		ProducerEpoch: 2,
+	}
+
+	// WHEN
+	got := getMetadataFromRecord(record)
+
+	// THEN
+	expected := Metadata{
+		Timestamp:     timestamp,
+		TimestampType: CreateTime,
+		LeaderEpoch:   3,
+		ProducerID:    1001,
+		ProducerEpoch: 2,
+		ValueSize:     9,
+		Compression:   "none",
+	}
+	assert.Equal(t, expected, got)
+}
+
+func TestSerializedMessageContainsStructuredMetadata(t *testing.T) {
+	// GIVEN
+	record := kgo.Record{
+		Key:         []byte("some-key"),
+		Value:       []byte(`{"id": 1}`),
+		Timestamp:   time.Date(2025, 4, 6, 11, 18, 3, 0, time.UTC),
+		Partition:   1,
+		Offset:      42,
+		LeaderEpoch: 3,
+		ProducerID:  -1,
+	}
+	msg := GetMessageFromRecord(record, Config{Encoding: JSON}, true)
+
+	// WHEN
+	jsonBytes, err := json.Marshal(msg.ToSerializable())
+
+	// THEN
+	require.NoError(t, err)
+	var got struct {
+		Metadata map[string]any `json:"metadata"`
+	}
+	require.NoError(t, json.Unmarshal(jsonBytes, &got))
+	assert.Equal(t, "2025-04-06T11:18:03Z", got.Metadata["timestamp"])
+	assert.Equal(t, "CreateTime", got.Metadata["timestamp_type"])
+	assert.InDelta(t, 3, got.Metadata["leader_epoch"], 0)
+	assert.InDelta(t, -1, got.Metadata["producer_id"], 0)
+	assert.InDelta(t, 9, got.Metadata["value_size"], 0)
+	assert.Equal(t, "none", got.Metadata["compression"])
+}
+
+func TestGetMessageFromRecordDecompressesValue(t *testing.T) {
+	// GIVEN
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	_, err := writer.Write([]byte(`{"id":1}`))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+	record := kgo.Record{
+		Value: buf.Bytes(),
+	}
+
+	// WHEN
+	msg := GetMessageFromRecord(record, Config{Encoding: JSON, Compression: AutoValueCompression}, true)
+
+	// THEN
+	require.NoError(t, msg.DecodeErr)
+	assert.Equal(t, "{\n  \"id\": 1\n}", string(msg.Value))
+	assert.Equal(t, "gzip", msg.Metadata.ValueCompression)
+	assert.Equal(t, len(record.Value), msg.Metadata.ValueSize)
+	assert.Equal(t, 8, msg.Metadata.DecompressedValueSize)
+}
+
+func TestGetMessageFromRecordFallsBackOnDecompressionError(t *testing.T) {
+	// GIVEN
+	record := kgo.Record{
+		Value: []byte(`{"id":1}`),
+	}
+
+	// WHEN
+	msg := GetMessageFromRecord(record, Config{Encoding: JSON, Compression: ZstdValueCompression}, true)
+
+	// THEN
+	require.Error(t, msg.DecodeErr)
+	assert.True(t, strings.HasPrefix(msg.DecodeErrFallback, "Hex dump:\n\n"), "fallback: %q", msg.DecodeErrFallback)
+	assert.Empty(t, msg.Metadata.ValueCompression)
+}
@@ -0,0 +1,58 @@
+package types
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// RawMessage holds the original bytes of a message's key, value, and headers,
+// as they were fetched from kafka (ie, before any decompression or decoding).
+// Byte fields are base64 encoded when marshalled to JSON; a null field means
+// the record didn't have it at all, which is different from it being empty.
+type RawMessage struct {
+	Topic     string      `json:"topic"`
+	Partition int32       `json:"partition"`
+	Offset    int64       `json:"offset"`
+	Timestamp time.Time   `json:"timestamp"`
+	Key       []byte      `json:"key"`
+	Value     []byte      `json:"value"`
+	Headers   []RawHeader `json:"headers"`
+	// the bytes of redacted values (and the headers of messages with such
+	// values) are withheld, so that sensitive fields never leave kplay
+	ValueWithheld   bool `json:"value_withheld,omitempty"`
+	HeadersWithheld bool `json:"headers_withheld,omitempty"`
+}
+
+type RawHeader struct {
+	Key   string `json:"key"`
+	Value []byte `json:"value"`
+}
+
+func (m Message) ToRaw() RawMessage {
+	headers := make([]RawHeader, len(m.Headers))
+	for i, h := range m.Headers {
+		headers[i] = RawHeader{Key: h.Key}
+		if !m.Redacted {
+			headers[i].Value = h.RawValue
+		}
+	}
+
+	return RawMessage{
+		Topic:     m.Topic,
+		Partition: m.Partition,
+		Offset:    m.Offset,
+		Timestamp: m.Metadata.Timestamp,
+		Key:       m.RawKey,
+		Value:     m.RawValue,
+		Headers:   headers,
+		// redaction discards the raw value, while leaving the (redacted) decoded
+		// one in place
+		ValueWithheld:   m.Redacted,
+		HeadersWithheld: m.Redacted && len(m.Headers) > 0,
+	}
+}
+
+// GetRawDetails returns the message's raw bytes as a JSON envelope.
+func (m Message) GetRawDetails() ([]byte, error) {
+	return json.MarshalIndent(m.ToRaw(), "", "  ")
+}
@@ -0,0 +1,120 @@
+package types
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+func TestRawDetailsAreLossless(t *testing.T) {
+	// GIVEN
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	_, err := writer.Write([]byte(`{"id":1}`))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+	record := kgo.Record{
+		Topic:     "orders",
+		Partition: 2,
+		Offset:    12,
+		Timestamp: time.Date(2025, 4, 6, 11, 18, 3, 0, time.UTC),
+		Key:       []byte{0x00, 0xff, 0x10},
+		Value:     buf.Bytes(),
+		Headers: []kgo.RecordHeader{
+			{Key: "trace-id", Value: []byte("abc")},
+			{Key: "empty", Value: []byte{}},
+			{Key: "null"},
+		},
+	}
+	msg := GetMessageFromRecord(record, Config{Encoding: JSON, Compression: AutoValueCompression}, true)
+
+	// WHEN
+	rawDetails, err := msg.GetRawDetails()
+
+	// THEN
+	require.NoError(t, err)
+	var got RawMessage
+	require.NoError(t, json.Unmarshal(rawDetails, &got))
+	assert.Equal(t, "orders", got.Topic)
+	assert.Equal(t, int32(2), got.Partition)
+	assert.Equal(t, int64(12), got.Offset)
+	assert.True(t, record.Timestamp.Equal(got.Timestamp))
+	assert.Equal(t, record.Key, got.Key)
+	assert.Equal(t, record.Value, got.Value)
+	assert.False(t, got.ValueWithheld)
+	require.Len(t, got.Headers, 3)
+	assert.Equal(t, RawHeader{Key: "trace-id", Value: []byte("abc")}, got.Headers[0])
+	assert.Equal(t, []byte{}, got.Headers[1].Value)
+	assert.Nil(t, got.Headers[2].Value)
+}
+
+func TestRawDetailsDistinguishNullFields(t *testing.T) {
+	// GIVEN
+	record := kgo.Record{
+		Value: []byte{},
+	}
+	msg := GetMessageFromRecord(record, Config{Encoding: JSON}, true)
+
+	// WHEN
+	rawDetails, err := msg.GetRawDetails()
+
+	// THEN
+	require.NoError(t, err)
+	var got map[string]any
+	require.NoError(t, json.Unmarshal(rawDetails, &got))
+	assert.Nil(t, got["key"])
+	assert.Empty(t, got["value"])
+	assert.NotNil(t, got["value"])
+	assert.NotContains(t, got, "value_withheld")
+}
+
+func TestRawDetailsWithholdRedactedValues(t *testing.T) {
+	// GIVEN
+	record := kgo.Record{
+		Key:   []byte("user-1"),
+		Value: []byte(`{"id": 1, "email": "user@example.com"}`),
+		Headers: []kgo.RecordHeader{
+			{Key: "email", Value: []byte("user@example.com")},
+		},
+	}
+	msg := GetMessageFromRecord(record, getTestRedactionConfig(t), true)
+
+	// WHEN
+	raw := msg.ToRaw()
+	rawDetails, err := msg.GetRawDetails()
+
+	// THEN
+	require.NoError(t, err)
+	assert.Equal(t, []byte("user-1"), raw.Key)
+	assert.Nil(t, raw.Value)
+	assert.True(t, raw.ValueWithheld)
+	assert.Equal(t, []RawHeader{{Key: "email"}}, raw.Headers)
+	assert.True(t, raw.HeadersWithheld)
+	assert.NotContains(t, string(rawDetails), "dXNlckBleGFtcGxlLmNvbQ")
+}
+
+func TestRawDetailsOfTombstonesAreNotWithheldForRedactionProfiles(t *testing.T) {
+	// GIVEN
+	record := kgo.Record{
+		Key: []byte("user-1"),
+		Headers: []kgo.RecordHeader{
+			{Key: "source", Value: []byte("billing")},
+		},
+	}
+	msg := GetMessageFromRecord(record, getTestRedactionConfig(t), true)
+
+	// WHEN
+	raw := msg.ToRaw()
+
+	// THEN
+	assert.False(t, msg.Redacted)
+	assert.False(t, raw.ValueWithheld)
+	assert.False(t, raw.HeadersWithheld)
+	assert.Equal(t, []RawHeader{{Key: "source", Value: []byte("billing")}}, raw.Headers)
+}
@@ -0,0 +1,82 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+
+	s "github.com/dhth/kplay/internal/serde"
+)
+
+const (
+	redactionWithheldNote = "Value withheld, since it couldn't be redacted as per the profile's redaction rules"
+	violationWithheldNote = "details withheld, since they may contain unredacted values"
+)
+
+func ValidateRedactionActionValue(value string) (s.RedactionAction, error) {
+	switch value {
+	case "mask":
+		return s.RedactionMask, nil
+	case "hash":
+		return s.RedactionHash, nil
+	case "drop":
+		return s.RedactionDrop, nil
+	default:
+		return s.RedactionMask, fmt.Errorf("redaction action is incorrect; possible values: [mask, hash, drop]")
+	}
+}
+
+// redactMessage applies redaction rules to a message's decoded value. The raw
+// bytes of the value are discarded, and values that couldn't be decoded (and
+// thus couldn't be redacted) are withheld altogether, so that sensitive fields
+// never leave kplay. Violations are computed on the unredacted value, so only
+// their locations are kept.
+func redactMessage(msg *Message, rules []s.RedactionRule) {
+	if len(rules) == 0 {
+		return
+	}
+
+	msg.RawValue = nil
+
+	if len(msg.Value) == 0 {
+		return
+	}
+
+	msg.Redacted = true
+	msg.Violations = withholdViolationDetails(msg.Violations)
+
+	if msg.DecodeErr != nil {
+		msg.Value = []byte(redactionWithheldNote)
+		msg.DecodeErrFallback = redactionWithheldNote
+		return
+	}
+
+	redacted, applied, err := s.RedactJSON(msg.Value, rules)
+	if err != nil {
+		msg.DecodeErr = err
+		msg.Value = []byte(redactionWithheldNote)
+		msg.DecodeErrFallback = redactionWithheldNote
+		return
+	}
+
+	msg.Value = redacted
+	for _, rule := range applied {
+		msg.Metadata.Redactions = append(msg.Metadata.Redactions, rule.String())
+	}
+}
+
+// withholdViolationDetails keeps the location of every violation (the part
+// before the first ": "), and drops the details, which can quote the offending
+// value (eg. json schema's "enum" and "pattern" errors).
+func withholdViolationDetails(violations []string) []string {
+	if len(violations) == 0 {
+		return violations
+	}
+
+	withheld := make([]string, len(violations))
+	for i, v := range violations {
+		location, _, _ := strings.Cut(v, ": ")
+		withheld[i] = fmt.Sprintf("%s: %s", location, violationWithheldNote)
+	}
+
+	return withheld
+}
@@ -0,0 +1,86 @@
+package types
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	s "github.com/dhth/kplay/internal/serde"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+func getTestRedactionConfig(t *testing.T) Config {
+	t.Helper()
+
+	segments, err := s.ParseRedactionPath("email", nil)
+	require.NoError(t, err)
+
+	return Config{
+		Encoding: JSON,
+		Redaction: []s.RedactionRule{
+			{Path: "email", Segments: segments, Action: s.RedactionMask},
+		},
+	}
+}
+
+func TestGetMessageFromRecordRedactsValue(t *testing.T) {
+	// GIVEN
+	record := kgo.Record{
+		Value: []byte(`{"id": 1, "email": "user@example.com"}`),
+	}
+
+	// WHEN
+	msg := GetMessageFromRecord(record, getTestRedactionConfig(t), false)
+
+	// THEN
+	require.NoError(t, msg.DecodeErr)
+	assert.Equal(t, "{\n  \"id\": 1,\n  \"email\": \"****\"\n}", string(msg.Value))
+	assert.Nil(t, msg.RawValue)
+	assert.True(t, msg.Redacted)
+	assert.Equal(t, []string{"email (mask)"}, msg.Metadata.Redactions)
+	assert.Nil(t, msg.ToSerializable().HexDump)
+}
+
+func TestGetMessageFromRecordWithholdsValuesThatCantBeRedacted(t *testing.T) {
+	// GIVEN
+	record := kgo.Record{
+		Value: []byte(`{"id": 1, "email": "user@example.com"`),
+	}
+
+	// WHEN
+	msg := GetMessageFromRecord(record, getTestRedactionConfig(t), true)
+
+	// THEN
+	require.Error(t, msg.DecodeErr)
+	assert.NotContains(t, string(msg.Value), "user@example.com")
+	assert.NotContains(t, msg.DecodeErrFallback, "user@example.com")
+	assert.NotContains(t, msg.GetDetails(), "user@example.com")
+	assert.Nil(t, msg.RawValue)
+	assert.True(t, msg.Redacted)
+	assert.Empty(t, msg.Metadata.Redactions)
+}
+
+func TestGetMessageFromRecordWithholdsViolationDetailsWhenRedacting(t *testing.T) {
+	// GIVEN
+	schemaPath := filepath.Join(t.TempDir(), "schema.json")
+	schemaJSON := `{"type": "object", "properties": {"email": {"type": "string", "pattern": "^[a-z]+@corp\\.com$"}}}`
+	require.NoError(t, os.WriteFile(schemaPath, []byte(schemaJSON), 0o644))
+	schema, err := s.CompileJSONSchema(schemaPath)
+	require.NoError(t, err)
+
+	config := getTestRedactionConfig(t)
+	config.Validation = &ValidationConfig{JSONSchemaFile: schemaPath, JSONSchema: schema}
+	record := kgo.Record{
+		Value: []byte(`{"id": 1, "email": "user@example.com"}`),
+	}
+
+	// WHEN
+	msg := GetMessageFromRecord(record, config, false)
+
+	// THEN
+	require.NoError(t, msg.DecodeErr)
+	assert.Equal(t, []string{"/email: details withheld, since they may contain unredacted values"}, msg.Violations)
+	assert.NotContains(t, msg.GetDetails(), "user@example.com")
+}
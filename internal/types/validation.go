@@ -0,0 +1,79 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+
+	s "github.com/dhth/kplay/internal/serde"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+type ValidationConfig struct {
+	JSONSchemaFile      string
+	JSONSchema          *jsonschema.Schema
+	Proto               s.ProtoValidationOptions
+	RequiredFieldOption string
+}
+
+func (vc ValidationConfig) Display() string {
+	var checks []string
+	if vc.JSONSchema != nil {
+		checks = append(checks, fmt.Sprintf("json schema (%s)", vc.JSONSchemaFile))
+	}
+	if vc.Proto.RejectUnknownFields {
+		checks = append(checks, "unknown fields")
+	}
+	if vc.Proto.CheckRequiredFields {
+		if vc.RequiredFieldOption != "" {
+			checks = append(checks, fmt.Sprintf("required fields (option: %s)", vc.RequiredFieldOption))
+		} else {
+			checks = append(checks, "required fields")
+		}
+	}
+
+	if len(checks) == 0 {
+		return NotProvided
+	}
+
+	return strings.Join(checks, ", ")
+}
+
+// getViolations validates a message's value as per the config's validation
+// rules. Failing to run the validation counts as a violation as well, since
+// the value couldn't be verified to match the contract.
+func getViolations(value []byte, config Config) []string {
+	if config.Validation == nil {
+		return nil
+	}
+
+	var violations []string
+	var err error
+
+	switch config.Encoding {
+	case JSON:
+		if config.Validation.JSONSchema == nil {
+			return nil
+		}
+		violations, err = s.ValidateJSON(value, config.Validation.JSONSchema)
+	case Protobuf:
+		if config.Proto == nil {
+			return nil
+		}
+		violations, err = s.ValidateProto(value, config.Proto.MsgDescriptor, config.Proto.Resolver, config.Validation.Proto)
+	}
+
+	if err != nil {
+		return []string{fmt.Sprintf("couldn't validate value: %s", err.Error())}
+	}
+
+	return violations
+}
+
+func getViolationsDisplay(violations []string) string {
+	lines := make([]string, len(violations))
+	for i, v := range violations {
+		lines[i] = fmt.Sprintf("- %s", v)
+	}
+
+	return strings.Join(lines, "\n")
+}
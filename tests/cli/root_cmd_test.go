@@ -83,6 +83,375 @@ func TestCLI(t *testing.T) {
 		assert.NoError(t, err, "output:\n%s", o)
 	})
 
+	t.Run("Parsing profile with key encoding works", func(t *testing.T) {
+		// GIVEN
+		// WHEN
+		configPath := "assets/config-key-encoding.yml"
+		c := exec.Command(binPath, "tui", "local", "--config-path", configPath, "--debug")
+		o, err := c.CombinedOutput()
+		// THEN
+		if err != nil {
+			fmt.Printf("output:\n%s", o)
+		}
+		assert.NoError(t, err, "output:\n%s", o)
+		assert.Contains(t, string(o), "sample.ApplicationState")
+	})
+
+	t.Run("Parsing profile with header rules works", func(t *testing.T) {
+		// GIVEN
+		// WHEN
+		configPath := "assets/config-header-rules.yml"
+		c := exec.Command(binPath, "tui", "local", "--config-path", configPath, "--debug")
+		o, err := c.CombinedOutput()
+		// THEN
+		if err != nil {
+			fmt.Printf("output:\n%s", o)
+		}
+		assert.NoError(t, err, "output:\n%s", o)
+		assert.Contains(t, string(o), "retry-count")
+	})
+
+	t.Run("Parsing profile with protobuf JSON options works", func(t *testing.T) {
+		// GIVEN
+		// WHEN
+		configPath := "assets/config-protobuf-json-options.yml"
+		c := exec.Command(binPath, "tui", "local", "--config-path", configPath, "--debug")
+		o, err := c.CombinedOutput()
+		// THEN
+		if err != nil {
+			fmt.Printf("output:\n%s", o)
+		}
+		assert.NoError(t, err, "output:\n%s", o)
+		assert.Contains(t, string(o), "json options: emit unpopulated, proto names")
+	})
+
+	t.Run("Parsing profiles with validation works", func(t *testing.T) {
+		// GIVEN
+		configPath := "assets/config-validation.yml"
+		testCases := []struct {
+			profile  string
+			expected string
+		}{
+			{profile: "json", expected: "json schema (assets/sample_schema.json)"},
+			{profile: "protobuf", expected: "unknown fields, required fields"},
+		}
+
+		for _, tt := range testCases {
+			// WHEN
+			c := exec.Command(binPath, "tui", tt.profile, "--config-path", configPath, "--debug")
+			o, err := c.CombinedOutput()
+			// THEN
+			if err != nil {
+				fmt.Printf("output:\n%s", o)
+			}
+			assert.NoError(t, err, "output:\n%s", o)
+			assert.Contains(t, string(o), tt.expected)
+		}
+	})
+
+	t.Run("Parsing profile with validation options for another encoding fails", func(t *testing.T) {
+		// GIVEN
+		// WHEN
+		configPath := "assets/config-validation.yml"
+		c := exec.Command(binPath, "tui", "incorrect", "--config-path", configPath, "--debug")
+		o, err := c.CombinedOutput()
+
+		// THEN
+		var exitError *exec.ExitError
+		if errors.As(err, &exitError) {
+			exitCode := exitError.ExitCode()
+			require.Equal(t, 1, exitCode, "exit code is not correct: got %d, expected: 1; output:\n%s", exitCode, o)
+			assert.Contains(t, string(o), "validation option is not applicable to this encoding format")
+		} else {
+			t.Fatalf("couldn't get error code")
+		}
+	})
+
+	t.Run("Parsing profile with value compression works", func(t *testing.T) {
+		// GIVEN
+		// WHEN
+		configPath := "assets/config-value-compression.yml"
+		c := exec.Command(binPath, "tui", "local", "--config-path", configPath, "--debug")
+		o, err := c.CombinedOutput()
+		// THEN
+		if err != nil {
+			fmt.Printf("output:\n%s", o)
+		}
+		assert.NoError(t, err, "output:\n%s", o)
+		assert.Contains(t, string(o), "value compression       auto")
+	})
+
+	t.Run("Parsing profile with incorrect value compression fails", func(t *testing.T) {
+		// GIVEN
+		// WHEN
+		configPath := "assets/config-value-compression.yml"
+		c := exec.Command(binPath, "tui", "incorrect", "--config-path", configPath, "--debug")
+		o, err := c.CombinedOutput()
+
+		// THEN
+		var exitError *exec.ExitError
+		if errors.As(err, &exitError) {
+			exitCode := exitError.ExitCode()
+			require.Equal(t, 1, exitCode, "exit code is not correct: got %d, expected: 1; output:\n%s", exitCode, o)
+			assert.Contains(t, string(o), "value compression is incorrect")
+		} else {
+			t.Fatalf("couldn't get error code")
+		}
+	})
+
+	t.Run("Parsing profiles with redaction rules works", func(t *testing.T) {
+		// GIVEN
+		configPath := "assets/config-redaction.yml"
+		testCases := []struct {
+			profile  string
+			expected string
+		}{
+			{profile: "json", expected: "customer.email (mask)"},
+			{profile: "protobuf", expected: "customDomain (drop)"},
+		}
+
+		for _, tt := range testCases {
+			// WHEN
+			c := exec.Command(binPath, "tui", tt.profile, "--config-path", configPath, "--debug")
+			o, err := c.CombinedOutput()
+			// THEN
+			if err != nil {
+				fmt.Printf("output:\n%s", o)
+			}
+			assert.NoError(t, err, "output:\n%s", o)
+			assert.Contains(t, string(o), tt.expected)
+		}
+	})
+
+	t.Run("Providing a redaction hash key works", func(t *testing.T) {
+		// GIVEN
+		configPath := "assets/config-redaction.yml"
+
+		// WHEN
+		c := exec.Command(binPath, "tui", "json", "--config-path", configPath, "--debug")
+		c.Env = append(os.Environ(), "KPLAY_TEST_REDACTION_HASH_KEY=secret")
+		o, err := c.CombinedOutput()
+		// THEN
+		if err != nil {
+			fmt.Printf("output:\n%s", o)
+		}
+		assert.NoError(t, err, "output:\n%s", o)
+		assert.Contains(t, string(o), "$.payments.cardNumber (hash, keyed)")
+	})
+
+	t.Run("Parsing profiles with incorrect redaction rules fails", func(t *testing.T) {
+		// GIVEN
+		configPath := "assets/config-redaction.yml"
+		testCases := []struct {
+			profile  string
+			expected string
+		}{
+			{profile: "unknown-proto-field", expected: "field not found in protobuf descriptor"},
+			{profile: "raw", expected: "redaction is not supported for this encoding format"},
+			{profile: "proto-unknown-fields", expected: "redaction can't be used along with showUnknownFields"},
+		}
+
+		for _, tt := range testCases {
+			// WHEN
+			c := exec.Command(binPath, "tui", tt.profile, "--config-path", configPath, "--debug")
+			o, err := c.CombinedOutput()
+
+			// THEN
+			var exitError *exec.ExitError
+			if errors.As(err, &exitError) {
+				exitCode := exitError.ExitCode()
+				require.Equal(t, 1, exitCode, "exit code is not correct: got %d, expected: 1; output:\n%s", exitCode, o)
+				assert.Contains(t, string(o), tt.expected)
+			} else {
+				t.Fatalf("couldn't get error code")
+			}
+		}
+	})
+
+	t.Run("Providing a filter expression works", func(t *testing.T) {
+		// GIVEN
+		filterExpr := `value.status == "FAILED" && headers["source"] == "billing"`
+
+		for _, command := range []string{"scan", "tui", "serve"} {
+			// WHEN
+			c := exec.Command(binPath, command, "local", "--config-path", correctConfigPath, "--filter", filterExpr, "--debug")
+			o, err := c.CombinedOutput()
+			// THEN
+			if err != nil {
+				fmt.Printf("output:\n%s", o)
+			}
+			assert.NoError(t, err, "output:\n%s", o)
+			assert.Contains(t, string(o), filterExpr)
+		}
+	})
+
+	t.Run("Providing header filters to scan works", func(t *testing.T) {
+		// GIVEN
+		// WHEN
+		c := exec.Command(binPath, "scan", "local", "--config-path", correctConfigPath,
+			"--header", "tenant=^acme-", "--has-header", "trace-id", "--header-columns", "tenant,trace-id", "--debug")
+		o, err := c.CombinedOutput()
+		// THEN
+		if err != nil {
+			fmt.Printf("output:\n%s", o)
+		}
+		assert.NoError(t, err, "output:\n%s", o)
+		assert.Contains(t, string(o), "tenant=^acme-")
+		assert.Contains(t, string(o), "trace-id (present)")
+		assert.Contains(t, string(o), "tenant, trace-id")
+	})
+
+	t.Run("Providing an output format to scan works", func(t *testing.T) {
+		// GIVEN
+		for _, format := range []string{"csv", "tsv", "jsonl"} {
+			// WHEN
+			c := exec.Command(binPath, "scan", "local", "--config-path", correctConfigPath, "--format", format, "--single-file", "--debug")
+			o, err := c.CombinedOutput()
+			// THEN
+			if err != nil {
+				fmt.Printf("output:\n%s", o)
+			}
+			assert.NoError(t, err, "output:\n%s", o)
+			assert.Contains(t, string(o), fmt.Sprintf("output format           %s", format))
+		}
+	})
+
+	t.Run("Providing a SQLite database to scan works", func(t *testing.T) {
+		// GIVEN
+		// WHEN
+		c := exec.Command(binPath, "scan", "local", "--config-path", correctConfigPath, "--sqlite", "billing.db", "--debug")
+		o, err := c.CombinedOutput()
+		// THEN
+		if err != nil {
+			fmt.Printf("output:\n%s", o)
+		}
+		assert.NoError(t, err, "output:\n%s", o)
+		assert.Contains(t, string(o), "sqlite database         billing.db")
+	})
+
+	t.Run("Requesting stats from scan works", func(t *testing.T) {
+		// GIVEN
+		// WHEN
+		c := exec.Command(binPath, "scan", "local", "--config-path", correctConfigPath, "--stats", "--debug")
+		o, err := c.CombinedOutput()
+		// THEN
+		if err != nil {
+			fmt.Printf("output:\n%s", o)
+		}
+		assert.NoError(t, err, "output:\n%s", o)
+		assert.Contains(t, string(o), "compute stats           true")
+	})
+
+	t.Run("Debugging infer-schema works", func(t *testing.T) {
+		// GIVEN
+		// WHEN
+		c := exec.Command(binPath, "infer-schema", "local", "--config-path", correctConfigPath, "--num-records", "500", "--debug")
+		o, err := c.CombinedOutput()
+		// THEN
+		if err != nil {
+			fmt.Printf("output:\n%s", o)
+		}
+		assert.NoError(t, err, "output:\n%s", o)
+		assert.Contains(t, string(o), "Schema Inference Behaviours:")
+		assert.Contains(t, string(o), "number of messages      500")
+	})
+
+	t.Run("Scanning with several workers works", func(t *testing.T) {
+		// GIVEN
+		// WHEN
+		c := exec.Command(binPath, "scan", "local", "--config-path", correctConfigPath, "--workers", "8", "--debug")
+		o, err := c.CombinedOutput()
+		// THEN
+		if err != nil {
+			fmt.Printf("output:\n%s", o)
+		}
+		assert.NoError(t, err, "output:\n%s", o)
+		assert.Contains(t, string(o), "workers                 8")
+	})
+
+	t.Run("Sampling a scan works", func(t *testing.T) {
+		// GIVEN
+		// WHEN
+		c := exec.Command(binPath, "scan", "local", "--config-path", correctConfigPath, "--sample", "reservoir:500", "--debug")
+		o, err := c.CombinedOutput()
+		// THEN
+		if err != nil {
+			fmt.Printf("output:\n%s", o)
+		}
+		assert.NoError(t, err, "output:\n%s", o)
+		assert.Contains(t, string(o), "sample                  reservoir of 500 messages")
+	})
+
+	t.Run("Debugging diff works", func(t *testing.T) {
+		// GIVEN
+		// WHEN
+		c := exec.Command(binPath, "diff", "local", "local", "--config-path", correctConfigPath, "--to-offset", "5000", "--sequence-field", "event.seq", "--debug")
+		o, err := c.CombinedOutput()
+		// THEN
+		if err != nil {
+			fmt.Printf("output:\n%s", o)
+		}
+		assert.NoError(t, err, "output:\n%s", o)
+		assert.Contains(t, string(o), "Diff Behaviours:")
+		assert.Contains(t, string(o), "sequence field          event.seq")
+		assert.Contains(t, string(o), "end offset              5000")
+	})
+
+	t.Run("Merging partitions works", func(t *testing.T) {
+		// GIVEN
+		// WHEN
+		c := exec.Command(binPath, "tui", "local", "--config-path", correctConfigPath, "--merge", "--merge-lateness", "30s", "--debug")
+		o, err := c.CombinedOutput()
+		// THEN
+		if err != nil {
+			fmt.Printf("output:\n%s", o)
+		}
+		assert.NoError(t, err, "output:\n%s", o)
+		assert.Contains(t, string(o), "merge partitions        by timestamp, with a lateness window of 30s")
+	})
+
+	t.Run("Debugging snapshot works", func(t *testing.T) {
+		// GIVEN
+		// WHEN
+		c := exec.Command(binPath, "snapshot", "local", "--config-path", correctConfigPath, "--format", "sqlite", "--max-keys-in-memory", "5000", "--debug")
+		o, err := c.CombinedOutput()
+		// THEN
+		if err != nil {
+			fmt.Printf("output:\n%s", o)
+		}
+		assert.NoError(t, err, "output:\n%s", o)
+		assert.Contains(t, string(o), "Snapshot Behaviours:")
+		assert.Contains(t, string(o), "output format           sqlite")
+		assert.Contains(t, string(o), "max keys in memory      5000")
+	})
+
+	t.Run("Saving raw bytes works", func(t *testing.T) {
+		// GIVEN
+		// WHEN
+		c := exec.Command(binPath, "scan", "local", "--config-path", correctConfigPath, "--save-messages", "--save-raw", "--debug")
+		o, err := c.CombinedOutput()
+		// THEN
+		if err != nil {
+			fmt.Printf("output:\n%s", o)
+		}
+		assert.NoError(t, err, "output:\n%s", o)
+		assert.Contains(t, string(o), "save raw bytes          true")
+	})
+
+	t.Run("Providing a save layout works", func(t *testing.T) {
+		// GIVEN
+		// WHEN
+		c := exec.Command(binPath, "tui", "local", "--config-path", correctConfigPath, "--save-path-template", "{{.Topic}}/{{.Date}}/{{.Key}}-{{.Offset}}.{{.Ext}}", "--save-format", "json", "--debug")
+		o, err := c.CombinedOutput()
+		// THEN
+		if err != nil {
+			fmt.Printf("output:\n%s", o)
+		}
+		assert.NoError(t, err, "output:\n%s", o)
+		assert.Contains(t, string(o), "save path template      {{.Topic}}/{{.Date}}/{{.Key}}-{{.Offset}}.{{.Ext}}")
+		assert.Contains(t, string(o), "save format             json")
+	})
+
 	t.Run("Reading config path from environment variable works", func(t *testing.T) {
 		// GIVEN
 		// WHEN
@@ -201,4 +570,299 @@ func TestCLI(t *testing.T) {
 			t.Fatalf("couldn't get error code")
 		}
 	})
+
+	t.Run("Fails for an incorrectly formatted header filter", func(t *testing.T) {
+		// GIVEN
+		// WHEN
+		c := exec.Command(binPath, "scan", "local", "--config-path", correctConfigPath, "--header", "tenant", "--debug")
+		o, err := c.CombinedOutput()
+
+		// THEN
+		var exitError *exec.ExitError
+		if errors.As(err, &exitError) {
+			exitCode := exitError.ExitCode()
+			require.Equal(t, 1, exitCode, "exit code is not correct: got %d, expected: 1; output:\n%s", exitCode, o)
+			assert.Contains(t, string(o), "header filter is not in the format <KEY>=<REGEX>")
+		} else {
+			t.Fatalf("couldn't get error code")
+		}
+	})
+
+	t.Run("Fails for an incorrect scan output format", func(t *testing.T) {
+		// GIVEN
+		// WHEN
+		c := exec.Command(binPath, "scan", "local", "--config-path", correctConfigPath, "--format", "xml", "--debug")
+		o, err := c.CombinedOutput()
+
+		// THEN
+		var exitError *exec.ExitError
+		if errors.As(err, &exitError) {
+			exitCode := exitError.ExitCode()
+			require.Equal(t, 1, exitCode, "exit code is not correct: got %d, expected: 1; output:\n%s", exitCode, o)
+			assert.Contains(t, string(o), "output format is incorrect")
+		} else {
+			t.Fatalf("couldn't get error code")
+		}
+	})
+
+	t.Run("Fails when both single file mode and saving messages are requested", func(t *testing.T) {
+		// GIVEN
+		// WHEN
+		c := exec.Command(binPath, "scan", "local", "--config-path", correctConfigPath, "--single-file", "--save-messages", "--debug")
+		o, err := c.CombinedOutput()
+
+		// THEN
+		var exitError *exec.ExitError
+		if errors.As(err, &exitError) {
+			exitCode := exitError.ExitCode()
+			require.Equal(t, 1, exitCode, "exit code is not correct: got %d, expected: 1; output:\n%s", exitCode, o)
+			assert.Contains(t, string(o), "none of the others can be")
+		} else {
+			t.Fatalf("couldn't get error code")
+		}
+	})
+
+	t.Run("Inferring schema fails for raw encoding", func(t *testing.T) {
+		// GIVEN
+		configPath := "assets/config-raw-encoding.yml"
+
+		// WHEN
+		c := exec.Command(binPath, "infer-schema", "local", "--config-path", configPath, "--debug")
+		o, err := c.CombinedOutput()
+
+		// THEN
+		var exitError *exec.ExitError
+		if errors.As(err, &exitError) {
+			exitCode := exitError.ExitCode()
+			require.Equal(t, 1, exitCode, "exit code is not correct: got %d, expected: 1; output:\n%s", exitCode, o)
+			assert.Contains(t, string(o), "schema inference is only supported for profiles with JSON or protobuf encoding")
+		} else {
+			t.Fatalf("couldn't get error code")
+		}
+	})
+
+	t.Run("Resuming a scan fails for a checkpoint from another topic", func(t *testing.T) {
+		// GIVEN
+		checkpointPath := filepath.Join(t.TempDir(), "scan-1-checkpoint.json")
+		checkpoint := `{"topic": "another-topic", "next_offsets": {"0": 150}, "results_file": "scan-1.csv", "format": "csv"}`
+		require.NoError(t, os.WriteFile(checkpointPath, []byte(checkpoint), 0o644))
+
+		// WHEN
+		c := exec.Command(binPath, "scan", "local", "--config-path", correctConfigPath, "--resume", checkpointPath, "--debug")
+		o, err := c.CombinedOutput()
+
+		// THEN
+		var exitError *exec.ExitError
+		if errors.As(err, &exitError) {
+			exitCode := exitError.ExitCode()
+			require.Equal(t, 1, exitCode, "exit code is not correct: got %d, expected: 1; output:\n%s", exitCode, o)
+			assert.Contains(t, string(o), "checkpoint doesn't belong to the profile's topic")
+		} else {
+			t.Fatalf("couldn't get error code")
+		}
+	})
+
+	t.Run("Scanning fails if workers is 0", func(t *testing.T) {
+		// GIVEN
+		// WHEN
+		c := exec.Command(binPath, "scan", "local", "--config-path", correctConfigPath, "--workers", "0", "--debug")
+		o, err := c.CombinedOutput()
+
+		// THEN
+		var exitError *exec.ExitError
+		if errors.As(err, &exitError) {
+			exitCode := exitError.ExitCode()
+			require.Equal(t, 1, exitCode, "exit code is not correct: got %d, expected: 1; output:\n%s", exitCode, o)
+			assert.Contains(t, string(o), "workers must be greater than 0")
+		} else {
+			t.Fatalf("couldn't get error code")
+		}
+	})
+
+	t.Run("Scanning fails for an invalid sample", func(t *testing.T) {
+		// GIVEN
+		// WHEN
+		c := exec.Command(binPath, "scan", "local", "--config-path", correctConfigPath, "--sample", "every", "--debug")
+		o, err := c.CombinedOutput()
+
+		// THEN
+		var exitError *exec.ExitError
+		if errors.As(err, &exitError) {
+			exitCode := exitError.ExitCode()
+			require.Equal(t, 1, exitCode, "exit code is not correct: got %d, expected: 1; output:\n%s", exitCode, o)
+			assert.Contains(t, string(o), "sample is not in the format <MODE>:<VALUE>")
+		} else {
+			t.Fatalf("couldn't get error code")
+		}
+	})
+
+	t.Run("Sampling evenly spaced offsets fails with a start offset", func(t *testing.T) {
+		// GIVEN
+		// WHEN
+		c := exec.Command(binPath, "scan", "local", "--config-path", correctConfigPath, "--sample", "spaced:10", "--from-offset", "100", "--debug")
+		o, err := c.CombinedOutput()
+
+		// THEN
+		var exitError *exec.ExitError
+		if errors.As(err, &exitError) {
+			exitCode := exitError.ExitCode()
+			require.Equal(t, 1, exitCode, "exit code is not correct: got %d, expected: 1; output:\n%s", exitCode, o)
+			assert.Contains(t, string(o), "can't be combined with a start offset or timestamp")
+		} else {
+			t.Fatalf("couldn't get error code")
+		}
+	})
+
+	t.Run("Duplicate detection flags fail without --detect-duplicates", func(t *testing.T) {
+		// GIVEN
+		// WHEN
+		c := exec.Command(binPath, "scan", "local", "--config-path", correctConfigPath, "--duplicate-id-field", "event.id", "--debug")
+		o, err := c.CombinedOutput()
+
+		// THEN
+		var exitError *exec.ExitError
+		if errors.As(err, &exitError) {
+			exitCode := exitError.ExitCode()
+			require.Equal(t, 1, exitCode, "exit code is not correct: got %d, expected: 1; output:\n%s", exitCode, o)
+			assert.Contains(t, string(o), "require --detect-duplicates")
+		} else {
+			t.Fatalf("couldn't get error code")
+		}
+	})
+
+	t.Run("Diff fails for an invalid end timestamp", func(t *testing.T) {
+		// GIVEN
+		// WHEN
+		c := exec.Command(binPath, "diff", "local", "local", "--config-path", correctConfigPath, "--to-timestamp", "yesterday", "--debug")
+		o, err := c.CombinedOutput()
+
+		// THEN
+		var exitError *exec.ExitError
+		if errors.As(err, &exitError) {
+			exitCode := exitError.ExitCode()
+			require.Equal(t, 1, exitCode, "exit code is not correct: got %d, expected: 1; output:\n%s", exitCode, o)
+			assert.Contains(t, string(o), `invalid value provided for "to timestamp"`)
+		} else {
+			t.Fatalf("couldn't get error code")
+		}
+	})
+
+	t.Run("Merge lateness fails without --merge", func(t *testing.T) {
+		// GIVEN
+		// WHEN
+		c := exec.Command(binPath, "serve", "local", "--config-path", correctConfigPath, "--merge-lateness", "10s", "--debug")
+		o, err := c.CombinedOutput()
+
+		// THEN
+		var exitError *exec.ExitError
+		if errors.As(err, &exitError) {
+			exitCode := exitError.ExitCode()
+			require.Equal(t, 1, exitCode, "exit code is not correct: got %d, expected: 1; output:\n%s", exitCode, o)
+			assert.Contains(t, string(o), "--merge-lateness requires --merge")
+		} else {
+			t.Fatalf("couldn't get error code")
+		}
+	})
+
+	t.Run("Snapshot fails for an invalid format", func(t *testing.T) {
+		// GIVEN
+		// WHEN
+		c := exec.Command(binPath, "snapshot", "local", "--config-path", correctConfigPath, "--format", "csv", "--debug")
+		o, err := c.CombinedOutput()
+
+		// THEN
+		var exitError *exec.ExitError
+		if errors.As(err, &exitError) {
+			exitCode := exitError.ExitCode()
+			require.Equal(t, 1, exitCode, "exit code is not correct: got %d, expected: 1; output:\n%s", exitCode, o)
+			assert.Contains(t, string(o), "output format is incorrect; possible values: [jsonl, sqlite]")
+		} else {
+			t.Fatalf("couldn't get error code")
+		}
+	})
+
+	t.Run("Saving raw bytes fails without --save-messages", func(t *testing.T) {
+		// GIVEN
+		// WHEN
+		c := exec.Command(binPath, "scan", "local", "--config-path", correctConfigPath, "--save-raw", "--debug")
+		o, err := c.CombinedOutput()
+
+		// THEN
+		var exitError *exec.ExitError
+		if errors.As(err, &exitError) {
+			exitCode := exitError.ExitCode()
+			require.Equal(t, 1, exitCode, "exit code is not correct: got %d, expected: 1; output:\n%s", exitCode, o)
+			assert.Contains(t, string(o), "--save-raw requires --save-messages")
+		} else {
+			t.Fatalf("couldn't get error code")
+		}
+	})
+
+	t.Run("Saving messages fails for a save path template outside the messages directory", func(t *testing.T) {
+		// GIVEN
+		// WHEN
+		c := exec.Command(binPath, "scan", "local", "--config-path", correctConfigPath, "--save-messages", "--save-path-template", "../{{.Offset}}.txt", "--debug")
+		o, err := c.CombinedOutput()
+
+		// THEN
+		var exitError *exec.ExitError
+		if errors.As(err, &exitError) {
+			exitCode := exitError.ExitCode()
+			require.Equal(t, 1, exitCode, "exit code is not correct: got %d, expected: 1; output:\n%s", exitCode, o)
+			assert.Contains(t, string(o), "save path template is invalid")
+		} else {
+			t.Fatalf("couldn't get error code")
+		}
+	})
+
+	t.Run("Saving messages fails for an incorrect save format", func(t *testing.T) {
+		// GIVEN
+		// WHEN
+		c := exec.Command(binPath, "tui", "local", "--config-path", correctConfigPath, "--save-format", "yaml", "--debug")
+		o, err := c.CombinedOutput()
+
+		// THEN
+		var exitError *exec.ExitError
+		if errors.As(err, &exitError) {
+			exitCode := exitError.ExitCode()
+			require.Equal(t, 1, exitCode, "exit code is not correct: got %d, expected: 1; output:\n%s", exitCode, o)
+			assert.Contains(t, string(o), "save format is incorrect; possible values: [txt, json, value]")
+		} else {
+			t.Fatalf("couldn't get error code")
+		}
+	})
+
+	t.Run("Save layout flags fail without --save-messages", func(t *testing.T) {
+		// GIVEN
+		// WHEN
+		c := exec.Command(binPath, "scan", "local", "--config-path", correctConfigPath, "--save-format", "json", "--debug")
+		o, err := c.CombinedOutput()
+
+		// THEN
+		var exitError *exec.ExitError
+		if errors.As(err, &exitError) {
+			exitCode := exitError.ExitCode()
+			require.Equal(t, 1, exitCode, "exit code is not correct: got %d, expected: 1; output:\n%s", exitCode, o)
+			assert.Contains(t, string(o), "--save-path-template and --save-format require --save-messages")
+		} else {
+			t.Fatalf("couldn't get error code")
+		}
+	})
+
+	t.Run("Fails for an invalid filter expression", func(t *testing.T) {
+		// GIVEN
+		// WHEN
+		c := exec.Command(binPath, "scan", "local", "--config-path", correctConfigPath, "--filter", "offset + 1", "--debug")
+		o, err := c.CombinedOutput()
+
+		// THEN
+		var exitError *exec.ExitError
+		if errors.As(err, &exitError) {
+			exitCode := exitError.ExitCode()
+			require.Equal(t, 1, exitCode, "exit code is not correct: got %d, expected: 1; output:\n%s", exitCode, o)
+			assert.Contains(t, string(o), "invalid filter expression")
+		} else {
+			t.Fatalf("couldn't get error code")
+		}
+	})
 }